@@ -14,17 +14,24 @@ import (
 	//protocols
 	_ "github.com/go-chassis/go-chassis/client/rest"
 	_ "github.com/go-chassis/go-chassis/server/restful"
+	_ "github.com/go-chassis/go-chassis/client/grpc"
+	_ "github.com/go-chassis/go-chassis/server/grpc"
 	//routers
 	"github.com/go-chassis/go-chassis/core/common"
 	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/handler"
 	"github.com/go-chassis/go-chassis/core/registry"
 	//router
+	_ "github.com/go-chassis/go-chassis/core/router/file"
 	_ "github.com/go-chassis/go-chassis/core/router/servicecomb"
 	//control panel
+	_ "github.com/go-chassis/go-chassis/control/istio"
 	_ "github.com/go-chassis/go-chassis/control/servicecomb"
 	// registry
 	_ "github.com/go-chassis/go-chassis/core/registry/servicecenter"
+	_ "github.com/go-chassis/go-chassis/core/registry/file"
+	_ "github.com/go-chassis/go-chassis/core/registry/aggregation"
+	_ "github.com/go-chassis/go-chassis/core/registry/consul"
 	"github.com/go-chassis/go-chassis/core/server"
 	// prometheus reporter for circuit breaker metrics
 	_ "github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix/reporter"
@@ -141,6 +148,7 @@ func waitingSignal() {
 
 //GracefulShutdown graceful shut down api
 func GracefulShutdown(s os.Signal) {
+	registry.StopSnapshot()
 	if !config.GetRegistratorDisable() {
 		registry.HBService.Stop()
 		openlogging.Info("unregister servers ...")
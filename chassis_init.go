@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/go-chassis/go-archaius"
 	"github.com/go-chassis/go-chassis/bootstrap"
@@ -38,6 +39,7 @@ import (
 	"github.com/go-chassis/go-chassis/pkg/backends/quota"
 	"github.com/go-chassis/go-chassis/pkg/metrics"
 	"github.com/go-chassis/go-chassis/pkg/runtime"
+	"github.com/go-chassis/go-chassis/resilience/rate"
 	"github.com/go-mesh/openlogging"
 )
 
@@ -148,8 +150,9 @@ func (c *chassis) initialize() error {
 		return err
 	}
 	opts := control.Options{
-		Infra:   config.GlobalDefinition.Panel.Infra,
-		Address: config.GlobalDefinition.Panel.Settings["address"],
+		Infra:    config.GlobalDefinition.Panel.Infra,
+		Address:  config.GlobalDefinition.Panel.Settings["address"],
+		Settings: config.GlobalDefinition.Panel.Settings,
 	}
 	if err := control.Init(opts); err != nil {
 		return err
@@ -174,6 +177,18 @@ func initBackendPlugins() error {
 	}); err != nil {
 		return err
 	}
+	timeout, _ := time.ParseDuration(archaius.GetString("cse.flowcontrol.distributed.timeout", ""))
+	if err := rate.InitDistributed(rate.DistributedOptions{
+		Plugin:    archaius.GetString("cse.flowcontrol.distributed.plugin", ""),
+		Address:   archaius.GetString("cse.flowcontrol.distributed.address", ""),
+		Password:  archaius.GetString("cse.flowcontrol.distributed.password", ""),
+		DB:        archaius.GetInt("cse.flowcontrol.distributed.db", 0),
+		Algorithm: archaius.GetString("cse.flowcontrol.distributed.algorithm", rate.AlgorithmSlidingWindow),
+		Prefix:    archaius.GetString("cse.flowcontrol.distributed.prefix", ""),
+		Timeout:   timeout,
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 func (c *chassis) registerSchema(serverName string, structPtr interface{}, opts ...server.RegisterOption) {
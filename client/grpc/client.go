@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/client"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-mesh/openlogging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//Name is the protocol name of grpc client
+const Name = common.ProtocolGRPC
+
+//ErrInvalidReq invalid input
+var ErrInvalidReq = errors.New("schema ID and operation ID must not be empty in grpc invocation")
+
+func init() {
+	client.InstallPlugin(Name, New)
+}
+
+//Client is grpc client holder, one client holds one connection to provider endpoint
+type Client struct {
+	mu   sync.RWMutex
+	c    *conn
+	opts client.Options
+}
+
+//conn counts calls in flight, so that it is closed after they finish when it is replaced
+type conn struct {
+	*grpc.ClientConn
+	calls sync.WaitGroup
+}
+
+//New create a grpc client, the connection is established lazily
+func New(opts client.Options) (client.ProtocolClient, error) {
+	conn, err := newConn(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c:    conn,
+		opts: opts,
+	}, nil
+}
+
+func newConn(opts client.Options) (*conn, error) {
+	var dialOpts []grpc.DialOption
+	if opts.TLSConfig != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(opts.TLSConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	cc, err := grpc.Dial(opts.Endpoint, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{ClientConn: cc}, nil
+}
+
+//acquire return the connection and options, release must be called after the call is finished
+func (c *Client) acquire() (*conn, client.Options) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.c.calls.Add(1)
+	return c.c, c.opts
+}
+
+//Call invoke the grpc method /{schema ID}/{operation ID},
+//inv.Args is the request message and rsp must be a pointer of response message.
+//the connection is bound to the endpoint of client, so addr must be empty or the same endpoint
+func (c *Client) Call(ctx context.Context, addr string, inv *invocation.Invocation, rsp interface{}) error {
+	if inv.SchemaID == "" || inv.OperationID == "" {
+		return ErrInvalidReq
+	}
+	cc, opts := c.acquire()
+	defer cc.calls.Done()
+	if addr != "" && addr != opts.Endpoint {
+		return fmt.Errorf("grpc client of [%s] can not call [%s]", opts.Endpoint, addr)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(common.FromContext(ctx)))
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	err := cc.Invoke(ctx, "/"+inv.SchemaID+"/"+inv.OperationID, inv.Args, rsp)
+	if err == nil {
+		return nil
+	}
+	switch status.Code(err) {
+	case codes.Canceled:
+		return client.ErrCanceled
+	case codes.Unavailable:
+		return client.TransportFailure{Message: err.Error()}
+	default:
+		return err
+	}
+}
+
+func (c *Client) String() string {
+	return "grpc_client"
+}
+
+//Close close the connection
+func (c *Client) Close() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.c.Close()
+}
+
+//ReloadConfigs reload configs for timeout and tls,
+//connection is replaced if tls config is changed, the old one is closed after calls in flight finish
+func (c *Client) ReloadConfigs(opts client.Options) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts = client.EqualOpts(c.opts, opts)
+	if opts.TLSConfig == nil {
+		return
+	}
+	conn, err := newConn(c.opts)
+	if err != nil {
+		openlogging.Error("can not reload grpc connection: " + err.Error())
+		return
+	}
+	old := c.c
+	c.c = conn
+	go func() {
+		old.calls.Wait()
+		if err := old.Close(); err != nil {
+			openlogging.Warn("close grpc connection failed: " + err.Error())
+		}
+	}()
+}
+
+//GetOptions method return opts
+func (c *Client) GetOptions() client.Options {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.opts
+}
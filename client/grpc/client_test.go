@@ -0,0 +1,115 @@
+package grpc_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	chassisgrpc "github.com/go-chassis/go-chassis/client/grpc"
+	"github.com/go-chassis/go-chassis/core/client"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/examples/schemas/helloworld"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+}
+
+func sayHelloHandler(srv interface{}, ctx context.Context, dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(helloworld.HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if in.Name == "slow" {
+		time.Sleep(200 * time.Millisecond)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return &helloworld.HelloReply{Message: "hello " + in.Name + md.Get(common.HeaderSourceName)[0]}, nil
+}
+
+var greeterDesc = &grpc.ServiceDesc{
+	ServiceName: "helloworld.Greeter",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "SayHello", Handler: sayHelloHandler},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func TestClient_Call(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := grpc.NewServer()
+	s.RegisterService(greeterDesc, struct{}{})
+	go s.Serve(l)
+	defer s.Stop()
+
+	f, err := client.GetClientNewFunc(chassisgrpc.Name)
+	assert.NoError(t, err)
+	c, err := f(client.Options{Endpoint: l.Addr().String(), Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, "grpc_client", c.String())
+	defer c.Close()
+
+	t.Run("call with headers", func(t *testing.T) {
+		inv := invocation.New(context.Background())
+		inv.SchemaID = "helloworld.Greeter"
+		inv.OperationID = "SayHello"
+		inv.Args = &helloworld.HelloRequest{Name: "peter"}
+		inv.SetHeader(common.HeaderSourceName, "!")
+		reply := &helloworld.HelloReply{}
+		err := c.Call(inv.Ctx, l.Addr().String(), inv, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello peter!", reply.Message)
+	})
+	t.Run("call without operation", func(t *testing.T) {
+		inv := invocation.New(context.Background())
+		inv.SchemaID = "helloworld.Greeter"
+		err := c.Call(inv.Ctx, l.Addr().String(), inv, &helloworld.HelloReply{})
+		assert.Equal(t, chassisgrpc.ErrInvalidReq, err)
+	})
+	t.Run("call canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		inv := invocation.New(ctx)
+		inv.SchemaID = "helloworld.Greeter"
+		inv.OperationID = "SayHello"
+		inv.Args = &helloworld.HelloRequest{Name: "peter"}
+		err := c.Call(inv.Ctx, l.Addr().String(), inv, &helloworld.HelloReply{})
+		assert.Equal(t, client.ErrCanceled, err)
+	})
+	t.Run("call other address", func(t *testing.T) {
+		inv := invocation.New(context.Background())
+		inv.SchemaID = "helloworld.Greeter"
+		inv.OperationID = "SayHello"
+		inv.Args = &helloworld.HelloRequest{Name: "peter"}
+		err := c.Call(inv.Ctx, "127.0.0.1:1", inv, &helloworld.HelloReply{})
+		assert.Error(t, err)
+	})
+	t.Run("call in flight finishes after tls is reloaded", func(t *testing.T) {
+		inv := invocation.New(context.Background())
+		inv.SchemaID = "helloworld.Greeter"
+		inv.OperationID = "SayHello"
+		inv.Args = &helloworld.HelloRequest{Name: "slow"}
+		inv.SetHeader(common.HeaderSourceName, "!")
+		done := make(chan error, 1)
+		reply := &helloworld.HelloReply{}
+		go func() {
+			done <- c.Call(inv.Ctx, l.Addr().String(), inv, reply)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		c.ReloadConfigs(client.Options{TLSConfig: &tls.Config{}})
+		assert.NoError(t, <-done)
+		assert.Equal(t, "hello slow!", reply.Message)
+	})
+}
@@ -109,6 +109,8 @@ func (c *Client) Call(ctx context.Context, addr string, inv *invocation.Invocati
 	}
 
 	c.contextToHeader(ctx, reqSend)
+	//request is aborted once caller cancels ctx, for example a hedged request loses
+	reqSend = reqSend.WithContext(ctx)
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -1 +1,290 @@
+//Package istio is a control panel which pulls configs from istio pilot by xDS protocol,
+//DestinationRule and VirtualService are translated into go-chassis governance configs,
+//local configs are used if pilot does not give any
 package istio
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/config/model"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/pkg/util/iputil"
+	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
+	"github.com/go-mesh/openlogging"
+)
+
+//Name is the infra name of istio panel
+const Name = "istio"
+
+//setting keys of control panel
+const (
+	SettingNodeID       = "nodeID"
+	SettingDomainSuffix = "domainSuffix"
+)
+
+//default values
+const (
+	DefaultAddress      = "istio-pilot.istio-system:15010"
+	DefaultDomainSuffix = "svc.cluster.local"
+)
+
+//Panel pull configs from istio pilot,
+//it falls back to local configs which is managed by archaius panel
+type Panel struct {
+	servicecomb.Panel
+	client       *xdsClient
+	domainSuffix string
+
+	//indexes are rebuilt when xDS resources change, so that requests do not scan all of resources
+	mu       sync.RWMutex
+	clusters map[string]*v2.Cluster    //default outbound cluster, key is service name
+	routes   map[string][]serviceRoute //routes of virtual hosts which serve the service, built on first use
+	//routeVersion increases after each route update, routes built from old resources are not saved
+	routeVersion int
+}
+
+//serviceRoute is a route with its virtual host and compiled path matcher
+type serviceRoute struct {
+	route *route.Route
+	vh    *route.VirtualHost
+	path  pathMatcher
+}
+
+func newPanel(opts control.Options) control.Panel {
+	servicecomb.SaveToLBCache(config.GetLoadBalancing())
+	servicecomb.SaveToCBCache(config.GetHystrixConfig())
+	addr := opts.Address
+	if addr == "" {
+		addr = DefaultAddress
+	}
+	if i := strings.Index(addr, "://"); i >= 0 {
+		addr = addr[i+3:]
+	}
+	p := &Panel{
+		client:       newXDSClient(addr, &core.Node{Id: nodeID(opts.Settings)}),
+		domainSuffix: opts.Settings[SettingDomainSuffix],
+	}
+	if p.domainSuffix == "" {
+		p.domainSuffix = DefaultDomainSuffix
+	}
+	p.client.onChange = p.changed
+	if err := p.client.start(); err != nil {
+		openlogging.GetLogger().Errorf("can not connect to pilot [%s]: %s", addr, err)
+	}
+	return p
+}
+
+//nodeID is in format of sidecar~ip~pod.namespace~namespace.svc.cluster.local, pilot generates configs by it
+func nodeID(settings map[string]string) string {
+	if id := settings[SettingNodeID]; id != "" {
+		return id
+	}
+	ip := os.Getenv("INSTANCE_IP")
+	if ip == "" {
+		ip = iputil.GetLocalIP()
+	}
+	pod := os.Getenv("POD_NAME")
+	if pod == "" {
+		pod, _ = os.Hostname()
+	}
+	ns := os.Getenv("POD_NAMESPACE")
+	if ns == "" {
+		ns = "default"
+	}
+	return strings.Join([]string{"sidecar", ip, pod + "." + ns, ns + "." + DefaultDomainSuffix}, "~")
+}
+
+//GetCircuitBreaker return command, and circuit breaker settings translated from outlier detection
+func (p *Panel) GetCircuitBreaker(inv invocation.Invocation, serviceType string) (string, hystrix.CommandConfig) {
+	command, c := p.Panel.GetCircuitBreaker(inv, serviceType)
+	if serviceType != common.Consumer {
+		return command, c
+	}
+	cluster, ok := p.cluster(inv.MicroServiceName)
+	if !ok {
+		return command, c
+	}
+	return command, ToCommandConfig(cluster, c)
+}
+
+//GetLoadBalancing get load balancing config from cluster lb policy and route retry policy
+func (p *Panel) GetLoadBalancing(inv invocation.Invocation) control.LoadBalancingConfig {
+	c := p.Panel.GetLoadBalancing(inv)
+	//ROUND_ROBIN is the zero value of lb policy, it means pilot does not set it, so local strategy is kept
+	if cluster, ok := p.cluster(inv.MicroServiceName); ok && cluster.LbPolicy != v2.Cluster_ROUND_ROBIN {
+		c.Strategy = ToStrategy(cluster.LbPolicy)
+	}
+	if r, vh, ok := p.route(inv); ok {
+		if rp := r.GetRoute().GetRetryPolicy(); rp != nil {
+			SetRetry(rp, &c)
+		} else {
+			SetRetry(vh.GetRetryPolicy(), &c)
+		}
+	}
+	return c
+}
+
+//GetFaultInjection get fault injection config of the route which matches invocation
+func (p *Panel) GetFaultInjection(inv invocation.Invocation) model.Fault {
+	r, _, ok := p.route(inv)
+	if !ok {
+		return p.Panel.GetFaultInjection(inv)
+	}
+	f, ok := RouteFault(r)
+	if !ok {
+		return p.Panel.GetFaultInjection(inv)
+	}
+	return ToFault(f)
+}
+
+//GetEgressRule return hosts out of mesh, they are defined by ServiceEntry
+func (p *Panel) GetEgressRule() []control.EgressConfig {
+	egresses := make([]control.EgressConfig, 0)
+	index := make(map[string]int)
+	for _, cluster := range p.client.clusterList() {
+		cn, ok := ParseClusterName(cluster.Name)
+		if !ok || cn.Direction != DirectionOutbound || cn.Subset != "" || strings.HasSuffix(cn.Host, "."+p.domainSuffix) {
+			continue
+		}
+		protocol := "http"
+		if cluster.TlsContext != nil {
+			protocol = "https"
+		}
+		port := &control.EgressPort{Port: int32(cn.Port), Protocol: protocol}
+		if i, ok := index[cn.Host]; ok {
+			egresses[i].Ports = append(egresses[i].Ports, port)
+			continue
+		}
+		index[cn.Host] = len(egresses)
+		egresses = append(egresses, control.EgressConfig{
+			Hosts: []string{cn.Host},
+			Ports: []*control.EgressPort{port},
+		})
+	}
+	return egresses
+}
+
+//Endpoints return addresses of a service subset which are pushed by EDS
+func (p *Panel) Endpoints(service, subset string) []string {
+	addrs := make([]string, 0)
+	for _, cluster := range p.client.clusterList() {
+		cn, ok := ParseClusterName(cluster.Name)
+		if !ok || cn.Direction != DirectionOutbound || cn.Subset != subset || ServiceName(cn.Host, p.domainSuffix) != service {
+			continue
+		}
+		name := cluster.GetEdsClusterConfig().GetServiceName()
+		if name == "" {
+			name = cluster.Name
+		}
+		cla, ok := p.client.loadAssignment(name)
+		if !ok {
+			continue
+		}
+		for _, le := range cla.Endpoints {
+			for _, lb := range le.LbEndpoints {
+				sa := lb.GetEndpoint().GetAddress().GetSocketAddress()
+				if sa == nil {
+					continue
+				}
+				addrs = append(addrs, net.JoinHostPort(sa.Address, strconv.Itoa(int(sa.GetPortValue()))))
+			}
+		}
+	}
+	return addrs
+}
+
+//changed rebuilds indexes of the resource type which is pushed by pilot
+func (p *Panel) changed(typeURL string) {
+	switch typeURL {
+	case cache.ClusterType:
+		clusters := make(map[string]*v2.Cluster)
+		for _, cluster := range p.client.clusterList() {
+			cn, ok := ParseClusterName(cluster.Name)
+			if !ok || cn.Direction != DirectionOutbound || cn.Subset != "" {
+				continue
+			}
+			service := ServiceName(cn.Host, p.domainSuffix)
+			if _, ok := clusters[service]; ok {
+				continue
+			}
+			clusters[service] = cluster
+			if od := cluster.OutlierDetection; od != nil && od.Consecutive_5Xx != nil {
+				openlogging.GetLogger().Warnf("consecutive_5xx of cluster [%s] is not supported, "+
+					"set cse.loadbalance.%s.outlierDetection.consecutiveErrors instead", cluster.Name, service)
+			}
+		}
+		p.mu.Lock()
+		p.clusters = clusters
+		p.mu.Unlock()
+	case cache.RouteType:
+		p.mu.Lock()
+		p.routes = nil
+		p.routeVersion++
+		p.mu.Unlock()
+	}
+}
+
+//cluster return the default outbound cluster of a service
+func (p *Panel) cluster(service string) (*v2.Cluster, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	cluster, ok := p.clusters[service]
+	return cluster, ok
+}
+
+//serviceRoutes return routes of a service in order, they are built once after each route update
+func (p *Panel) serviceRoutes(service string) []serviceRoute {
+	p.mu.RLock()
+	routes, ok := p.routes[service]
+	version := p.routeVersion
+	p.mu.RUnlock()
+	if ok {
+		return routes
+	}
+	routes = make([]serviceRoute, 0)
+	for _, rc := range p.client.routeList() {
+		for _, vh := range rc.VirtualHosts {
+			if !MatchDomain(vh, service) {
+				continue
+			}
+			for _, r := range vh.Routes {
+				routes = append(routes, serviceRoute{route: r, vh: vh, path: newPathMatcher(r.Match)})
+			}
+		}
+	}
+	p.mu.Lock()
+	if p.routeVersion == version {
+		if p.routes == nil {
+			p.routes = make(map[string][]serviceRoute)
+		}
+		p.routes[service] = routes
+	}
+	p.mu.Unlock()
+	return routes
+}
+
+//route return the first route which matches invocation
+func (p *Panel) route(inv invocation.Invocation) (*route.Route, *route.VirtualHost, bool) {
+	for _, r := range p.serviceRoutes(inv.MicroServiceName) {
+		if r.path.match(inv.URLPathFormat) {
+			return r.route, r.vh, true
+		}
+	}
+	return nil, nil, false
+}
+
+func init() {
+	control.InstallPlugin(Name, newPanel)
+}
@@ -0,0 +1,248 @@
+package istio
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	auth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	cluster "github.com/envoyproxy/go-control-plane/envoy/api/v2/cluster"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	faultv2 "github.com/envoyproxy/go-control-plane/envoy/config/filter/fault/v2"
+	fault "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	xds "github.com/envoyproxy/go-control-plane/pkg/server"
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+const (
+	nodeName       = "sidecar~127.0.0.1~client.default~default.svc.cluster.local"
+	reviews        = "outbound|9080||reviews.default.svc.cluster.local"
+	reviewsV1      = "outbound|9080|v1|reviews.default.svc.cluster.local"
+	details        = "outbound|9080||details.default.svc.cluster.local"
+	external       = "outbound|443||www.example.com"
+	reviewsSvcName = "reviews"
+)
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.loadbalance.strategy.name", loadbalancer.StrategyRoundRobin)
+	archaius.Set("cse.loadbalance.details.strategy.name", loadbalancer.StrategyLeastRequest)
+	archaius.Set("cse.isolation.Consumer.maxConcurrentRequests", 100)
+	if err := config.ReadLBFromArchaius(); err != nil {
+		panic(err)
+	}
+	if err := config.ReadHystrixFromArchaius(); err != nil {
+		panic(err)
+	}
+}
+
+func edsCluster(name string, c *v2.Cluster) *v2.Cluster {
+	c.Name = name
+	c.ClusterDiscoveryType = &v2.Cluster_Type{Type: v2.Cluster_EDS}
+	c.EdsClusterConfig = &v2.Cluster_EdsClusterConfig{
+		EdsConfig: &core.ConfigSource{ConfigSourceSpecifier: &core.ConfigSource_Ads{Ads: &core.AggregatedConfigSource{}}},
+	}
+	return c
+}
+
+func snapshot(t *testing.T) cache.Snapshot {
+	httpFault, err := ptypes.MarshalAny(&fault.HTTPFault{
+		Abort: &fault.FaultAbort{
+			ErrorType:  &fault.FaultAbort_HttpStatus{HttpStatus: 503},
+			Percentage: &envoytype.FractionalPercent{Numerator: 50},
+		},
+		Delay: &faultv2.FaultDelay{
+			FaultDelaySecifier: &faultv2.FaultDelay_FixedDelay{FixedDelay: ptypes.DurationProto(time.Second)},
+			Percentage:         &envoytype.FractionalPercent{Numerator: 2000, Denominator: envoytype.FractionalPercent_TEN_THOUSAND},
+		},
+	})
+	assert.NoError(t, err)
+	clusters := []cache.Resource{
+		edsCluster(reviews, &v2.Cluster{
+			LbPolicy: v2.Cluster_RANDOM,
+			OutlierDetection: &cluster.OutlierDetection{
+				Consecutive_5Xx:  &wrappers.UInt32Value{Value: 3},
+				BaseEjectionTime: ptypes.DurationProto(10 * time.Second),
+			},
+			CircuitBreakers: &cluster.CircuitBreakers{Thresholds: []*cluster.CircuitBreakers_Thresholds{
+				{MaxRequests: &wrappers.UInt32Value{Value: 10}},
+			}},
+		}),
+		edsCluster(reviewsV1, &v2.Cluster{}),
+		edsCluster(details, &v2.Cluster{}),
+		&v2.Cluster{
+			Name:                 external,
+			ClusterDiscoveryType: &v2.Cluster_Type{Type: v2.Cluster_STRICT_DNS},
+			TlsContext:           &auth.UpstreamTlsContext{Sni: "www.example.com"},
+		},
+	}
+	endpoints := []cache.Resource{
+		&v2.ClusterLoadAssignment{ClusterName: reviews, Endpoints: []*endpoint.LocalityLbEndpoints{{
+			LbEndpoints: []*endpoint.LbEndpoint{lbEndpoint("10.0.0.1", 9080), lbEndpoint("10.0.0.2", 9080)},
+		}}},
+		&v2.ClusterLoadAssignment{ClusterName: reviewsV1, Endpoints: []*endpoint.LocalityLbEndpoints{{
+			LbEndpoints: []*endpoint.LbEndpoint{lbEndpoint("10.0.0.1", 9080)},
+		}}},
+	}
+	routes := []cache.Resource{
+		&v2.RouteConfiguration{Name: "9080", VirtualHosts: []*route.VirtualHost{{
+			Name:    "reviews.default.svc.cluster.local:9080",
+			Domains: []string{"reviews.default.svc.cluster.local", "reviews", "reviews:9080"},
+			Routes: []*route.Route{
+				{
+					Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/fault"}},
+					Action: &route.Route_Route{Route: &route.RouteAction{
+						ClusterSpecifier: &route.RouteAction_Cluster{Cluster: reviews},
+					}},
+					TypedPerFilterConfig: map[string]*any.Any{FaultFilterName: httpFault},
+				},
+				{
+					Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+					Action: &route.Route_Route{Route: &route.RouteAction{
+						ClusterSpecifier: &route.RouteAction_Cluster{Cluster: reviews},
+						RetryPolicy:      &route.RetryPolicy{NumRetries: &wrappers.UInt32Value{Value: 2}},
+					}},
+				},
+			},
+		}}},
+		&v2.RouteConfiguration{Name: "443"},
+	}
+	return cache.NewSnapshot("1", endpoints, clusters, routes, nil)
+}
+
+func lbEndpoint(ip string, port uint32) *endpoint.LbEndpoint {
+	return &endpoint.LbEndpoint{HostIdentifier: &endpoint.LbEndpoint_Endpoint{Endpoint: &endpoint.Endpoint{
+		Address: &core.Address{Address: &core.Address_SocketAddress{SocketAddress: &core.SocketAddress{
+			Address:       ip,
+			PortSpecifier: &core.SocketAddress_PortValue{PortValue: port},
+		}}},
+	}}}
+}
+
+//startPilot starts an in-process xDS management server
+func startPilot(t *testing.T) (string, func()) {
+	snapshots := cache.NewSnapshotCache(true, cache.IDHash{}, nil)
+	assert.NoError(t, snapshots.SetSnapshot(nodeName, snapshot(t)))
+	s := grpc.NewServer()
+	discovery.RegisterAggregatedDiscoveryServiceServer(s, xds.NewServer(snapshots, nil))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go s.Serve(l)
+	return l.Addr().String(), s.Stop
+}
+
+func TestParseClusterName(t *testing.T) {
+	cn, ok := ParseClusterName(reviewsV1)
+	assert.True(t, ok)
+	assert.Equal(t, ClusterName{Direction: DirectionOutbound, Port: 9080, Subset: "v1", Host: "reviews.default.svc.cluster.local"}, cn)
+	assert.Equal(t, reviewsSvcName, ServiceName(cn.Host, DefaultDomainSuffix))
+	_, ok = ParseClusterName("PassthroughCluster")
+	assert.False(t, ok)
+}
+
+func TestPanel(t *testing.T) {
+	addr, stop := startPilot(t)
+	defer stop()
+	p := newPanel(control.Options{
+		Infra:    Name,
+		Address:  "grpc://" + addr,
+		Settings: map[string]string{SettingNodeID: nodeName},
+	}).(*Panel)
+	defer p.client.close()
+
+	inv := invocation.Invocation{MicroServiceName: reviewsSvcName, URLPathFormat: "/reviews"}
+	for i := 0; i < 50; i++ {
+		if _, _, ok := p.route(inv); ok && len(p.Endpoints(reviewsSvcName, "v1")) == 1 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Run("circuit breaker from outlier detection", func(t *testing.T) {
+		command, c := p.GetCircuitBreaker(inv, common.Consumer)
+		assert.Equal(t, "Consumer.reviews", command)
+		assert.True(t, c.CircuitBreakerEnabled)
+		_, local := p.Panel.GetCircuitBreaker(inv, common.Consumer)
+		assert.Equal(t, local.RequestVolumeThreshold, c.RequestVolumeThreshold, "consecutive errors is not translated")
+		assert.Equal(t, 10000, c.SleepWindow)
+		assert.Equal(t, 10, c.MaxConcurrentRequests)
+
+		_, c = p.GetCircuitBreaker(invocation.Invocation{MicroServiceName: "ratings"}, common.Consumer)
+		assert.Equal(t, 100, c.MaxConcurrentRequests)
+	})
+	t.Run("load balancing", func(t *testing.T) {
+		c := p.GetLoadBalancing(inv)
+		assert.Equal(t, loadbalancer.StrategyRandom, c.Strategy)
+		assert.True(t, c.RetryEnabled)
+		assert.Equal(t, 2, c.RetryOnNext)
+
+		c = p.GetLoadBalancing(invocation.Invocation{MicroServiceName: "ratings"})
+		assert.Equal(t, loadbalancer.StrategyRoundRobin, c.Strategy)
+		assert.False(t, c.RetryEnabled)
+
+		//lb policy is not set by pilot, local strategy is kept
+		c = p.GetLoadBalancing(invocation.Invocation{MicroServiceName: "details"})
+		assert.Equal(t, loadbalancer.StrategyLeastRequest, c.Strategy)
+	})
+	t.Run("fault injection", func(t *testing.T) {
+		f := p.GetFaultInjection(invocation.Invocation{MicroServiceName: reviewsSvcName, URLPathFormat: "/fault/1"})
+		assert.Equal(t, 503, f.Abort.HTTPStatus)
+		assert.Equal(t, 50, f.Abort.Percent)
+		assert.Equal(t, time.Second, f.Delay.FixedDelay)
+		assert.Equal(t, 20, f.Delay.Percent)
+
+		f = p.GetFaultInjection(inv)
+		assert.Equal(t, 0, f.Abort.HTTPStatus)
+	})
+	t.Run("egress", func(t *testing.T) {
+		egresses := p.GetEgressRule()
+		assert.Equal(t, 1, len(egresses))
+		assert.Equal(t, []string{"www.example.com"}, egresses[0].Hosts)
+		assert.Equal(t, int32(443), egresses[0].Ports[0].Port)
+		assert.Equal(t, "https", egresses[0].Ports[0].Protocol)
+	})
+	t.Run("endpoints", func(t *testing.T) {
+		assert.Equal(t, []string{"10.0.0.1:9080", "10.0.0.2:9080"}, p.Endpoints(reviewsSvcName, ""))
+		assert.Equal(t, []string{"10.0.0.1:9080"}, p.Endpoints(reviewsSvcName, "v1"))
+	})
+}
+
+func TestToStrategy(t *testing.T) {
+	assert.Equal(t, loadbalancer.StrategyRoundRobin, ToStrategy(v2.Cluster_ROUND_ROBIN))
+	assert.Equal(t, loadbalancer.StrategyRandom, ToStrategy(v2.Cluster_RANDOM))
+	assert.Equal(t, loadbalancer.StrategyLeastRequest, ToStrategy(v2.Cluster_LEAST_REQUEST))
+	assert.Equal(t, loadbalancer.StrategyConsistentHash, ToStrategy(v2.Cluster_RING_HASH))
+	assert.Equal(t, loadbalancer.StrategyConsistentHash, ToStrategy(v2.Cluster_MAGLEV))
+}
+
+func TestMatchPath(t *testing.T) {
+	regex := &route.RouteMatch{PathSpecifier: &route.RouteMatch_Regex{Regex: "/reviews/[0-9]+"}}
+	assert.True(t, MatchPath(regex, "/reviews/1"))
+	assert.False(t, MatchPath(regex, "/reviews/1/comments"))
+	invalid := &route.RouteMatch{PathSpecifier: &route.RouteMatch_Regex{Regex: "/reviews/[0-"}}
+	assert.False(t, MatchPath(invalid, "/reviews/1"))
+	prefix := &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/reviews"}}
+	assert.True(t, MatchPath(prefix, "/reviews/1"))
+	assert.True(t, MatchPath(nil, "/reviews/1"))
+	assert.True(t, MatchPath(prefix, ""))
+}
@@ -0,0 +1,256 @@
+package istio
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	route "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	fault "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/fault/v2"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/config/model"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/resilience/retry"
+	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+)
+
+//direction of cluster
+const (
+	DirectionOutbound = "outbound"
+	DirectionInbound  = "inbound"
+)
+
+//FaultFilterName is the name of envoy http fault filter
+const FaultFilterName = "envoy.fault"
+
+//DefaultBaseEjectionTime is default value of envoy outlier detection
+const DefaultBaseEjectionTime = 30000
+
+//ClusterName is the parsed name of cluster which generated by pilot,
+//it is in format of direction|port|subset|host, like outbound|9080|v1|reviews.default.svc.cluster.local
+type ClusterName struct {
+	Direction string
+	Port      int
+	Subset    string
+	Host      string
+}
+
+//ParseClusterName parse cluster name, return false if it is not generated by pilot
+func ParseClusterName(name string) (ClusterName, bool) {
+	s := strings.Split(name, "|")
+	if len(s) != 4 {
+		return ClusterName{}, false
+	}
+	port, err := strconv.Atoi(s[1])
+	if err != nil {
+		return ClusterName{}, false
+	}
+	return ClusterName{Direction: s[0], Port: port, Subset: s[2], Host: s[3]}, true
+}
+
+//ServiceName return micro service name of host, it is the short name of kubernetes service,
+//if host is not in mesh, host itself is returned
+func ServiceName(host, domainSuffix string) string {
+	if strings.HasSuffix(host, "."+domainSuffix) {
+		return strings.SplitN(host, ".", 2)[0]
+	}
+	return host
+}
+
+//ToCommandConfig translate outlier detection and circuit breaker thresholds of cluster into hystrix config.
+//base ejection time is treated as sleep window, and max requests as max concurrent requests.
+//consecutive errors has no counterpart in hystrix, it is not translated
+func ToCommandConfig(cluster *v2.Cluster, c hystrix.CommandConfig) hystrix.CommandConfig {
+	if od := cluster.OutlierDetection; od != nil {
+		c.CircuitBreakerEnabled = true
+		c.SleepWindow = DefaultBaseEjectionTime
+		if od.BaseEjectionTime != nil {
+			if d, err := ptypes.Duration(od.BaseEjectionTime); err == nil {
+				c.SleepWindow = int(d.Nanoseconds() / 1e6)
+			}
+		}
+	}
+	if cb := cluster.CircuitBreakers; cb != nil {
+		for _, t := range cb.Thresholds {
+			if t.MaxRequests != nil {
+				c.MaxConcurrentRequests = int(t.MaxRequests.Value)
+				break
+			}
+		}
+	}
+	return c
+}
+
+//ToStrategy translate lb policy into load balance strategy name
+func ToStrategy(policy v2.Cluster_LbPolicy) string {
+	switch policy {
+	case v2.Cluster_RANDOM:
+		return loadbalancer.StrategyRandom
+	case v2.Cluster_LEAST_REQUEST:
+		return loadbalancer.StrategyLeastRequest
+	case v2.Cluster_RING_HASH, v2.Cluster_MAGLEV:
+		return loadbalancer.StrategyConsistentHash
+	default:
+		return loadbalancer.StrategyRoundRobin
+	}
+}
+
+//SetRetry set retry policy of route into load balancing config
+func SetRetry(p *route.RetryPolicy, c *control.LoadBalancingConfig) {
+	if p == nil {
+		return
+	}
+	c.RetryEnabled = true
+	c.RetryOnNext = 1
+	if p.NumRetries != nil {
+		c.RetryOnNext = int(p.NumRetries.Value)
+	}
+	c.RetryPolicy = ToRetryPolicy(p)
+	if b := p.RetryBackOff; b != nil {
+		c.BackOffKind = retry.KindExponential
+		if d, err := ptypes.Duration(b.BaseInterval); err == nil {
+			c.BackOffMin = int(d.Nanoseconds() / 1e6)
+		}
+		if d, err := ptypes.Duration(b.MaxInterval); err == nil {
+			c.BackOffMax = int(d.Nanoseconds() / 1e6)
+		}
+	}
+}
+
+//ToRetryPolicy translate retry_on of envoy into retry policy, unsupported conditions are ignored
+func ToRetryPolicy(p *route.RetryPolicy) retry.Policy {
+	var statusCodes, errs []string
+	for _, on := range strings.Split(p.RetryOn, ",") {
+		switch strings.TrimSpace(on) {
+		case "5xx":
+			statusCodes = append(statusCodes, "5xx")
+			errs = append(errs, retry.ErrorConnectFailure, retry.ErrorReset)
+		case "gateway-error":
+			statusCodes = append(statusCodes, "502", "503", "504")
+		case "connect-failure":
+			errs = append(errs, retry.ErrorConnectFailure)
+		case "reset":
+			errs = append(errs, retry.ErrorReset)
+		case "retriable-4xx":
+			statusCodes = append(statusCodes, "409")
+		case "retriable-status-codes":
+			for _, code := range p.RetriableStatusCodes {
+				statusCodes = append(statusCodes, strconv.Itoa(int(code)))
+			}
+		}
+	}
+	return retry.Policy{StatusCodes: statusCodes, Errors: errs}
+}
+
+//ToFault translate envoy http fault into fault injection model
+func ToFault(f *fault.HTTPFault) model.Fault {
+	result := model.Fault{}
+	if a := f.Abort; a != nil {
+		result.Abort.HTTPStatus = int(a.GetHttpStatus())
+		result.Abort.Percent = percent(a.Percentage)
+	}
+	if d := f.Delay; d != nil {
+		if fd := d.GetFixedDelay(); fd != nil {
+			if duration, err := ptypes.Duration(fd); err == nil {
+				result.Delay.FixedDelay = duration
+			}
+		}
+		result.Delay.Percent = percent(d.Percentage)
+	}
+	return result
+}
+
+//RouteFault return fault filter config of route
+func RouteFault(r *route.Route) (*fault.HTTPFault, bool) {
+	f := &fault.HTTPFault{}
+	if a, ok := r.TypedPerFilterConfig[FaultFilterName]; ok {
+		if err := ptypes.UnmarshalAny(a, f); err != nil {
+			return nil, false
+		}
+		return f, true
+	}
+	if s, ok := r.PerFilterConfig[FaultFilterName]; ok {
+		js, err := (&jsonpb.Marshaler{}).MarshalToString(s)
+		if err != nil {
+			return nil, false
+		}
+		if err := jsonpb.UnmarshalString(js, f); err != nil {
+			return nil, false
+		}
+		return f, true
+	}
+	return nil, false
+}
+
+//MatchPath check if route matches path, empty path matches any route
+func MatchPath(m *route.RouteMatch, path string) bool {
+	return newPathMatcher(m).match(path)
+}
+
+//pathMatcher keeps compiled regex of route match, so that it is compiled once for each xDS update
+type pathMatcher struct {
+	m     *route.RouteMatch
+	regex *regexp.Regexp
+}
+
+func newPathMatcher(m *route.RouteMatch) pathMatcher {
+	pm := pathMatcher{m: m}
+	if m == nil || m.GetPath() != "" {
+		return pm
+	}
+	exp := m.GetRegex()
+	if exp == "" && m.GetSafeRegex() != nil {
+		exp = m.GetSafeRegex().Regex
+	}
+	if exp != "" {
+		//invalid regex matches nothing
+		pm.regex, _ = regexp.Compile("^" + exp + "$")
+	}
+	return pm
+}
+
+func (pm pathMatcher) match(path string) bool {
+	m := pm.m
+	if path == "" || m == nil {
+		return true
+	}
+	switch {
+	case m.GetPath() != "":
+		return m.GetPath() == path
+	case m.GetRegex() != "" || m.GetSafeRegex() != nil:
+		return pm.regex != nil && pm.regex.MatchString(path)
+	default:
+		return strings.HasPrefix(path, m.GetPrefix())
+	}
+}
+
+//MatchDomain check if virtual host serves the micro service
+func MatchDomain(vh *route.VirtualHost, service string) bool {
+	for _, d := range vh.Domains {
+		if i := strings.LastIndex(d, ":"); i > 0 {
+			d = d[:i]
+		}
+		if d == service || strings.HasPrefix(d, service+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func percent(p *envoytype.FractionalPercent) int {
+	if p == nil {
+		return 100
+	}
+	switch p.Denominator {
+	case envoytype.FractionalPercent_TEN_THOUSAND:
+		return int(p.Numerator / 100)
+	case envoytype.FractionalPercent_MILLION:
+		return int(p.Numerator / 10000)
+	default:
+		return int(p.Numerator)
+	}
+}
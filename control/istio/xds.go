@@ -0,0 +1,297 @@
+package istio
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/cache"
+	"github.com/go-mesh/openlogging"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc"
+)
+
+const (
+	maxBackOff = 30 * time.Second
+	minBackOff = time.Second
+)
+
+//resourceState records the latest acked version and nonce of one resource type
+type resourceState struct {
+	version string
+	nonce   string
+	names   []string
+}
+
+//xdsClient subscribes CDS, EDS and RDS from pilot through one ADS stream,
+//resources are kept in memory and replaced once pilot pushes new version
+type xdsClient struct {
+	addr string
+	node *core.Node
+
+	mu        sync.RWMutex
+	clusters  map[string]*v2.Cluster
+	endpoints map[string]*v2.ClusterLoadAssignment
+	routes    map[string]*v2.RouteConfiguration
+
+	states map[string]*resourceState
+	conn   *grpc.ClientConn
+	stopCh chan struct{}
+	//onChange is called after resources of a type are replaced
+	onChange func(typeURL string)
+}
+
+func newXDSClient(addr string, node *core.Node) *xdsClient {
+	return &xdsClient{
+		addr:      addr,
+		node:      node,
+		clusters:  make(map[string]*v2.Cluster),
+		endpoints: make(map[string]*v2.ClusterLoadAssignment),
+		routes:    make(map[string]*v2.RouteConfiguration),
+		states:    make(map[string]*resourceState),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+//start connects to pilot and keeps the stream alive until client is closed
+func (c *xdsClient) start() error {
+	conn, err := grpc.Dial(c.addr, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	go func() {
+		backOff := minBackOff
+		for {
+			err := c.stream()
+			select {
+			case <-c.stopCh:
+				return
+			default:
+			}
+			openlogging.GetLogger().Warnf("xds stream to [%s] broken: %v, reconnect after %s", c.addr, err, backOff)
+			time.Sleep(backOff)
+			if backOff *= 2; backOff > maxBackOff {
+				backOff = maxBackOff
+			}
+		}
+	}()
+	return nil
+}
+
+func (c *xdsClient) close() {
+	select {
+	case <-c.stopCh:
+		return
+	default:
+		close(c.stopCh)
+	}
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+func (c *xdsClient) stream() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	s, err := discovery.NewAggregatedDiscoveryServiceClient(c.conn).StreamAggregatedResources(ctx)
+	if err != nil {
+		return err
+	}
+	//a new stream must subscribe all resources again
+	c.states = make(map[string]*resourceState)
+	if err := c.send(s, cache.ClusterType, nil); err != nil {
+		return err
+	}
+	for {
+		resp, err := s.Recv()
+		if err != nil {
+			return err
+		}
+		if err := c.handle(s, resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *xdsClient) send(s discovery.AggregatedDiscoveryService_StreamAggregatedResourcesClient, typeURL string, names []string) error {
+	st, ok := c.states[typeURL]
+	if !ok {
+		st = &resourceState{}
+		c.states[typeURL] = st
+	}
+	st.names = names
+	return s.Send(&v2.DiscoveryRequest{
+		Node:          c.node,
+		TypeUrl:       typeURL,
+		ResourceNames: names,
+		VersionInfo:   st.version,
+		ResponseNonce: st.nonce,
+	})
+}
+
+func (c *xdsClient) handle(s discovery.AggregatedDiscoveryService_StreamAggregatedResourcesClient, resp *v2.DiscoveryResponse) error {
+	st, ok := c.states[resp.TypeUrl]
+	if !ok {
+		openlogging.GetLogger().Warnf("receive unknown xds resource type [%s]", resp.TypeUrl)
+		return nil
+	}
+	if err := c.save(resp); err != nil {
+		//NACK, keep the last version
+		openlogging.GetLogger().Errorf("invalid xds response of [%s]: %s", resp.TypeUrl, err)
+		st.nonce = resp.Nonce
+		return c.send(s, resp.TypeUrl, st.names)
+	}
+	st.version = resp.VersionInfo
+	st.nonce = resp.Nonce
+	if err := c.send(s, resp.TypeUrl, st.names); err != nil {
+		return err
+	}
+	if resp.TypeUrl != cache.ClusterType {
+		return nil
+	}
+	//clusters decide which endpoints and routes should be subscribed
+	eds, rds := c.dependencies()
+	if err := c.subscribe(s, cache.EndpointType, eds); err != nil {
+		return err
+	}
+	return c.subscribe(s, cache.RouteType, rds)
+}
+
+//subscribe send request only if resource names changes
+func (c *xdsClient) subscribe(s discovery.AggregatedDiscoveryService_StreamAggregatedResourcesClient, typeURL string, names []string) error {
+	if st, ok := c.states[typeURL]; ok && equal(st.names, names) {
+		return nil
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return c.send(s, typeURL, names)
+}
+
+func (c *xdsClient) save(resp *v2.DiscoveryResponse) error {
+	switch resp.TypeUrl {
+	case cache.ClusterType:
+		m := make(map[string]*v2.Cluster, len(resp.Resources))
+		for _, r := range resp.Resources {
+			cluster := &v2.Cluster{}
+			if err := ptypes.UnmarshalAny(r, cluster); err != nil {
+				return err
+			}
+			m[cluster.Name] = cluster
+		}
+		c.mu.Lock()
+		c.clusters = m
+		c.mu.Unlock()
+	case cache.EndpointType:
+		m := make(map[string]*v2.ClusterLoadAssignment, len(resp.Resources))
+		for _, r := range resp.Resources {
+			cla := &v2.ClusterLoadAssignment{}
+			if err := ptypes.UnmarshalAny(r, cla); err != nil {
+				return err
+			}
+			m[cla.ClusterName] = cla
+		}
+		c.mu.Lock()
+		c.endpoints = m
+		c.mu.Unlock()
+	case cache.RouteType:
+		m := make(map[string]*v2.RouteConfiguration, len(resp.Resources))
+		for _, r := range resp.Resources {
+			rc := &v2.RouteConfiguration{}
+			if err := ptypes.UnmarshalAny(r, rc); err != nil {
+				return err
+			}
+			m[rc.Name] = rc
+		}
+		c.mu.Lock()
+		c.routes = m
+		c.mu.Unlock()
+	}
+	openlogging.GetLogger().Debugf("receive [%d] xds resources of [%s], version [%s]", len(resp.Resources), resp.TypeUrl, resp.VersionInfo)
+	if c.onChange != nil {
+		c.onChange(resp.TypeUrl)
+	}
+	return nil
+}
+
+//dependencies return EDS cluster names and route names.
+//pilot names outbound route configuration by port, so route names come from ports of outbound clusters
+func (c *xdsClient) dependencies() ([]string, []string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	eds := make(map[string]bool)
+	rds := make(map[string]bool)
+	for name, cluster := range c.clusters {
+		if cluster.GetType() == v2.Cluster_EDS {
+			if n := cluster.GetEdsClusterConfig().GetServiceName(); n != "" {
+				eds[n] = true
+			} else {
+				eds[name] = true
+			}
+		}
+		if cn, ok := ParseClusterName(name); ok && cn.Direction == DirectionOutbound {
+			rds[strconv.Itoa(cn.Port)] = true
+		}
+	}
+	return keys(eds), keys(rds)
+}
+
+func (c *xdsClient) clusterList() []*v2.Cluster {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	l := make([]*v2.Cluster, 0, len(c.clusters))
+	for _, cluster := range c.clusters {
+		l = append(l, cluster)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+	return l
+}
+
+func (c *xdsClient) routeList() []*v2.RouteConfiguration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	l := make([]*v2.RouteConfiguration, 0, len(c.routes))
+	for _, rc := range c.routes {
+		l = append(l, rc)
+	}
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Name < l[j].Name
+	})
+	return l
+}
+
+func (c *xdsClient) loadAssignment(name string) (*v2.ClusterLoadAssignment, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cla, ok := c.endpoints[name]
+	return cla, ok
+}
+
+func keys(m map[string]bool) []string {
+	l := make([]string, 0, len(m))
+	for k := range m {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+func equal(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
@@ -2,6 +2,7 @@ package control
 
 //Options is for initiating control panel
 type Options struct {
-	Address string
-	Infra   string
+	Address  string
+	Infra    string
+	Settings map[string]string
 }
@@ -35,6 +35,24 @@ type Panel interface {
 	GetEgressRule() []EgressConfig
 }
 
+//HedgingPanel is implemented by panel which provides hedging config,
+//if DefaultPanel does not implement it, hedging config is read from archaius
+type HedgingPanel interface {
+	GetHedging(inv invocation.Invocation) HedgingConfig
+}
+
+//BulkheadPanel is implemented by panel which provides bulkhead config,
+//if DefaultPanel does not implement it, bulkhead config is read from archaius
+type BulkheadPanel interface {
+	GetBulkhead(inv invocation.Invocation, serviceType string) BulkheadConfig
+}
+
+//BreakerPanel is implemented by panel which provides native circuit breaker config,
+//if DefaultPanel does not implement it, the config is read from archaius
+type BreakerPanel interface {
+	GetBreaker(inv invocation.Invocation, serviceType string) BreakerConfig
+}
+
 //InstallPlugin install implementation
 func InstallPlugin(name string, f func(options Options) Panel) {
 	panelPlugin[name] = f
@@ -0,0 +1,32 @@
+package servicecomb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+)
+
+//GetBreaker get window, slow call and half open settings of native circuit breaker,
+//configs are cached until circuit breaker config changes
+func (p *Panel) GetBreaker(inv invocation.Invocation, serviceType string) control.BreakerConfig {
+	key := GetCBCacheKey(inv.MicroServiceName, serviceType)
+	if v, ok := BreakerConfigCache.Get(key); ok {
+		return v.(control.BreakerConfig)
+	}
+	command := serviceType
+	if inv.MicroServiceName != "" {
+		command = strings.Join([]string{serviceType, inv.MicroServiceName}, ".")
+	}
+	c := control.BreakerConfig{
+		WindowType:            config.GetWindowType(command, serviceType),
+		WindowSize:            config.GetWindowSize(command, serviceType),
+		SlowCallDuration:      time.Duration(config.GetSlowCallDuration(command, serviceType)) * time.Millisecond,
+		SlowCallRateThreshold: config.GetSlowCallRateThreshold(command, serviceType),
+		HalfOpenRequests:      config.GetHalfOpenRequests(command, serviceType),
+	}
+	BreakerConfigCache.SetDefault(key, c)
+	return c
+}
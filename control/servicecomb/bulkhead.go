@@ -0,0 +1,69 @@
+package servicecomb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/resilience/bulkhead"
+)
+
+//BulkheadPrefix is the config prefix of bulkhead
+const BulkheadPrefix = "cse.bulkhead"
+
+//default values of bulkhead
+const (
+	DefaultBulkheadMaxConcurrentCalls = 1000
+	DefaultBulkheadMaxWaitingCalls    = 100
+	DefaultBulkheadMaxWaitDuration    = time.Second
+)
+
+//GetBulkhead get bulkhead config, config of operation overrides schema, then service, then global.
+//configs are cached until bulkhead config changes
+func (p *Panel) GetBulkhead(inv invocation.Invocation, serviceType string) control.BulkheadConfig {
+	key := strings.Join([]string{serviceType, inv.MicroServiceName, inv.SchemaID, inv.OperationID}, ":")
+	var c control.BulkheadConfig
+	if v, ok := BulkheadConfigCache.Get(key); ok {
+		c = v.(control.BulkheadConfig)
+	} else {
+		c = readBulkhead(inv, serviceType)
+		BulkheadConfigCache.SetDefault(key, c)
+	}
+	scope := archaius.GetString(BulkheadPrefix+".scope", control.ScopeAPI)
+	c.Name = control.NewCircuitName(serviceType, scope, inv)
+	return c
+}
+
+func readBulkhead(inv invocation.Invocation, serviceType string) control.BulkheadConfig {
+	keys := operationKeys(BulkheadPrefix+"."+serviceType, inv)
+	c := control.BulkheadConfig{
+		Mode:               bulkhead.ModeSemaphore,
+		MaxConcurrentCalls: DefaultBulkheadMaxConcurrentCalls,
+		MaxWaitingCalls:    DefaultBulkheadMaxWaitingCalls,
+		MaxWaitDuration:    DefaultBulkheadMaxWaitDuration,
+	}
+	if k := existingKey(keys, "enabled"); k != "" {
+		c.Enabled = archaius.GetBool(k, false)
+	}
+	if k := existingKey(keys, "mode"); k != "" {
+		c.Mode = archaius.GetString(k, bulkhead.ModeSemaphore)
+	}
+	if k := existingKey(keys, "maxConcurrentCalls"); k != "" {
+		if n := archaius.GetInt(k, 0); n > 0 {
+			c.MaxConcurrentCalls = n
+		}
+	}
+	if k := existingKey(keys, "maxWaitingCalls"); k != "" {
+		if n := archaius.GetInt(k, -1); n >= 0 {
+			c.MaxWaitingCalls = n
+		}
+	}
+	if k := existingKey(keys, "maxWaitDuration"); k != "" {
+		if d, err := time.ParseDuration(archaius.GetString(k, "")); err == nil && d >= 0 {
+			c.MaxWaitDuration = d
+		}
+	}
+	return c
+}
@@ -16,6 +16,12 @@ var (
 	RLConfigCache     = cache.New(0, 0)
 	EgressConfigCache = cache.New(0, 0)
 	FIConfigCache     = cache.New(0, 0)
+	//key is [Provider|Consumer]:service:schema:operation
+	BulkheadConfigCache = cache.New(0, 0)
+	//key is service:schema:operation
+	HedgingConfigCache = cache.New(0, 0)
+	//key is the same with CBConfigCache
+	BreakerConfigCache = cache.New(0, 0)
 )
 
 //Default values
@@ -0,0 +1,87 @@
+package servicecomb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/invocation"
+)
+
+//HedgingPrefix is the config prefix of hedging
+const HedgingPrefix = "cse.hedging." + common.Consumer
+
+//default values of hedging
+const (
+	DefaultHedgingDelay         = 100 * time.Millisecond
+	DefaultHedgingMaxAttempts   = 2
+	DefaultHedgingBudgetPercent = 10
+)
+
+//operationKeys return key prefixes from operation to global, the first existing one is used for each property
+func operationKeys(prefix string, inv invocation.Invocation) []string {
+	keys := make([]string, 0, 4)
+	if inv.MicroServiceName != "" {
+		service := strings.Join([]string{prefix, inv.MicroServiceName}, ".")
+		if inv.SchemaID != "" {
+			schema := strings.Join([]string{service, inv.SchemaID}, ".")
+			if inv.OperationID != "" {
+				keys = append(keys, strings.Join([]string{schema, inv.OperationID}, "."))
+			}
+			keys = append(keys, schema)
+		}
+		keys = append(keys, service)
+	}
+	return append(keys, prefix)
+}
+
+//existingKey return the first key which has value, or empty string
+func existingKey(keys []string, property string) string {
+	for _, k := range keys {
+		if archaius.GetString(k+"."+property, "") != "" {
+			return k + "." + property
+		}
+	}
+	return ""
+}
+
+//GetHedging get hedging config, config of operation overrides schema, then service, then global.
+//configs are cached until hedging config changes
+func (p *Panel) GetHedging(inv invocation.Invocation) control.HedgingConfig {
+	key := strings.Join([]string{inv.MicroServiceName, inv.SchemaID, inv.OperationID}, ":")
+	if v, ok := HedgingConfigCache.Get(key); ok {
+		return v.(control.HedgingConfig)
+	}
+	c := readHedging(inv)
+	HedgingConfigCache.SetDefault(key, c)
+	return c
+}
+
+func readHedging(inv invocation.Invocation) control.HedgingConfig {
+	keys := operationKeys(HedgingPrefix, inv)
+	c := control.HedgingConfig{
+		Delay:         DefaultHedgingDelay,
+		MaxAttempts:   DefaultHedgingMaxAttempts,
+		BudgetPercent: DefaultHedgingBudgetPercent,
+	}
+	if k := existingKey(keys, "enabled"); k != "" {
+		c.Enabled = archaius.GetBool(k, false)
+	}
+	if k := existingKey(keys, "delay"); k != "" {
+		if d, err := time.ParseDuration(archaius.GetString(k, "")); err == nil && d > 0 {
+			c.Delay = d
+		}
+	}
+	if k := existingKey(keys, "percentile"); k != "" {
+		c.Percentile = archaius.GetFloat64(k, 0)
+	}
+	if k := existingKey(keys, "maxAttempts"); k != "" {
+		c.MaxAttempts = archaius.GetInt(k, DefaultHedgingMaxAttempts)
+	}
+	if k := existingKey(keys, "budgetPercent"); k != "" {
+		c.BudgetPercent = archaius.GetInt(k, DefaultHedgingBudgetPercent)
+	}
+	return c
+}
@@ -54,6 +54,7 @@ func (p *Panel) GetLoadBalancing(inv invocation.Invocation) control.LoadBalancin
 func (p *Panel) GetRateLimiting(inv invocation.Invocation, serviceType string) control.RateLimitingConfig {
 	rl := control.RateLimitingConfig{}
 	rl.Enabled = archaius.GetBool("cse.flowcontrol."+serviceType+".qps.enabled", true)
+	rl.Mode = archaius.GetString("cse.flowcontrol."+serviceType+".qps.mode", rate.ModeLocal)
 	if serviceType == common.Consumer {
 		keys := GetConsumerKey(inv.SourceMicroService, inv.MicroServiceName, inv.SchemaID, inv.OperationID)
 		rl.Rate, rl.Key = GetQPSRateWithPriority(
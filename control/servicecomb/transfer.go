@@ -43,6 +43,8 @@ func saveDefaultLB(raw *model.LoadBalancing) string { // return updated key
 		BackOffKind:             raw.Backoff.Kind,
 		BackOffMin:              raw.Backoff.MinMs,
 		BackOffMax:              raw.Backoff.MaxMs,
+		RetryPolicy:             toRetryPolicy(raw.RetryPolicy),
+		RetryBudgetPercent:      raw.RetryPolicy.BudgetPercent,
 		SessionTimeoutInSeconds: raw.SessionStickinessRule.SessionTimeoutInSeconds,
 		SuccessiveFailedTimes:   raw.SessionStickinessRule.SuccessiveFailedTimes,
 	}
@@ -60,6 +62,8 @@ func saveEachLB(k string, raw model.LoadBalancingSpec) string { // return update
 		BackOffKind:             raw.Backoff.Kind,
 		BackOffMin:              raw.Backoff.MinMs,
 		BackOffMax:              raw.Backoff.MaxMs,
+		RetryPolicy:             toRetryPolicy(raw.RetryPolicy),
+		RetryBudgetPercent:      raw.RetryPolicy.BudgetPercent,
 		SessionTimeoutInSeconds: raw.SessionStickinessRule.SessionTimeoutInSeconds,
 		SuccessiveFailedTimes:   raw.SessionStickinessRule.SuccessiveFailedTimes,
 	}
@@ -69,6 +73,10 @@ func saveEachLB(k string, raw model.LoadBalancingSpec) string { // return update
 	return k
 }
 
+func toRetryPolicy(raw model.RetryPolicy) retry.Policy {
+	return retry.NewPolicy(raw.StatusCodes, raw.Errors, raw.Methods)
+}
+
 func setDefaultLBValue(c *control.LoadBalancingConfig) {
 	if c.Strategy == "" {
 		c.Strategy = loadbalancer.StrategyRoundRobin
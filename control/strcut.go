@@ -1,5 +1,11 @@
 package control
 
+import (
+	"time"
+
+	"github.com/go-chassis/go-chassis/resilience/retry"
+)
+
 //LoadBalancingConfig is a standardized model
 type LoadBalancingConfig struct {
 	Strategy     string
@@ -10,6 +16,9 @@ type LoadBalancingConfig struct {
 	BackOffKind  string
 	BackOffMin   int
 	BackOffMax   int
+	RetryPolicy  retry.Policy
+	//RetryBudgetPercent is max percentage of retries to target service, 0 means no limit
+	RetryBudgetPercent int
 
 	SessionTimeoutInSeconds int
 	SuccessiveFailedTimes   int
@@ -20,6 +29,31 @@ type RateLimitingConfig struct {
 	Key     string
 	Enabled bool
 	Rate    int
+	//Mode is local or distributed
+	Mode string
+}
+
+//BulkheadConfig is a standardized model
+type BulkheadConfig struct {
+	//Name is the bulkhead name created by NewCircuitName with bulkhead scope
+	Name               string
+	Enabled            bool
+	Mode               string
+	MaxConcurrentCalls int
+	MaxWaitingCalls    int
+	MaxWaitDuration    time.Duration
+}
+
+//BreakerConfig is a standardized model of circuit breaker settings which hystrix does not have,
+//they are used only by native circuit breaker
+type BreakerConfig struct {
+	//WindowType is count or time
+	WindowType string
+	WindowSize int
+	//SlowCallDuration is the duration calls longer than it are slow
+	SlowCallDuration      time.Duration
+	SlowCallRateThreshold int
+	HalfOpenRequests      int
 }
 
 //EgressConfig is a standardized model
@@ -33,3 +67,16 @@ type EgressPort struct {
 	Port     int32
 	Protocol string
 }
+
+//HedgingConfig is a standardized model
+type HedgingConfig struct {
+	Enabled bool
+	//Delay is the time to wait before sending next attempt
+	Delay time.Duration
+	//Percentile is the latency percentile of service used as delay, it takes effect if it is greater than 0
+	Percentile float64
+	//MaxAttempts includes the first request
+	MaxAttempts int
+	//BudgetPercent is max percentage of hedged requests
+	BudgetPercent int
+}
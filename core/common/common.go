@@ -65,6 +65,7 @@ const (
 const (
 	ProtocolRest    = "rest"
 	ProtocolHighway = "highway"
+	ProtocolGRPC    = "grpc"
 	LBSessionID     = "go-chassisLB"
 )
 
@@ -102,11 +103,17 @@ const (
 	HeaderSourceName = "x-cse-src-microservice"
 	// HeaderXCseContent is constant for header , get some json msg about HeaderSourceName like {"k":"v"}
 	HeaderXCseContent = "x-cse-context"
+	// HeaderMirror marks a request which is a copy sent by request mirroring
+	HeaderMirror = "x-cse-mirror"
 )
 
 const (
 	// RestMethod is the http method for restful protocol
 	RestMethod = "method"
+	// Stream marks a invocation whose request body is a stream, it is never buffered
+	Stream = "stream"
+	// Mirror marks a invocation which is a copy sent to shadow destination, its response is discarded
+	Mirror = "mirror"
 )
 
 // constant for default application name and version
@@ -27,6 +27,13 @@ const (
 	PolicyThrowException                 = "throwexception"
 )
 
+// engines of circuit breaker
+const (
+	CircuitBreakerEngineKey     = "cse.circuitBreaker.engine"
+	CircuitBreakerEngineHystrix = "hystrix"
+	CircuitBreakerEngineNative  = "native"
+)
+
 var cbMutex = sync.RWMutex{}
 
 // GetFallbackEnabled get fallback enabled
@@ -136,6 +143,41 @@ func GetSleepWindow(command, t string) int {
 	return m
 }
 
+// GetWindowType get type of circuit breaker window, count or time
+func GetWindowType(command, t string) string {
+	return archaius.GetString(GetHystrixSpecificKey(NamespaceCircuitBreaker, command, PropertyWindowType),
+		archaius.GetString(GetHystrixSpecificKey(NamespaceCircuitBreaker, t, PropertyWindowType), ""))
+}
+
+// GetWindowSize get size of circuit breaker window, it is calls of count window or seconds of time window
+func GetWindowSize(command, t string) int {
+	return archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, command, PropertyWindowSize),
+		archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, t, PropertyWindowSize), 0))
+}
+
+// GetSlowCallDuration get duration in milliseconds, calls longer than it are slow
+func GetSlowCallDuration(command, t string) int {
+	return archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, command, PropertySlowCallDuration),
+		archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, t, PropertySlowCallDuration), 0))
+}
+
+// GetSlowCallRateThreshold get percentage of slow calls which trips circuit, 0 means disabled
+func GetSlowCallRateThreshold(command, t string) int {
+	return archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, command, PropertySlowCallRateThreshold),
+		archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, t, PropertySlowCallRateThreshold), 0))
+}
+
+// GetHalfOpenRequests get calls allowed in half open state
+func GetHalfOpenRequests(command, t string) int {
+	return archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, command, PropertyHalfOpenRequests),
+		archaius.GetInt(GetHystrixSpecificKey(NamespaceCircuitBreaker, t, PropertyHalfOpenRequests), 0))
+}
+
+// GetCircuitBreakerEngine get engine of circuit breaker, default is hystrix
+func GetCircuitBreakerEngine() string {
+	return archaius.GetString(CircuitBreakerEngineKey, CircuitBreakerEngineHystrix)
+}
+
 // GetPolicy get fallback policy
 func GetPolicy(service, t string) string {
 	cbMutex.RLock()
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/resilience/limit"
+)
+
+const (
+	concurrencyLimitPrefix = "cse.concurrencyLimit.Provider."
+)
+
+//GetConcurrencyLimitEnabled return whether adaptive concurrency limit of provider is enabled
+func GetConcurrencyLimitEnabled() bool {
+	return archaius.GetBool(concurrencyLimitPrefix+"enabled", false)
+}
+
+//GetConcurrencyLimitSettings return settings of adaptive concurrency limit of provider,
+//zero values are replaced by defaults in limiter
+func GetConcurrencyLimitSettings() limit.Settings {
+	s := limit.Settings{
+		Algorithm:    archaius.GetString(concurrencyLimitPrefix+"algorithm", limit.AlgorithmGradient),
+		InitialLimit: archaius.GetInt(concurrencyLimitPrefix+"initialLimit", limit.DefaultInitialLimit),
+		MinLimit:     archaius.GetInt(concurrencyLimitPrefix+"minLimit", limit.DefaultMinLimit),
+		MaxLimit:     archaius.GetInt(concurrencyLimitPrefix+"maxLimit", limit.DefaultMaxLimit),
+		Tolerance:    archaius.GetFloat64(concurrencyLimitPrefix+"gradient.tolerance", limit.DefaultTolerance),
+		Smoothing:    archaius.GetFloat64(concurrencyLimitPrefix+"gradient.smoothing", limit.DefaultSmoothing),
+		BackoffRatio: archaius.GetFloat64(concurrencyLimitPrefix+"aimd.backoffRatio", limit.DefaultBackoffRatio),
+	}
+	if d, err := time.ParseDuration(archaius.GetString(concurrencyLimitPrefix+"aimd.timeout", "")); err == nil {
+		s.Timeout = d
+	}
+	return s
+}
@@ -16,6 +16,11 @@ const (
 	PropertyErrorThresholdPercentage  = "errorThresholdPercentage"  //失败率
 	PropertyRequestVolumeThreshold    = "requestVolumeThreshold"    //窗口请求数
 	PropertySleepWindowInMilliseconds = "sleepWindowInMilliseconds" //熔断时间窗
+	PropertyWindowType                = "windowType"                //统计窗口类型
+	PropertyWindowSize                = "windowSize"                //统计窗口大小
+	PropertySlowCallDuration          = "slowCallDurationInMilliseconds"
+	PropertySlowCallRateThreshold     = "slowCallRateThreshold" //慢调用率
+	PropertyHalfOpenRequests          = "halfOpenRequests"      //半开状态请求数
 	PropertyEnabled                   = "enabled"
 	PropertyForce                     = "force"
 	PropertyPolicy                    = "policy"
@@ -5,6 +5,7 @@ import (
 	"github.com/go-chassis/go-chassis/resilience/retry"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -16,6 +17,16 @@ const (
 	propertyRetryOnSame                      = "retryOnSame"
 	propertyBackoffMinMs                     = "backoff.minMs"
 	propertyBackoffMaxMs                     = "backoff.maxMs"
+	propertyOutlierConsecutiveErrors         = "outlierDetection.consecutiveErrors"
+	propertyOutlierBaseEjectionTime          = "outlierDetection.baseEjectionTime"
+	propertyOutlierMaxEjectionTime           = "outlierDetection.maxEjectionTime"
+	propertyOutlierMaxEjectionPercent        = "outlierDetection.maxEjectionPercent"
+	propertyConsistentHash                   = "consistentHash"
+	propertyWeightKey                        = "weight.key"
+	propertyWeightSlowStartWindow            = "weight.slowStartWindow"
+	propertyZoneAwareSpilloverThreshold      = "zoneAware.spilloverThreshold"
+	propertyRetryBodyBufferSize              = "retryBody.bufferSize"
+	propertyRetryBodyMaxSize                 = "retryBody.maxSize"
 
 	//DefaultStrategy is default value for strategy
 	DefaultStrategy = "RoundRobin"
@@ -23,6 +34,24 @@ const (
 	DefaultSessionTimeout = 30
 	//DefaultFailedTimes is default value for failed times
 	DefaultFailedTimes = 5
+	//DefaultConsecutiveErrors is default value of consecutive errors which ejects an endpoint
+	DefaultConsecutiveErrors = 5
+	//DefaultBaseEjectionTime is default value of the first ejection time
+	DefaultBaseEjectionTime = 30 * time.Second
+	//DefaultMaxEjectionTime is default value of max ejection time
+	DefaultMaxEjectionTime = 300 * time.Second
+	//DefaultMaxEjectionPercent is default value of max percentage of ejected instances
+	DefaultMaxEjectionPercent = 10
+	//DefaultHashReplicas is default value of virtual nodes of an instance in hash ring
+	DefaultHashReplicas = 160
+	//DefaultMaglevTableSize is default value of maglev lookup table size, it must be a prime
+	DefaultMaglevTableSize = 65537
+	//DefaultWeightKey is default metadata key of instance weight
+	DefaultWeightKey = "weight"
+	//DefaultRetryBodyBufferSize is default value of max bytes of request body kept in memory for retry
+	DefaultRetryBodyBufferSize = 1 << 20
+	//DefaultRetryBodyMaxSize is default value of max bytes of request body saved for retry, including temp file
+	DefaultRetryBodyMaxSize = 64 << 20
 )
 
 var lbMutex = sync.RWMutex{}
@@ -129,3 +158,104 @@ func BackOffMaxMs(source, service string) int {
 	ms := archaius.GetInt(genKey(lbPrefix, service, propertyBackoffMaxMs), global)
 	return ms
 }
+
+//OutlierConsecutiveErrors return how many consecutive errors eject an endpoint of service
+func OutlierConsecutiveErrors(service string) int {
+	return lbInt(service, propertyOutlierConsecutiveErrors, DefaultConsecutiveErrors)
+}
+
+//OutlierBaseEjectionTime return the first ejection time, it grows each time the endpoint is ejected again
+func OutlierBaseEjectionTime(service string) time.Duration {
+	return lbDuration(service, propertyOutlierBaseEjectionTime, DefaultBaseEjectionTime)
+}
+
+//OutlierMaxEjectionTime return max ejection time
+func OutlierMaxEjectionTime(service string) time.Duration {
+	return lbDuration(service, propertyOutlierMaxEjectionTime, DefaultMaxEjectionTime)
+}
+
+//OutlierMaxEjectionPercent return max percentage of instances which can be ejected
+func OutlierMaxEjectionPercent(service string) int {
+	return lbInt(service, propertyOutlierMaxEjectionPercent, DefaultMaxEjectionPercent)
+}
+
+func lbDuration(service, property string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(lbString(service, property, def.String()))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+//WeightKey return metadata key of instance weight
+func WeightKey(service string) string {
+	return lbString(service, propertyWeightKey, DefaultWeightKey)
+}
+
+//SlowStartWindow return warmup time of new instance, weight of new instance grows linearly in this window,
+//0 means no warmup
+func SlowStartWindow(service string) time.Duration {
+	d, err := time.ParseDuration(lbString(service, propertyWeightSlowStartWindow, "0s"))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+//ZoneAwareSpilloverThreshold return the percentage of healthy instances in local zone,
+//under which requests spill over to other zones, 0 means never spill over
+func ZoneAwareSpilloverThreshold(service string) int {
+	return lbInt(service, propertyZoneAwareSpilloverThreshold, 0)
+}
+
+//RetryBodyBufferSize return max bytes of request body kept in memory for retry, the rest is saved in temp file
+func RetryBodyBufferSize() int64 {
+	return archaius.GetInt64(genKey(lbPrefix, propertyRetryBodyBufferSize), DefaultRetryBodyBufferSize)
+}
+
+//RetryBodyMaxSize return max bytes of request body saved for retry, larger request is not retried
+func RetryBodyMaxSize() int64 {
+	return archaius.GetInt64(genKey(lbPrefix, propertyRetryBodyMaxSize), DefaultRetryBodyMaxSize)
+}
+
+//ConsistentHashConfig is the config of consistent hash strategy,
+//hash key is read from header, query or invocation metadata in order
+type ConsistentHashConfig struct {
+	Header   string
+	Query    string
+	Metadata string
+	//Algorithm is ring or maglev
+	Algorithm string
+	Replicas  int
+	TableSize int
+	//BoundedLoadFactor limits in-flight calls of an instance to factor * average, 0 means no limit
+	BoundedLoadFactor float64
+}
+
+//GetConsistentHash return consistent hash config of service
+func GetConsistentHash(service string) ConsistentHashConfig {
+	return ConsistentHashConfig{
+		Header:            lbString(service, genKey(propertyConsistentHash, "header"), ""),
+		Query:             lbString(service, genKey(propertyConsistentHash, "query"), ""),
+		Metadata:          lbString(service, genKey(propertyConsistentHash, "metadata"), ""),
+		Algorithm:         lbString(service, genKey(propertyConsistentHash, "algorithm"), "ring"),
+		Replicas:          lbInt(service, genKey(propertyConsistentHash, "replicas"), DefaultHashReplicas),
+		TableSize:         lbInt(service, genKey(propertyConsistentHash, "tableSize"), DefaultMaglevTableSize),
+		BoundedLoadFactor: lbFloat64(service, genKey(propertyConsistentHash, "boundedLoadFactor"), 0),
+	}
+}
+
+func lbString(service, property, def string) string {
+	global := archaius.GetString(genKey(lbPrefix, property), def)
+	return archaius.GetString(genKey(lbPrefix, service, property), global)
+}
+
+func lbInt(service, property string, def int) int {
+	global := archaius.GetInt(genKey(lbPrefix, property), def)
+	return archaius.GetInt(genKey(lbPrefix, service, property), global)
+}
+
+func lbFloat64(service, property string, def float64) float64 {
+	global := archaius.GetFloat64(genKey(lbPrefix, property), def)
+	return archaius.GetFloat64(genKey(lbPrefix, service, property), global)
+}
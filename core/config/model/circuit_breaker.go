@@ -57,6 +57,11 @@ type CircuitBreakerSpec struct {
 	SleepWindowInMilliseconds int                                   `yaml:"sleepWindowInMilliseconds"`
 	RequestVolumeThreshold    int                                   `yaml:"requestVolumeThreshold"`
 	ErrorThresholdPercentage  int                                   `yaml:"errorThresholdPercentage"`
+	WindowType                string                                `yaml:"windowType"`
+	WindowSize                int                                   `yaml:"windowSize"`
+	SlowCallDuration          int                                   `yaml:"slowCallDurationInMilliseconds"`
+	SlowCallRateThreshold     int                                   `yaml:"slowCallRateThreshold"`
+	HalfOpenRequests          int                                   `yaml:"halfOpenRequests"`
 	AnyService                map[string]CircuitBreakPropertyStruct `yaml:",inline"`
 }
 
@@ -82,12 +87,17 @@ type IsolationPropertyStruct struct {
 
 // CircuitBreakPropertyStruct circuitBreaker 属性集合
 type CircuitBreakPropertyStruct struct {
-	Enabled                   bool `yaml:"enabled"`
-	ForceOpen                 bool `yaml:"forceOpen"`
-	ForceClose                bool `yaml:"forceClosed"`
-	SleepWindowInMilliseconds int  `yaml:"sleepWindowInMilliseconds"`
-	RequestVolumeThreshold    int  `yaml:"requestVolumeThreshold"`
-	ErrorThresholdPercentage  int  `yaml:"errorThresholdPercentage"`
+	Enabled                   bool   `yaml:"enabled"`
+	ForceOpen                 bool   `yaml:"forceOpen"`
+	ForceClose                bool   `yaml:"forceClosed"`
+	SleepWindowInMilliseconds int    `yaml:"sleepWindowInMilliseconds"`
+	RequestVolumeThreshold    int    `yaml:"requestVolumeThreshold"`
+	ErrorThresholdPercentage  int    `yaml:"errorThresholdPercentage"`
+	WindowType                string `yaml:"windowType"`
+	WindowSize                int    `yaml:"windowSize"`
+	SlowCallDuration          int    `yaml:"slowCallDurationInMilliseconds"`
+	SlowCallRateThreshold     int    `yaml:"slowCallRateThreshold"`
+	HalfOpenRequests          int    `yaml:"halfOpenRequests"`
 }
 
 // FallbackPropertyStruct fallback property structure
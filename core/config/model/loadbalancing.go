@@ -18,6 +18,7 @@ type LoadBalancing struct {
 	RetryOnSame           int                          `yaml:"retryOnSame"`
 	Filters               string                       `yaml:"serverListFilters"`
 	Backoff               BackoffStrategy              `yaml:"backoff"`
+	RetryPolicy           RetryPolicy                  `yaml:"retryPolicy"`
 	SessionStickinessRule SessionStickinessRule        `yaml:"SessionStickinessRule"`
 	AnyService            map[string]LoadBalancingSpec `yaml:",inline"`
 }
@@ -30,6 +31,7 @@ type LoadBalancingSpec struct {
 	RetryOnNext           int                   `yaml:"retryOnNext"`
 	RetryOnSame           int                   `yaml:"retryOnSame"`
 	Backoff               BackoffStrategy       `yaml:"backoff"`
+	RetryPolicy           RetryPolicy           `yaml:"retryPolicy"`
 }
 
 // SessionStickinessRule loadbalancing structure
@@ -38,6 +40,14 @@ type SessionStickinessRule struct {
 	SuccessiveFailedTimes   int `yaml:"successiveFailedTimes"`
 }
 
+// RetryPolicy decides which failed calls are retried, values are comma separated
+type RetryPolicy struct {
+	StatusCodes   string `yaml:"statusCodes"`
+	Errors        string `yaml:"errors"`
+	Methods       string `yaml:"methods"`
+	BudgetPercent int    `yaml:"budgetPercent"`
+}
+
 // BackoffStrategy back off strategy
 type BackoffStrategy struct {
 	Kind  string `yaml:"kind"`
@@ -5,11 +5,19 @@ import "github.com/go-chassis/go-archaius"
 //DefaultRouterType set the default router type
 const DefaultRouterType = "cse"
 
+//DefaultMirrorMaxInflight is default value of max shadow requests which are not finished
+const DefaultMirrorMaxInflight = 100
+
 // GetRouterType returns the type of router
 func GetRouterType() string {
 	return archaius.GetString("servicecomb.service.router.infra", DefaultRouterType)
 }
 
+// GetMirrorMaxInflight returns max shadow requests which are not finished, requests are not mirrored if it is reached
+func GetMirrorMaxInflight() int {
+	return archaius.GetInt("servicecomb.service.router.mirror.maxInflight", DefaultMirrorMaxInflight)
+}
+
 // GetRouterEndpoints returns the router address
 func GetRouterEndpoints() string {
 	return archaius.GetString("servicecomb.service.router.address", "")
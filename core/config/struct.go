@@ -48,6 +48,49 @@ type RouteRule struct {
 	Precedence int         `json:"precedence" yaml:"precedence"`
 	Routes     []*RouteTag `json:"route" yaml:"route"`
 	Match      Match       `json:"match" yaml:"match"`
+	Mirror     *Mirror     `json:"mirror,omitempty" yaml:"mirror"`
+	//actions of route
+	Headers *HeaderActions `json:"headers,omitempty" yaml:"headers"`
+	Rewrite *Rewrite       `json:"rewrite,omitempty" yaml:"rewrite"`
+	Timeout string         `json:"timeout,omitempty" yaml:"timeout"` //duration like 500ms
+	Retries *RouteRetry    `json:"retries,omitempty" yaml:"retries"`
+}
+
+// HeaderActions modifies headers of request and response
+type HeaderActions struct {
+	Request  *HeaderOperations `json:"request,omitempty" yaml:"request"`
+	Response *HeaderOperations `json:"response,omitempty" yaml:"response"`
+}
+
+// HeaderOperations sets, adds and removes headers
+type HeaderOperations struct {
+	Set    map[string]string `json:"set,omitempty" yaml:"set"`
+	Add    map[string]string `json:"add,omitempty" yaml:"add"`
+	Remove []string          `json:"remove,omitempty" yaml:"remove"`
+}
+
+// Rewrite rewrites path of http request, Path replaces whole path,
+// otherwise StripPrefix is removed from path and then Prefix is added
+type Rewrite struct {
+	Path        string `json:"path,omitempty" yaml:"path"`
+	StripPrefix string `json:"stripPrefix,omitempty" yaml:"stripPrefix"`
+	Prefix      string `json:"prefix,omitempty" yaml:"prefix"`
+}
+
+// RouteRetry overrides retry config of load balancing
+type RouteRetry struct {
+	RetryOnSame int    `json:"retryOnSame" yaml:"retryOnSame"`
+	RetryOnNext int    `json:"retryOnNext" yaml:"retryOnNext"`
+	StatusCodes string `json:"statusCodes,omitempty" yaml:"statusCodes"`
+	Errors      string `json:"errors,omitempty" yaml:"errors"`
+}
+
+// Mirror sends a copy of matched requests to a shadow destination, response of shadow is discarded
+type Mirror struct {
+	Service string            `json:"service" yaml:"service"` //shadow service, default is the target service
+	Tags    map[string]string `json:"tags" yaml:"tags"`
+	Percent float64           `json:"percent" yaml:"percent"` //percentage of matched requests to mirror, from 0 to 100
+	Timeout string            `json:"timeout,omitempty" yaml:"timeout"` //timeout of shadow request, duration like 500ms
 }
 
 // RouteTag gives route tag information
@@ -57,13 +100,18 @@ type RouteTag struct {
 	Label  string
 }
 
-// Match is checking source, source tags, and http headers
+// Match is checking source, source tags, http headers, and path, method, query or operation of request
 type Match struct {
 	Refer       string                       `json:"refer" yaml:"refer"`
 	Source      string                       `json:"source" yaml:"source"`
 	SourceTags  map[string]string            `json:"sourceTags" yaml:"sourceTags"`
 	HTTPHeaders map[string]map[string]string `json:"httpHeaders" yaml:"httpHeaders"`
 	Headers     map[string]map[string]string `json:"headers" yaml:"headers"`
+	Path        map[string]string            `json:"path,omitempty" yaml:"path"` //operator is key, like exact, prefix and regex
+	Method      string                       `json:"method,omitempty" yaml:"method"`
+	Query       map[string]map[string]string `json:"query,omitempty" yaml:"query"`
+	SchemaID    string                       `json:"schemaID,omitempty" yaml:"schemaID"`
+	OperationID string                       `json:"operationID,omitempty" yaml:"operationID"`
 }
 
 //DarkLaunchRule dark launch rule
@@ -40,6 +40,8 @@ func ProcessMatch(key string, value string) {
 type limiterPolicy struct {
 	Matcher string `json:"match"`
 	Quota   int    `json:"quota"`
+	//Mode is local or distributed, default is local
+	Mode string `json:"mode"`
 }
 
 //ProcessLimiter saves limiter, after a invocation is marked,
@@ -58,5 +60,9 @@ func ProcessLimiter(key string, value string) {
 	}
 
 	//key is match rule name, value is qps
+	if policy.Mode == "" {
+		policy.Mode = rate.ModeLocal
+	}
+	rate.GetRateLimiters().SetMode(policy.Matcher, policy.Mode)
 	rate.GetRateLimiters().UpdateRateLimit(policy.Matcher, policy.Quota, policy.Quota/5)
 }
@@ -45,6 +45,7 @@ func init() {
 	HandlerFuncMap[Router] = newRouterHandler
 	HandlerFuncMap[FaultInject] = newFaultHandler
 	HandlerFuncMap[TrafficMarker] = newMarkHandler
+	HandlerFuncMap[Hedging] = newHedgingHandler
 }
 
 // Handler interface for handlers
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/resilience/retry"
+	"github.com/go-mesh/openlogging"
+)
+
+//Hedging is handler name
+const Hedging = "hedging"
+
+//maxHedgingTokens limits hedges after a quiet time
+const maxHedgingTokens = 100
+
+var (
+	hedgingBudgets   = make(map[string]*retry.Budget)
+	hedgingBudgetsMu sync.Mutex
+)
+
+func getHedgingBudget(key string, percent int) *retry.Budget {
+	hedgingBudgetsMu.Lock()
+	defer hedgingBudgetsMu.Unlock()
+	b, ok := hedgingBudgets[key]
+	if !ok {
+		b = retry.NewBudget(percent, maxHedgingTokens)
+		hedgingBudgets[key] = b
+		return b
+	}
+	b.SetPercent(percent)
+	return b
+}
+
+//HedgingHandler sends another attempt to a different instance if response does not arrive in time,
+//the first successful response is used, and other attempts are canceled.
+//it must be placed before load balance handler, and only for idempotent requests
+type HedgingHandler struct{}
+
+type hedgingResult struct {
+	inv  *invocation.Invocation
+	resp *invocation.Response
+}
+
+//hedgingConfig get config from panel, or from archaius if panel does not provide it
+func hedgingConfig(i *invocation.Invocation) control.HedgingConfig {
+	if p, ok := control.DefaultPanel.(control.HedgingPanel); ok {
+		return p.GetHedging(*i)
+	}
+	return new(servicecomb.Panel).GetHedging(*i)
+}
+
+//Handle sends attempts until one of them succeeds or all of them fail
+func (h *HedgingHandler) Handle(chain *Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	//shadow requests are not hedged
+	if i.IsMirror() {
+		chain.Next(i, cb)
+		return
+	}
+	c := hedgingConfig(i)
+	if !c.Enabled || c.MaxAttempts < 2 || !hedgeable(i) {
+		chain.Next(i, cb)
+		return
+	}
+	budget := getHedgingBudget(control.NewCircuitName(common.Consumer, control.ScopeAPI, *i), c.BudgetPercent)
+	budget.Deposit()
+	delay := hedgingDelay(i, c)
+
+	exclusion := loadbalancer.NewExclusion()
+	results := make(chan *hedgingResult, c.MaxAttempts)
+	cancels := make([]context.CancelFunc, 0, c.MaxAttempts)
+	attempts := make([]*invocation.Invocation, 0, c.MaxAttempts)
+	send := func() {
+		inv, cancel := cloneForHedging(i, exclusion)
+		cancels = append(cancels, cancel)
+		attempts = append(attempts, inv)
+		go chain.Next(inv, func(r *invocation.Response) {
+			results <- &hedgingResult{inv: inv, resp: r}
+		})
+	}
+
+	send()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var result *hedgingResult
+	finished := 0
+wait:
+	for finished < len(attempts) {
+		select {
+		case result = <-results:
+			finished++
+			if result.resp.Err == nil {
+				break wait
+			}
+		case <-timer.C:
+			if len(attempts) < c.MaxAttempts && budget.Withdraw() {
+				openlogging.GetLogger().Debugf("hedge request to [%s] after %s", i.MicroServiceName, delay)
+				send()
+				timer.Reset(delay)
+			}
+		}
+	}
+	for n, inv := range attempts {
+		if inv != result.inv {
+			cancels[n]()
+			continue
+		}
+		//body of response is read after call, so context of winner is canceled after body is closed
+		if resp, ok := inv.Reply.(*http.Response); ok && resp.Body != nil {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancels[n]}
+		} else {
+			cancels[n]()
+		}
+	}
+	go drainHedging(results, len(attempts)-finished)
+
+	i.Endpoint = result.inv.Endpoint
+	i.Protocol = result.inv.Protocol
+	i.SSLEnable = result.inv.SSLEnable
+	i.Strategy = result.inv.Strategy
+	i.Filters = result.inv.Filters
+	reflect.ValueOf(i.Reply).Elem().Set(reflect.ValueOf(result.inv.Reply).Elem())
+	if result.resp.Result != nil {
+		result.resp.Result = i.Reply
+	}
+	cb(result.resp)
+}
+
+//drainHedging waits for canceled attempts, and closes body of their responses
+func drainHedging(results chan *hedgingResult, n int) {
+	for ; n > 0; n-- {
+		r := <-results
+		if resp, ok := r.inv.Reply.(*http.Response); ok && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}
+}
+
+//hedgingDelay return percentile latency of service if there is, or the fixed delay
+func hedgingDelay(i *invocation.Invocation, c control.HedgingConfig) time.Duration {
+	if c.Percentile > 0 {
+		if d, ok := loadbalancer.LatencyPercentile(i.MicroServiceName, i.RouteTags.String(), i.Protocol, c.Percentile); ok {
+			return d
+		}
+	}
+	return c.Delay
+}
+
+//hedgeable return whether request can be sent more than once
+func hedgeable(i *invocation.Invocation) bool {
+	if i.Reply == nil || reflect.TypeOf(i.Reply).Kind() != reflect.Ptr {
+		return false
+	}
+	if req, ok := i.Args.(*http.Request); ok {
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return true
+}
+
+//cloneForHedging copies invocation, attempts have their own request, reply, headers and metadata
+func cloneForHedging(i *invocation.Invocation, exclusion *loadbalancer.Exclusion) (*invocation.Invocation, context.CancelFunc) {
+	inv := *i
+	parent := i.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	headers := make(map[string]string)
+	for k, v := range common.FromContext(parent) {
+		headers[k] = v
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(parent, common.ContextHeaderKey{}, headers))
+	inv.Ctx = ctx
+	inv.Metadata = make(map[string]interface{}, len(i.Metadata)+1)
+	for k, v := range i.Metadata {
+		inv.Metadata[k] = v
+	}
+	inv.Metadata[loadbalancer.MetaExclusion] = exclusion
+	inv.Reply = reflect.New(reflect.TypeOf(i.Reply).Elem()).Interface()
+	if req, ok := i.Args.(*http.Request); ok {
+		r := req.Clone(ctx)
+		if req.GetBody != nil {
+			r.Body, _ = req.GetBody()
+		}
+		inv.Args = r
+	}
+	return &inv, cancel
+}
+
+//Name returns hedging string
+func (h *HedgingHandler) Name() string {
+	return Hedging
+}
+
+func newHedgingHandler() Handler {
+	return &HedgingHandler{}
+}
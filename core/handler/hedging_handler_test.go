@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/control"
+	_ "github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/stretchr/testify/assert"
+)
+
+//hedgingTarget picks endpoints like load balancer, the first endpoint is slow
+type hedgingTarget struct {
+	mu        sync.Mutex
+	endpoints []string
+	fail      bool
+	//ctx is context of the last successful attempt
+	ctx context.Context
+}
+
+func (h *hedgingTarget) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	ep := "10.0.7.1:8080"
+	if e := loadbalancer.GetExclusion(i); e != nil && e.Has(ep) {
+		ep = "10.0.7.2:8080"
+	}
+	if e := loadbalancer.GetExclusion(i); e != nil {
+		e.Add(ep)
+	}
+	h.mu.Lock()
+	h.endpoints = append(h.endpoints, ep)
+	h.mu.Unlock()
+	i.Endpoint = ep
+	if ep == "10.0.7.1:8080" {
+		select {
+		case <-i.Ctx.Done():
+			cb(&invocation.Response{Err: i.Ctx.Err()})
+			return
+		case <-time.After(time.Second):
+		}
+	}
+	if h.fail {
+		cb(&invocation.Response{Err: errors.New("failed")})
+		return
+	}
+	h.mu.Lock()
+	h.ctx = i.Ctx
+	h.mu.Unlock()
+	resp := i.Reply.(*http.Response)
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(strings.NewReader("ok"))
+	cb(&invocation.Response{Result: i.Reply})
+}
+
+func (h *hedgingTarget) Name() string {
+	return "hedging-target"
+}
+
+func TestHedgingHandler_Handle(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	assert.NoError(t, control.Init(control.Options{}))
+	archaius.Set("cse.hedging.Consumer.HedgingServer.enabled", "true")
+	archaius.Set("cse.hedging.Consumer.HedgingServer.delay", "20ms")
+	defer archaius.Delete("cse.hedging.Consumer.HedgingServer.enabled")
+	defer archaius.Delete("cse.hedging.Consumer.HedgingServer.delay")
+
+	newInvocation := func() *invocation.Invocation {
+		return &invocation.Invocation{
+			MicroServiceName: "HedgingServer",
+			Protocol:         "rest",
+			Args:             &http.Request{Method: http.MethodGet},
+			Reply:            &http.Response{},
+		}
+	}
+	t.Run("faster response wins", func(t *testing.T) {
+		target := &hedgingTarget{}
+		c := handler.Chain{}
+		c.AddHandler(&handler.HedgingHandler{})
+		c.AddHandler(target)
+		inv := newInvocation()
+		start := time.Now()
+		c.Next(inv, func(r *invocation.Response) {
+			assert.NoError(t, r.Err)
+		})
+		assert.True(t, time.Since(start) < time.Second)
+		assert.Equal(t, "10.0.7.2:8080", inv.Endpoint)
+		assert.Equal(t, http.StatusOK, inv.Reply.(*http.Response).StatusCode)
+		target.mu.Lock()
+		assert.Equal(t, []string{"10.0.7.1:8080", "10.0.7.2:8080"}, target.endpoints)
+		ctx := target.ctx
+		target.mu.Unlock()
+
+		assert.NoError(t, ctx.Err(), "winner is not canceled before body is read")
+		b, err := ioutil.ReadAll(inv.Reply.(*http.Response).Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", string(b))
+		assert.NoError(t, inv.Reply.(*http.Response).Body.Close())
+		assert.Error(t, ctx.Err())
+	})
+	t.Run("all attempts fail", func(t *testing.T) {
+		target := &hedgingTarget{fail: true}
+		c := handler.Chain{}
+		c.AddHandler(&handler.HedgingHandler{})
+		c.AddHandler(target)
+		c.Next(newInvocation(), func(r *invocation.Response) {
+			assert.Error(t, r.Err)
+		})
+	})
+	t.Run("config is read from archaius if panel does not provide it", func(t *testing.T) {
+		old := control.DefaultPanel
+		control.DefaultPanel = struct{ control.Panel }{old}
+		defer func() { control.DefaultPanel = old }()
+		target := &hedgingTarget{}
+		c := handler.Chain{}
+		c.AddHandler(&handler.HedgingHandler{})
+		c.AddHandler(target)
+		inv := newInvocation()
+		c.Next(inv, func(r *invocation.Response) {
+			assert.NoError(t, r.Err)
+		})
+		assert.Equal(t, "10.0.7.2:8080", inv.Endpoint)
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/client"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/pkg/metrics"
+	"github.com/go-chassis/go-chassis/resilience/retry"
+	"github.com/go-mesh/openlogging"
+)
+
+//retry metrics of load balance handler
+const (
+	MetricRetryAttempts        = "retry_attempt_total"
+	MetricRetryBudgetExhausted = "retry_budget_exhausted_total"
+	MetricRetrySuccess         = "retry_success_total"
+)
+
+//maxRetryTokens limits retries after a quiet time
+const maxRetryTokens = 100
+
+//maxDrainSize is max bytes read from body of a retried response,
+//connection is not reused if body is larger
+const maxDrainSize = 4 << 10
+
+var (
+	errRetryBudgetExhausted = errors.New("retry budget is exhausted")
+	errRetriableStatus      = errors.New("response status is retriable")
+)
+
+var (
+	retryBudgets   = make(map[string]*retry.Budget)
+	retryBudgetsMu sync.Mutex
+
+	retryMetricsMu      sync.Mutex
+	retryMetricsCreated bool
+)
+
+//getRetryBudget return budget of target service, it is nil if there is no limit
+func getRetryBudget(service string, percent int) *retry.Budget {
+	if percent <= 0 {
+		return nil
+	}
+	retryBudgetsMu.Lock()
+	defer retryBudgetsMu.Unlock()
+	b, ok := retryBudgets[service]
+	if !ok {
+		b = retry.NewBudget(percent, maxRetryTokens)
+		retryBudgets[service] = b
+		return b
+	}
+	b.SetPercent(percent)
+	return b
+}
+
+//requestMethod return http method of invocation, it is empty if protocol is not http
+func requestMethod(i *invocation.Invocation) string {
+	if req, ok := i.Args.(*http.Request); ok && req != nil {
+		if req.Method == "" {
+			return http.MethodGet
+		}
+		return req.Method
+	}
+	return ""
+}
+
+//retriable return whether the response should be retried by policy, canceled calls are never retried
+func retriable(i *invocation.Invocation, r *invocation.Response, p retry.Policy) bool {
+	if r == nil || r.Err == client.ErrCanceled {
+		return false
+	}
+	if i.Ctx != nil && i.Ctx.Err() != nil {
+		return false
+	}
+	return p.Retriable(r.Status, r.Err)
+}
+
+//discardReply drains and closes body of response which is going to be retried,
+//so that connection can be reused and context of call is canceled
+func discardReply(i *invocation.Invocation) {
+	resp, ok := i.Reply.(*http.Response)
+	if !ok || resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxDrainSize))
+	resp.Body.Close()
+	resp.Body = http.NoBody
+}
+
+//createRetryMetrics creates metrics if they are not created, it does nothing before metrics are initialized
+func createRetryMetrics() {
+	retryMetricsMu.Lock()
+	defer retryMetricsMu.Unlock()
+	if retryMetricsCreated {
+		return
+	}
+	for _, name := range []string{MetricRetryAttempts, MetricRetryBudgetExhausted, MetricRetrySuccess} {
+		if err := metrics.CreateCounter(metrics.CounterOpts{
+			Name:   name,
+			Help:   retryMetricsHelp[name],
+			Labels: []string{"service"},
+		}); err != nil {
+			openlogging.Warn("can not create retry metrics: " + err.Error())
+			return
+		}
+	}
+	retryMetricsCreated = true
+}
+
+var retryMetricsHelp = map[string]string{
+	MetricRetryAttempts:        "total retries to target service",
+	MetricRetryBudgetExhausted: "total calls which are not retried because retry budget is exhausted",
+	MetricRetrySuccess:         "total calls which succeed after retry",
+}
+
+func reportRetry(name, service string) {
+	if err := metrics.CounterAdd(name, 1, map[string]string{"service": service}); err != nil {
+		openlogging.GetLogger().Debugf("report retry metric failed: %s", err)
+	}
+}
@@ -0,0 +1,208 @@
+package handler_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config/model"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/core/registry"
+	mk "github.com/go-chassis/go-chassis/core/registry/mock"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/stretchr/testify/assert"
+)
+
+//closeTracker records whether body is closed
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+//statusHandler responds with status in order, the last one is repeated
+type statusHandler struct {
+	status     []int
+	retryAfter string
+	calls      int
+	onCall     func(req *http.Request)
+	bodies     []*closeTracker
+}
+
+func (h *statusHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	s := h.status[len(h.status)-1]
+	if h.calls < len(h.status) {
+		s = h.status[h.calls]
+	}
+	h.calls++
+	if h.onCall != nil {
+		h.onCall(i.Args.(*http.Request))
+	}
+	resp := i.Reply.(*http.Response)
+	resp.StatusCode = s
+	resp.Header = http.Header{}
+	body := &closeTracker{Reader: strings.NewReader("response")}
+	h.bodies = append(h.bodies, body)
+	resp.Body = body
+	if h.retryAfter != "" {
+		resp.Header.Set("Retry-After", h.retryAfter)
+	}
+	cb(&invocation.Response{Status: s, Result: resp})
+}
+
+func (h *statusHandler) Name() string {
+	return "status"
+}
+
+func TestLBHandler_RetryPolicy(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	assert.NoError(t, control.Init(control.Options{}))
+	spec := model.LoadBalancingSpec{
+		RetryEnabled: true,
+		RetryOnSame:  2,
+		Backoff:      model.BackoffStrategy{Kind: "zero"},
+		RetryPolicy: model.RetryPolicy{
+			StatusCodes:   "503",
+			Methods:       "GET,HEAD",
+			BudgetPercent: 1,
+		},
+	}
+	//retry budget is shared by calls to a service,
+	//so tests which retry, except the first one, use their own services and do not change budget test
+	servicecomb.SaveToLBCache(&model.LoadBalancing{
+		AnyService: map[string]model.LoadBalancingSpec{
+			"RetryServer":  spec,
+			"ReplayServer": spec,
+			"DrainServer":  spec,
+		},
+	})
+	defer servicecomb.SaveToLBCache(&model.LoadBalancing{})
+	loadbalancer.Enable(loadbalancer.StrategyRoundRobin)
+	d := new(mk.DiscoveryMock)
+	registry.DefaultServiceDiscoveryService = d
+	for n, service := range []string{"RetryServer", "ReplayServer", "DrainServer"} {
+		d.On("FindMicroServiceInstances", "", "default", service, "1.0", "").Return([]*registry.MicroServiceInstance{{
+			InstanceID:   "ins1",
+			EndpointsMap: map[string]*registry.Endpoint{"rest": {Address: fmt.Sprintf("10.0.9.%d:8080", n+1)}},
+		}}, nil)
+	}
+
+	call := func(h *statusHandler, method string, opts ...func(*invocation.Invocation)) *http.Response {
+		c := handler.Chain{}
+		c.AddHandler(&handler.LBHandler{})
+		c.AddHandler(h)
+		inv := &invocation.Invocation{
+			MicroServiceName: "RetryServer",
+			Protocol:         "rest",
+			Strategy:         loadbalancer.StrategyRoundRobin,
+			RouteTags:        utiltags.NewDefaultTag("1.0", "default"),
+			Args:             &http.Request{Method: method},
+			Reply:            &http.Response{},
+		}
+		for _, o := range opts {
+			o(inv)
+		}
+		c.Next(inv, func(r *invocation.Response) {
+			assert.NoError(t, r.Err)
+		})
+		return inv.Reply.(*http.Response)
+	}
+	t.Run("retriable status is retried", func(t *testing.T) {
+		h := &statusHandler{status: []int{503, 200}}
+		assert.Equal(t, http.StatusOK, call(h, http.MethodGet).StatusCode)
+		assert.Equal(t, 2, h.calls)
+	})
+	t.Run("body of retried response is closed", func(t *testing.T) {
+		h := &statusHandler{status: []int{503, 503, 200}}
+		resp := call(h, http.MethodGet, func(inv *invocation.Invocation) {
+			inv.MicroServiceName = "DrainServer"
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 3, len(h.bodies))
+		assert.True(t, h.bodies[0].closed)
+		assert.True(t, h.bodies[1].closed)
+		assert.False(t, h.bodies[2].closed, "body of last response is returned to caller")
+		b, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "response", string(b))
+	})
+	t.Run("other status is not retried", func(t *testing.T) {
+		h := &statusHandler{status: []int{500, 200}}
+		assert.Equal(t, http.StatusInternalServerError, call(h, http.MethodGet).StatusCode)
+		assert.Equal(t, 1, h.calls)
+	})
+	t.Run("method is not retriable", func(t *testing.T) {
+		h := &statusHandler{status: []int{503, 200}}
+		assert.Equal(t, http.StatusServiceUnavailable, call(h, http.MethodPost).StatusCode)
+		assert.Equal(t, 1, h.calls)
+	})
+	t.Run("retry after is too long", func(t *testing.T) {
+		h := &statusHandler{status: []int{503, 200}, retryAfter: "60"}
+		assert.Equal(t, http.StatusServiceUnavailable, call(h, http.MethodGet).StatusCode)
+		assert.Equal(t, 1, h.calls)
+	})
+	t.Run("route retry overrides config", func(t *testing.T) {
+		d.On("FindMicroServiceInstances", "", "default", "RouteRetryServer", "1.0", "").Return([]*registry.MicroServiceInstance{{
+			InstanceID:   "ins2",
+			EndpointsMap: map[string]*registry.Endpoint{"rest": {Address: "10.0.8.2:8080"}},
+		}}, nil)
+		//retry is not enabled for the service
+		h := &statusHandler{status: []int{500, 200}}
+		route := func(inv *invocation.Invocation) {
+			inv.MicroServiceName = "RouteRetryServer"
+			inv.RouteAction = &invocation.RouteAction{Retry: &invocation.RouteRetry{RetryOnSame: 1, StatusCodes: "5xx"}}
+		}
+		assert.Equal(t, http.StatusOK, call(h, http.MethodGet, route).StatusCode)
+		assert.Equal(t, 2, h.calls)
+
+		h = &statusHandler{status: []int{503, 200}}
+		route = func(inv *invocation.Invocation) {
+			inv.RouteAction = &invocation.RouteAction{Retry: &invocation.RouteRetry{}}
+		}
+		assert.Equal(t, http.StatusServiceUnavailable, call(h, http.MethodGet, route).StatusCode)
+		assert.Equal(t, 1, h.calls)
+	})
+	t.Run("stream is not retried", func(t *testing.T) {
+		h := &statusHandler{status: []int{503, 200}}
+		stream := func(inv *invocation.Invocation) {
+			inv.SetMetadata(common.Stream, true)
+		}
+		assert.Equal(t, http.StatusServiceUnavailable, call(h, http.MethodGet, stream).StatusCode)
+		assert.Equal(t, 1, h.calls)
+	})
+	t.Run("body is replayed", func(t *testing.T) {
+		h := &statusHandler{status: []int{503, 200}}
+		var bodies []string
+		h.onCall = func(req *http.Request) {
+			b, _ := ioutil.ReadAll(req.Body)
+			bodies = append(bodies, string(b))
+		}
+		body := func(inv *invocation.Invocation) {
+			inv.MicroServiceName = "ReplayServer"
+			inv.Args.(*http.Request).Body = ioutil.NopCloser(strings.NewReader("hello"))
+		}
+		assert.Equal(t, http.StatusOK, call(h, http.MethodGet, body).StatusCode)
+		assert.Equal(t, []string{"hello", "hello"}, bodies)
+	})
+	t.Run("budget is exhausted", func(t *testing.T) {
+		h := &statusHandler{status: []int{503}}
+		for n := 0; n < 3; n++ {
+			call(h, http.MethodGet)
+		}
+		//the first call uses the last 2 tokens, others are not retried
+		assert.Equal(t, 3+1+1, h.calls)
+	})
+}
@@ -1,13 +1,14 @@
 package handler
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/util/httputil"
 	"github.com/go-chassis/go-chassis/resilience/retry"
-	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/cenkalti/backoff"
 	"github.com/go-chassis/go-archaius"
@@ -53,6 +54,8 @@ func (lb *LBHandler) getEndpoint(i *invocation.Invocation, lbConfig control.Load
 		lbErr := loadbalancer.LBError{Message: err.Error()}
 		return nil, lbErr
 	}
+	exclusion := loadbalancer.GetExclusion(i)
+	ins = repick(s, ins, exclusion)
 
 	if i.Protocol == "" {
 		i.Protocol = archaius.GetString("cse.references."+i.MicroServiceName+".transport", ins.DefaultProtocol)
@@ -73,13 +76,43 @@ func (lb *LBHandler) getEndpoint(i *invocation.Invocation, lbConfig control.Load
 		openlogging.GetLogger().Errorf(lbErr.Error())
 		return nil, lbErr
 	}
+	if exclusion != nil {
+		exclusion.Add(ep.Address)
+	}
 	return ep, nil
 }
 
+//maxRepick is the max times to pick again when picked instance is excluded
+const maxRepick = 3
+
+//repick picks again if any endpoint of instance is excluded, the last picked one is used if all of them are excluded
+func repick(s loadbalancer.Strategy, ins *registry.MicroServiceInstance, exclusion *loadbalancer.Exclusion) *registry.MicroServiceInstance {
+	if exclusion == nil {
+		return ins
+	}
+	excluded := func(ins *registry.MicroServiceInstance) bool {
+		for _, ep := range ins.EndpointsMap {
+			if exclusion.Has(ep.Address) {
+				return true
+			}
+		}
+		return false
+	}
+	for n := 0; n < maxRepick && excluded(ins); n++ {
+		next, err := s.Pick()
+		if err != nil {
+			return ins
+		}
+		ins = next
+	}
+	return ins
+}
+
 // Handle to handle the load balancing
 func (lb *LBHandler) Handle(chain *Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
-	lbConfig := control.DefaultPanel.GetLoadBalancing(*i)
-	if !lbConfig.RetryEnabled {
+	lbConfig := routeRetry(i, control.DefaultPanel.GetLoadBalancing(*i))
+	//shadow requests are not retried, they must not add load to provider
+	if !lbConfig.RetryEnabled || i.IsMirror() {
 		lb.handleWithNoRetry(chain, i, lbConfig, cb)
 	} else {
 		lb.handleWithRetry(chain, i, lbConfig, cb)
@@ -99,21 +132,38 @@ func (lb *LBHandler) handleWithNoRetry(chain *Chain, i *invocation.Invocation, l
 }
 
 func (lb *LBHandler) handleWithRetry(chain *Chain, i *invocation.Invocation, lbConfig control.LoadBalancingConfig, cb invocation.ResponseCallBack) {
+	policy := lbConfig.RetryPolicy
+	if !policy.MatchMethod(requestMethod(i)) || i.IsStream() {
+		lb.handleWithNoRetry(chain, i, lbConfig, cb)
+		return
+	}
+	var body *httputil.ReplayableBody
+	if req, ok := i.Args.(*http.Request); ok && req != nil {
+		var err error
+		body, err = httputil.NewReplayableBody(req, config.RetryBodyBufferSize(), config.RetryBodyMaxSize())
+		if err == httputil.ErrBodyNotReplayable {
+			openlogging.GetLogger().Debugf("request to [%s] is not retried, body is too large", i.MicroServiceName)
+			lb.handleWithNoRetry(chain, i, lbConfig, cb)
+			return
+		}
+		if err != nil {
+			WriteBackErr(err, status.Status(i.Protocol, status.InternalServerError), cb)
+			return
+		}
+		defer body.Close()
+	}
 	retryOnSame := lbConfig.RetryOnSame
 	retryOnNext := lbConfig.RetryOnNext
+	budget := getRetryBudget(i.MicroServiceName, lbConfig.RetryBudgetPercent)
+	if budget != nil {
+		budget.Deposit()
+	}
 	handlerIndex := i.HandlerIndex
 	var invResp *invocation.Response
-	var reqBytes []byte
-	if req, ok := i.Args.(*http.Request); ok {
-		if req != nil {
-			if req.Body != nil {
-				reqBytes, _ = ioutil.ReadAll(req.Body)
-			}
-		}
-	}
 	// get retry func
-	lbBackoff := retry.GetBackOff(lbConfig.BackOffKind, lbConfig.BackOffMin, lbConfig.BackOffMax)
+	lbBackoff := &retry.RetryAfterBackOff{BackOff: retry.GetBackOff(lbConfig.BackOffKind, lbConfig.BackOffMin, lbConfig.BackOffMax)}
 	callTimes := 0
+	attempts := 0
 
 	ep, err := lb.getEndpoint(i, lbConfig)
 	if err != nil {
@@ -125,20 +175,29 @@ func (lb *LBHandler) handleWithRetry(chain *Chain, i *invocation.Invocation, lbC
 		i.Endpoint = ep.Address
 		i.SSLEnable = ep.IsSSLEnable()
 		callTimes++
-		var respErr error
+		attempts++
 		i.HandlerIndex = handlerIndex
 
-		if _, ok := i.Args.(*http.Request); ok {
-			i.Args.(*http.Request).Body = ioutil.NopCloser(bytes.NewBuffer(reqBytes))
+		if body != nil {
+			if err := body.Reset(); err != nil {
+				invResp = &invocation.Response{Err: err}
+				return backoff.Permanent(err)
+			}
 		}
 
 		chain.Next(i, func(r *invocation.Response) {
 			if r != nil {
 				invResp = r
-				respErr = invResp.Err
 				return
 			}
 		})
+		if !retriable(i, invResp, policy) {
+			return nil
+		}
+		respErr := errRetriableStatus
+		if invResp.Err != nil {
+			respErr = invResp.Err
+		}
 
 		if callTimes >= retryOnSame+1 {
 			if retryOnNext <= 0 {
@@ -152,10 +211,26 @@ func (lb *LBHandler) handleWithRetry(chain *Chain, i *invocation.Invocation, lbC
 			callTimes = 0
 			retryOnNext--
 		}
+		if resp, ok := i.Reply.(*http.Response); ok && resp.Header != nil {
+			if d, ok := retry.RetryAfter(resp.Header, time.Now()); ok {
+				if d > retry.MaxRetryAfter {
+					return backoff.Permanent(fmt.Errorf("retry after %s is too long", d))
+				}
+				lbBackoff.SetRetryAfter(d)
+			}
+		}
+		if budget != nil && !budget.Withdraw() {
+			reportRetry(MetricRetryBudgetExhausted, i.MicroServiceName)
+			return backoff.Permanent(errRetryBudgetExhausted)
+		}
+		reportRetry(MetricRetryAttempts, i.MicroServiceName)
+		discardReply(i)
 		return respErr
 	}
 	if err := backoff.Retry(operation, lbBackoff); err != nil {
 		openlogging.GetLogger().Errorf("stop retry , error : %v", err)
+	} else if attempts > 1 {
+		reportRetry(MetricRetrySuccess, i.MicroServiceName)
 	}
 
 	if invResp == nil {
@@ -170,5 +245,6 @@ func (lb *LBHandler) Name() string {
 }
 
 func newLBHandler() Handler {
+	createRetryMetrics()
 	return &LBHandler{}
 }
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/router"
+	"github.com/go-mesh/openlogging"
+)
+
+//detachedContext keeps values of parent, but it is not canceled when parent is canceled,
+//so that shadow request is not interrupted after caller returns
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+//mirrorInflight is the shadow requests which are not finished
+var mirrorInflight int64
+
+//mirror sends a copy of invocation to shadow destination in another goroutine,
+//the copy goes through rest of chain, and its response is discarded.
+//requests are not mirrored if too many shadow requests are not finished
+func mirror(chain *Chain, i *invocation.Invocation, m *config.Mirror) {
+	if i.IsStream() || !hedgeable(i) {
+		openlogging.GetLogger().Debugf("request to [%s] is not mirrored, it can not be sent twice", i.MicroServiceName)
+		return
+	}
+	if atomic.AddInt64(&mirrorInflight, 1) > int64(config.GetMirrorMaxInflight()) {
+		atomic.AddInt64(&mirrorInflight, -1)
+		openlogging.GetLogger().Debugf("request to [%s] is not mirrored, too many shadow requests", i.MicroServiceName)
+		return
+	}
+	inv, cancel := cloneForMirror(i, m)
+	go func() {
+		defer atomic.AddInt64(&mirrorInflight, -1)
+		defer cancel()
+		chain.Next(inv, func(r *invocation.Response) {
+			if r.Err != nil {
+				openlogging.GetLogger().Debugf("mirror request to [%s] failed: %s", inv.MicroServiceName, r.Err)
+			}
+			if resp, ok := inv.Reply.(*http.Response); ok && resp.Body != nil {
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}()
+}
+
+//cloneForMirror copies invocation, the copy has its own request, reply, headers and metadata,
+//and it is marked by header and metadata.
+//it is not canceled with caller, but it has its own timeout
+func cloneForMirror(i *invocation.Invocation, m *config.Mirror) (*invocation.Invocation, context.CancelFunc) {
+	inv := *i
+	parent := i.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	headers := make(map[string]string)
+	for k, v := range common.FromContext(parent) {
+		headers[k] = v
+	}
+	headers[common.HeaderMirror] = common.TRUE
+	ctx, cancel := context.WithTimeout(context.WithValue(detachedContext{parent}, common.ContextHeaderKey{}, headers), router.MirrorTimeout(m))
+	inv.Ctx = ctx
+	inv.Metadata = make(map[string]interface{}, len(i.Metadata)+1)
+	for k, v := range i.Metadata {
+		inv.Metadata[k] = v
+	}
+	inv.Metadata[common.Mirror] = true
+	if m.Service != "" && m.Service != i.MicroServiceName {
+		inv.MicroServiceName = m.Service
+		inv.Endpoint = ""
+	}
+	inv.RouteTags = router.MirrorTags(m)
+	inv.Reply = reflect.New(reflect.TypeOf(i.Reply).Elem()).Interface()
+	if req, ok := i.Args.(*http.Request); ok {
+		r := req.Clone(inv.Ctx)
+		if req.GetBody != nil {
+			r.Body, _ = req.GetBody()
+		}
+		inv.Args = r
+	}
+	return &inv, cancel
+}
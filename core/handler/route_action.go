@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/resilience/retry"
+)
+
+//applyRequestActions modifies headers and path of request by route action
+func applyRequestActions(i *invocation.Invocation, a *invocation.RouteAction) {
+	req, _ := i.Args.(*http.Request)
+	if req != nil && req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if o := a.RequestHeaders; o != nil {
+		if i.Ctx == nil {
+			i.Ctx = context.Background()
+		}
+		//headers in context may be shared with other calls, so they are copied before changing
+		headers := make(map[string]string)
+		if old, ok := i.Ctx.Value(common.ContextHeaderKey{}).(map[string]string); ok {
+			for k, v := range old {
+				headers[k] = v
+			}
+		}
+		i.Ctx = context.WithValue(i.Ctx, common.ContextHeaderKey{}, headers)
+		for _, k := range o.Remove {
+			delete(headers, k)
+			if req != nil {
+				req.Header.Del(k)
+			}
+		}
+		for k, v := range o.Set {
+			headers[k] = v
+			if req != nil {
+				req.Header.Set(k, v)
+			}
+		}
+		for k, v := range o.Add {
+			//headers in context overwrite headers of http request, so value is appended to context if it exists
+			if old, ok := headers[k]; ok {
+				headers[k] = old + "," + v
+			} else if req != nil {
+				req.Header.Add(k, v)
+			} else {
+				headers[k] = v
+			}
+		}
+	}
+	if a.Rewrite != nil && req != nil && req.URL != nil {
+		req.URL.Path = rewritePath(req.URL.Path, a.Rewrite)
+		req.URL.RawPath = ""
+	}
+}
+
+func rewritePath(path string, rw *invocation.Rewrite) string {
+	if rw.Path != "" {
+		return rw.Path
+	}
+	//prefix is stripped only at segment boundary, /api does not match /apiv2
+	if p := strings.TrimSuffix(rw.StripPrefix, "/"); p != "" && (path == p || strings.HasPrefix(path, p+"/")) {
+		path = path[len(p):]
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if rw.Prefix != "" {
+		path = strings.TrimSuffix(rw.Prefix, "/") + path
+	}
+	return path
+}
+
+//applyResponseActions modifies headers of http response
+func applyResponseActions(i *invocation.Invocation, o *invocation.HeaderOperations) {
+	resp, ok := i.Reply.(*http.Response)
+	if !ok || resp == nil {
+		return
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	for _, k := range o.Remove {
+		resp.Header.Del(k)
+	}
+	for k, v := range o.Set {
+		resp.Header.Set(k, v)
+	}
+	for k, v := range o.Add {
+		resp.Header.Add(k, v)
+	}
+}
+
+//routeRetry overrides retry config of load balancing by route action
+func routeRetry(i *invocation.Invocation, c control.LoadBalancingConfig) control.LoadBalancingConfig {
+	if i.RouteAction == nil || i.RouteAction.Retry == nil {
+		return c
+	}
+	r := i.RouteAction.Retry
+	c.RetryEnabled = r.RetryOnSame > 0 || r.RetryOnNext > 0
+	c.RetryOnSame = r.RetryOnSame
+	c.RetryOnNext = r.RetryOnNext
+	policy := retry.NewPolicy(r.StatusCodes, r.Errors, "")
+	policy.Methods = c.RetryPolicy.Methods
+	c.RetryPolicy = policy
+	return c
+}
+
+//routeTimeout return context with timeout of route action, the cancel function must be called after call finishes
+func routeTimeout(i *invocation.Invocation) (context.Context, context.CancelFunc) {
+	if i.RouteAction == nil || i.RouteAction.Timeout <= 0 {
+		return i.Ctx, func() {}
+	}
+	parent := i.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	return context.WithTimeout(parent, i.RouteAction.Timeout)
+}
+
+//cancelOnClose cancels context of call after response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -21,7 +21,9 @@ func (ph *RouterHandler) Handle(chain *Chain, i *invocation.Invocation, cb invoc
 
 	tags := map[string]string{}
 	for k, v := range i.Metadata {
-		tags[k] = v.(string)
+		if s, ok := v.(string); ok {
+			tags[k] = s
+		}
 	}
 	tags[common.BuildinTagApp] = runtime.App
 
@@ -37,6 +39,19 @@ func (ph *RouterHandler) Handle(chain *Chain, i *invocation.Invocation, cb invoc
 	if err != nil {
 		WriteBackErr(err, status.Status(i.Protocol, status.ServiceUnavailable), cb)
 	}
+	if a := i.RouteAction; a != nil {
+		applyRequestActions(i, a)
+		if o := a.ResponseHeaders; o != nil {
+			next := cb
+			cb = func(r *invocation.Response) {
+				applyResponseActions(i, o)
+				next(r)
+			}
+		}
+	}
+	if m := router.TakeMirror(i); m != nil {
+		mirror(chain, i, m)
+	}
 
 	//call next chain
 	chain.Next(i, cb)
@@ -4,9 +4,14 @@ import (
 	"github.com/go-chassis/go-archaius"
 	_ "github.com/go-chassis/go-chassis/core/router/servicecomb"
 
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/handler"
 	"github.com/go-chassis/go-chassis/core/invocation"
 	"github.com/go-chassis/go-chassis/core/router"
@@ -77,3 +82,182 @@ func TestRouterHandler_Handle(t *testing.T) {
 
 	assert.Equal(t, "router", r.Name())
 }
+
+type recorder struct {
+	invs chan *invocation.Invocation
+}
+
+func (r *recorder) Name() string {
+	return "recorder"
+}
+
+func (r *recorder) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	r.invs <- i
+	cb(&invocation.Response{})
+}
+
+func TestRouterHandler_Mirror(t *testing.T) {
+	router.BuildRouter("cse")
+	router.DefaultRouter.SetRouteRule(map[string][]*config.RouteRule{
+		"service2": {
+			{
+				Routes: []*config.RouteTag{
+					{Weight: 100, Tags: map[string]string{"version": "1.0"}},
+				},
+				Mirror: &config.Mirror{Service: "service2-shadow", Tags: map[string]string{"version": "2.0"}, Percent: 100, Timeout: "100ms"},
+			},
+		},
+	})
+	rec := &recorder{invs: make(chan *invocation.Invocation, 2)}
+	c := handler.Chain{}
+	c.AddHandler(&handler.RouterHandler{})
+	c.AddHandler(rec)
+
+	req, err := http.NewRequest(http.MethodPost, "cse://service2/hello", strings.NewReader("body"))
+	assert.NoError(t, err)
+	i := &invocation.Invocation{
+		MicroServiceName: "service2",
+		Args:             req,
+		Reply:            &http.Response{},
+		Ctx:              common.NewContext(map[string]string{}),
+	}
+	i.SetMetadata(common.Stream, false)
+	c.Next(i, func(r *invocation.Response) {
+		assert.NoError(t, r.Err)
+	})
+
+	var main, shadow *invocation.Invocation
+	for n := 0; n < 2; n++ {
+		select {
+		case inv := <-rec.invs:
+			if inv.IsMirror() {
+				shadow = inv
+			} else {
+				main = inv
+			}
+		case <-time.After(time.Second):
+			t.Fatal("shadow request is not sent")
+		}
+	}
+	assert.True(t, main == i)
+	assert.Equal(t, "1.0", main.RouteTags.Version())
+	assert.Empty(t, main.Headers()[common.HeaderMirror])
+
+	assert.Equal(t, "service2-shadow", shadow.MicroServiceName)
+	assert.Equal(t, "2.0", shadow.RouteTags.Version())
+	assert.Equal(t, common.TRUE, shadow.Headers()[common.HeaderMirror])
+	shadowReq := shadow.Args.(*http.Request)
+	assert.False(t, shadowReq == req)
+	b, err := ioutil.ReadAll(shadowReq.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "body", string(b))
+	deadline, ok := shadow.Ctx.Deadline()
+	assert.True(t, ok, "shadow request has its own timeout")
+	assert.True(t, time.Until(deadline) <= 100*time.Millisecond)
+
+	t.Run("not mirrored if too many shadow requests are not finished", func(t *testing.T) {
+		archaius.Init(archaius.WithMemorySource())
+		archaius.Set("servicecomb.service.router.mirror.maxInflight", 0)
+		defer archaius.Delete("servicecomb.service.router.mirror.maxInflight")
+		req, err := http.NewRequest(http.MethodGet, "cse://service2/hello", nil)
+		assert.NoError(t, err)
+		i := &invocation.Invocation{MicroServiceName: "service2", Args: req, Reply: &http.Response{}}
+		i.SetMetadata(common.Stream, false)
+		c.Next(i, func(r *invocation.Response) {
+			assert.NoError(t, r.Err)
+		})
+		assert.True(t, <-rec.invs == i)
+		select {
+		case <-rec.invs:
+			t.Fatal("shadow request is sent")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+type responder struct {
+	header http.Header
+}
+
+func (r *responder) Name() string {
+	return "responder"
+}
+
+func (r *responder) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	resp := i.Reply.(*http.Response)
+	resp.Header = r.header
+	cb(&invocation.Response{Result: resp})
+}
+
+func TestRouterHandler_RouteAction(t *testing.T) {
+	router.BuildRouter("cse")
+	router.DefaultRouter.SetRouteRule(map[string][]*config.RouteRule{
+		"service3": {
+			{
+				Routes: []*config.RouteTag{
+					{Weight: 100, Tags: map[string]string{"version": "1.0"}},
+				},
+				Headers: &config.HeaderActions{
+					Request: &config.HeaderOperations{
+						Set:    map[string]string{"X-Canary": "true"},
+						Add:    map[string]string{"X-Trace": "b", "X-Tenant": "t1"},
+						Remove: []string{"X-Debug"},
+					},
+					Response: &config.HeaderOperations{
+						Set:    map[string]string{"X-Version": "1.0"},
+						Remove: []string{"Server"},
+					},
+				},
+				Rewrite: &config.Rewrite{StripPrefix: "/api", Prefix: "/v2"},
+				Timeout: "500ms",
+				Retries: &config.RouteRetry{RetryOnNext: 2, StatusCodes: "503"},
+			},
+		},
+	})
+	c := handler.Chain{}
+	c.AddHandler(&handler.RouterHandler{})
+	c.AddHandler(&responder{header: http.Header{"Server": {"x"}}})
+
+	req, err := http.NewRequest(http.MethodGet, "cse://service3/api/users?id=1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Debug", "1")
+	req.Header.Set("X-Tenant", "t0")
+	shared := map[string]string{"X-Trace": "a"}
+	i := &invocation.Invocation{
+		MicroServiceName: "service3",
+		Args:             req,
+		Reply:            &http.Response{},
+		Ctx:              common.NewContext(shared),
+	}
+	c.Next(i, func(r *invocation.Response) {
+		assert.NoError(t, r.Err)
+	})
+
+	assert.Equal(t, map[string]string{"X-Trace": "a"}, shared, "headers in parent context are not changed")
+	assert.Equal(t, "/v2/users", req.URL.Path)
+	assert.Equal(t, "id=1", req.URL.RawQuery)
+	assert.Equal(t, "true", i.Headers()["X-Canary"])
+	assert.Equal(t, "a,b", i.Headers()["X-Trace"])
+	assert.Empty(t, req.Header.Get("X-Debug"))
+	assert.Equal(t, []string{"t0", "t1"}, req.Header.Values("X-Tenant"))
+
+	resp := i.Reply.(*http.Response)
+	assert.Equal(t, "1.0", resp.Header.Get("X-Version"))
+	assert.Empty(t, resp.Header.Get("Server"))
+
+	assert.Equal(t, 500*time.Millisecond, i.RouteAction.Timeout)
+	assert.Equal(t, 2, i.RouteAction.Retry.RetryOnNext)
+	assert.Equal(t, "503", i.RouteAction.Retry.StatusCodes)
+
+	t.Run("prefix is stripped at segment boundary", func(t *testing.T) {
+		for path, expected := range map[string]string{"/apiv2/users": "/v2/apiv2/users", "/api": "/v2/"} {
+			req, err := http.NewRequest(http.MethodGet, "cse://service3"+path, nil)
+			assert.NoError(t, err)
+			i := &invocation.Invocation{MicroServiceName: "service3", Args: req, Reply: &http.Response{}}
+			c.Next(i, func(r *invocation.Response) {
+				assert.NoError(t, r.Err)
+			})
+			assert.Equal(t, expected, req.URL.Path)
+		}
+	})
+}
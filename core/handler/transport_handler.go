@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-chassis/go-chassis/core/client"
@@ -42,9 +43,34 @@ func (th *TransportHandler) Handle(chain *Chain, i *invocation.Invocation, cb in
 
 	//taking the time elapsed to check for latency aware strategy
 	timeBefore := time.Now()
-	err = c.Call(i.Ctx, i.Endpoint, i, i.Reply)
+	trackLoad := i.Strategy == loadbalancer.StrategyLeastRequest || i.Strategy == loadbalancer.StrategyP2C ||
+		i.Strategy == loadbalancer.StrategyConsistentHash
+	if trackLoad {
+		loadbalancer.IncreaseInflight(i.Endpoint)
+	}
+	ctx, cancel := routeTimeout(i)
+	err = c.Call(ctx, i.Endpoint, i, i.Reply)
+	if trackLoad {
+		loadbalancer.DecreaseInflight(i.Endpoint, time.Since(timeBefore))
+	}
+	if err != nil && ctx != nil && ctx.Err() == context.DeadlineExceeded && (i.Ctx == nil || i.Ctx.Err() == nil) {
+		err = fmt.Errorf("call exceeds route timeout %s: %w", i.RouteAction.Timeout, context.DeadlineExceeded)
+	}
 	if resp, ok := i.Reply.(*http.Response); ok {
 		r.Status = resp.StatusCode
+		//body is read after call, so context is canceled after body is closed
+		if resp.Body != nil {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		} else {
+			cancel()
+		}
+	} else {
+		cancel()
+	}
+	//shadow requests do not affect health of instances
+	if outlierDetectionEnabled(i) && !i.IsMirror() {
+		failed := (err != nil && err != client.ErrCanceled) || r.Status >= http.StatusInternalServerError
+		loadbalancer.ReportResult(i.MicroServiceName, i.Endpoint, failed)
 	}
 	if err != nil {
 		r.Err = err
@@ -72,6 +98,15 @@ func (th *TransportHandler) Handle(chain *Chain, i *invocation.Invocation, cb in
 	cb(r)
 }
 
+func outlierDetectionEnabled(i *invocation.Invocation) bool {
+	for _, f := range i.Filters {
+		if f == loadbalancer.OutlierDetection {
+			return true
+		}
+	}
+	return false
+}
+
 //ProcessSpecialProtocol handles special logic for protocol
 func ProcessSpecialProtocol(inv *invocation.Invocation) {
 	switch inv.Protocol {
@@ -43,6 +43,7 @@ type Invocation struct {
 	Ctx                context.Context        //ctx can save protocol headers
 	Metadata           map[string]interface{} //local scope data
 	RouteTags          utiltags.Tags          //route tags is decided in router handler
+	RouteAction        *RouteAction           //actions of matched route rule, it is decided in router handler
 	Strategy           string                 //load balancing strategy
 	Filters            []string
 }
@@ -78,6 +79,18 @@ func New(ctx context.Context) *Invocation {
 	return inv
 }
 
+//IsStream return whether request body of invocation is a stream
+func (inv *Invocation) IsStream() bool {
+	s, _ := inv.Metadata[common.Stream].(bool)
+	return s
+}
+
+//IsMirror return whether invocation is a copy sent by request mirroring
+func (inv *Invocation) IsMirror() bool {
+	m, _ := inv.Metadata[common.Mirror].(bool)
+	return m
+}
+
 //SetMetadata local scope params
 func (inv *Invocation) SetMetadata(key string, value interface{}) {
 	if inv.Metadata == nil {
@@ -0,0 +1,37 @@
+package invocation
+
+import "time"
+
+//RouteAction is actions of matched route rule, request actions are applied by router handler,
+//timeout and retry are applied by transport and load balance handler
+type RouteAction struct {
+	RequestHeaders  *HeaderOperations
+	ResponseHeaders *HeaderOperations
+	Rewrite         *Rewrite
+	//Timeout limits each call, response body must be read before timeout, 0 means no limit
+	Timeout time.Duration
+	//Retry overrides retry config of load balancing if it is not nil
+	Retry *RouteRetry
+}
+
+//HeaderOperations sets, adds and removes headers
+type HeaderOperations struct {
+	Set    map[string]string
+	Add    map[string]string
+	Remove []string
+}
+
+//Rewrite rewrites path of http request
+type Rewrite struct {
+	Path        string
+	StripPrefix string
+	Prefix      string
+}
+
+//RouteRetry is retry config of route, StatusCodes and Errors are comma separated
+type RouteRetry struct {
+	RetryOnSame int
+	RetryOnNext int
+	StatusCodes string
+	Errors      string
+}
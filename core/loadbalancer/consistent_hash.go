@@ -0,0 +1,182 @@
+package loadbalancer
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+//StrategyConsistentHash is name
+const StrategyConsistentHash = "ConsistentHash"
+
+func init() {
+	InstallStrategy(StrategyConsistentHash, newConsistentHashStrategy)
+}
+
+//instanceSlice identifies a slice, instance cache of registry returns the same slice until instances change
+type instanceSlice struct {
+	head **registry.MicroServiceInstance
+	size int
+}
+
+func sliceOf(instances []*registry.MicroServiceInstance) instanceSlice {
+	return instanceSlice{head: &instances[0], size: len(instances)}
+}
+
+type cachedTable struct {
+	slice     instanceSlice
+	signature signature
+	config    config.ConsistentHashConfig
+	table     hashTable
+}
+
+//hash tables are built only when instances or config changes, key is service key.
+//builds are serialized, so that concurrent picks do not build the same table
+var (
+	hashTables   = make(map[string]*cachedTable)
+	hashTablesMu sync.RWMutex
+	buildMu      sync.Mutex
+)
+
+//signature identifies instances regardless of their order, it needs no sorting
+type signature struct {
+	size     int
+	sum, xor uint64
+}
+
+func signatureOf(instances []*registry.MicroServiceInstance) signature {
+	s := signature{size: len(instances)}
+	for _, ins := range instances {
+		h := hash64(nodeName(ins))
+		s.sum += h
+		s.xor ^= h
+	}
+	return s
+}
+
+func lookupHashTable(serviceKey string) (*cachedTable, bool) {
+	hashTablesMu.RLock()
+	defer hashTablesMu.RUnlock()
+	t, ok := hashTables[serviceKey]
+	return t, ok
+}
+
+//getHashTable return cached table if the same slice is passed, signature is checked only if slice changes
+func getHashTable(serviceKey string, instances []*registry.MicroServiceInstance, c config.ConsistentHashConfig) hashTable {
+	slice := sliceOf(instances)
+	t, ok := lookupHashTable(serviceKey)
+	if ok && t.slice == slice && t.config == c {
+		return t.table
+	}
+	sig := signatureOf(instances)
+	if ok && t.signature == sig && t.config == c {
+		hashTablesMu.Lock()
+		hashTables[serviceKey] = &cachedTable{slice: slice, signature: sig, config: c, table: t.table}
+		hashTablesMu.Unlock()
+		return t.table
+	}
+	buildMu.Lock()
+	defer buildMu.Unlock()
+	//table may be built by another pick while waiting
+	if t, ok := lookupHashTable(serviceKey); ok && t.signature == sig && t.config == c {
+		return t.table
+	}
+	t = &cachedTable{slice: slice, signature: sig, config: c}
+	if c.Algorithm == HashMaglev {
+		t.table = newMaglev(instances, c.TableSize)
+	} else {
+		t.table = newHashRing(instances, c.Replicas)
+	}
+	hashTablesMu.Lock()
+	hashTables[serviceKey] = t
+	hashTablesMu.Unlock()
+	return t.table
+}
+
+//HashKey return the hash key of invocation, it is read from header, query or metadata,
+//empty string means there is no key
+func HashKey(inv *invocation.Invocation, c config.ConsistentHashConfig) string {
+	req, _ := inv.Args.(*http.Request)
+	if c.Header != "" {
+		if inv.Ctx != nil {
+			if h, ok := inv.Ctx.Value(common.ContextHeaderKey{}).(map[string]string); ok && h[c.Header] != "" {
+				return h[c.Header]
+			}
+		}
+		if req != nil && req.Header.Get(c.Header) != "" {
+			return req.Header.Get(c.Header)
+		}
+	}
+	if c.Query != "" && req != nil && req.URL != nil {
+		if v := req.URL.Query().Get(c.Query); v != "" {
+			return v
+		}
+	}
+	if c.Metadata != "" {
+		if v, ok := inv.Metadata[c.Metadata]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+//ConsistentHashStrategy picks instance by hash of key, so that same key always goes to same instance,
+//only keys of the changed instances are remapped when instances join or leave.
+//with bounded load, an instance whose in-flight calls exceed factor * average is skipped
+type ConsistentHashStrategy struct {
+	instances  []*registry.MicroServiceInstance
+	key        string
+	serviceKey string
+	protocol   string
+	config     config.ConsistentHashConfig
+}
+
+func newConsistentHashStrategy() Strategy {
+	return &ConsistentHashStrategy{}
+}
+
+//ReceiveData receive data
+func (r *ConsistentHashStrategy) ReceiveData(inv *invocation.Invocation, instances []*registry.MicroServiceInstance, serviceKey string) {
+	r.instances = instances
+	r.serviceKey = serviceKey
+	r.protocol = inv.Protocol
+	r.config = config.GetConsistentHash(inv.MicroServiceName)
+	r.key = HashKey(inv, r.config)
+}
+
+//Pick return instance, it picks randomly if there is no hash key
+func (r *ConsistentHashStrategy) Pick() (*registry.MicroServiceInstance, error) {
+	if len(r.instances) == 0 {
+		return nil, ErrNoneAvailableInstance
+	}
+	if r.key == "" {
+		return r.instances[randIntn(len(r.instances))], nil
+	}
+	capacity := int64(math.MaxInt64)
+	if r.config.BoundedLoadFactor > 0 {
+		var total int64
+		for _, ins := range r.instances {
+			total += GetInflight(loadAddr(ins, r.protocol))
+		}
+		avg := float64(total+1) / float64(len(r.instances))
+		capacity = int64(math.Ceil(avg * r.config.BoundedLoadFactor))
+	}
+	var picked *registry.MicroServiceInstance
+	getHashTable(r.serviceKey, r.instances, r.config).Lookup(hash64(r.key), func(ins *registry.MicroServiceInstance) bool {
+		if GetInflight(loadAddr(ins, r.protocol)) < capacity {
+			picked = ins
+			return false
+		}
+		return true
+	})
+	if picked == nil {
+		return r.instances[randIntn(len(r.instances))], nil
+	}
+	return picked, nil
+}
@@ -0,0 +1,90 @@
+package loadbalancer_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func pickByKey(t *testing.T, instances []*registry.MicroServiceInstance, key string) string {
+	inv := invocation.New(context.Background())
+	inv.MicroServiceName = "HashServer"
+	inv.SetHeader("user", key)
+	s := &loadbalancer.ConsistentHashStrategy{}
+	s.ReceiveData(inv, instances, "HashServer")
+	ins, err := s.Pick()
+	assert.NoError(t, err)
+	return ins.InstanceID
+}
+
+func TestConsistentHashStrategy_Pick(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.loadbalance.HashServer.consistentHash.header", "user")
+	instances := newLoadInstances("10.0.3.1:8080", "10.0.3.2:8080", "10.0.3.3:8080", "10.0.3.4:8080")
+
+	for _, algorithm := range []string{loadbalancer.HashRing, loadbalancer.HashMaglev} {
+		t.Run(algorithm, func(t *testing.T) {
+			archaius.Set("cse.loadbalance.HashServer.consistentHash.algorithm", algorithm)
+			picked := make(map[string]string)
+			for n := 0; n < 200; n++ {
+				key := fmt.Sprintf("user-%d", n)
+				picked[key] = pickByKey(t, instances, key)
+				assert.Equal(t, picked[key], pickByKey(t, instances, key))
+			}
+
+			//only keys of the removed instance are remapped
+			for key, id := range picked {
+				now := pickByKey(t, instances[1:], key)
+				if id != "10.0.3.1:8080" {
+					assert.Equal(t, id, now)
+				}
+			}
+
+			//order of instances does not matter
+			reversed := []*registry.MicroServiceInstance{instances[3], instances[2], instances[1], instances[0]}
+			for key, id := range picked {
+				assert.Equal(t, id, pickByKey(t, reversed, key))
+			}
+		})
+	}
+}
+
+func TestConsistentHashStrategy_BoundedLoad(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.loadbalance.HashServer.consistentHash.header", "user")
+	archaius.Set("cse.loadbalance.HashServer.consistentHash.boundedLoadFactor", 1.25)
+	instances := newLoadInstances("10.0.4.1:8080", "10.0.4.2:8080")
+
+	owner := pickByKey(t, instances, "hot")
+	for n := 0; n < 10; n++ {
+		loadbalancer.IncreaseInflight(owner)
+		defer loadbalancer.DecreaseInflight(owner, time.Millisecond)
+	}
+	assert.NotEqual(t, owner, pickByKey(t, instances, "hot"))
+}
+
+func TestHashKey(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	req, _ := http.NewRequest(http.MethodGet, "http://HashServer/api?user=query", nil)
+	inv := &invocation.Invocation{
+		Args:     req,
+		Ctx:      context.WithValue(context.Background(), common.ContextHeaderKey{}, map[string]string{}),
+		Metadata: map[string]interface{}{"user": 1},
+	}
+	c := config.ConsistentHashConfig{Header: "user", Query: "user", Metadata: "user"}
+	assert.Equal(t, "query", loadbalancer.HashKey(inv, c))
+	req.Header.Set("user", "header")
+	assert.Equal(t, "header", loadbalancer.HashKey(inv, c))
+	assert.Equal(t, "1", loadbalancer.HashKey(inv, config.ConsistentHashConfig{Metadata: "user"}))
+	assert.Equal(t, "", loadbalancer.HashKey(inv, config.ConsistentHashConfig{}))
+}
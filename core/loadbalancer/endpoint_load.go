@@ -0,0 +1,95 @@
+package loadbalancer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+//ewmaDecay is the weight of the latest latency in ewma latency
+const ewmaDecay = 0.3
+
+//endpointLoad is the load of an endpoint, it is shared by all consumers of this process
+type endpointLoad struct {
+	inflight int64
+	mu       sync.Mutex
+	//ewma is the exponentially weighted moving average latency in milliseconds
+	ewma float64
+}
+
+var (
+	endpointLoads   = make(map[string]*endpointLoad)
+	endpointLoadsMu sync.RWMutex
+)
+
+func getEndpointLoad(addr string) *endpointLoad {
+	endpointLoadsMu.RLock()
+	l, ok := endpointLoads[addr]
+	endpointLoadsMu.RUnlock()
+	if ok {
+		return l
+	}
+	endpointLoadsMu.Lock()
+	defer endpointLoadsMu.Unlock()
+	if l, ok = endpointLoads[addr]; !ok {
+		l = &endpointLoad{}
+		endpointLoads[addr] = l
+	}
+	return l
+}
+
+//IncreaseInflight records a call to endpoint is started
+func IncreaseInflight(addr string) {
+	atomic.AddInt64(&getEndpointLoad(addr).inflight, 1)
+}
+
+//DecreaseInflight records a call to endpoint is finished, and takes its latency into ewma latency
+func DecreaseInflight(addr string, latency time.Duration) {
+	l := getEndpointLoad(addr)
+	atomic.AddInt64(&l.inflight, -1)
+	ms := float64(latency) / float64(time.Millisecond)
+	l.mu.Lock()
+	if l.ewma == 0 {
+		l.ewma = ms
+	} else {
+		l.ewma = ewmaDecay*ms + (1-ewmaDecay)*l.ewma
+	}
+	l.mu.Unlock()
+}
+
+//GetInflight return in-flight calls of endpoint
+func GetInflight(addr string) int64 {
+	return atomic.LoadInt64(&getEndpointLoad(addr).inflight)
+}
+
+//GetEWMALatency return ewma latency of endpoint
+func GetEWMALatency(addr string) time.Duration {
+	l := getEndpointLoad(addr)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Duration(l.ewma * float64(time.Millisecond))
+}
+
+//cost is the score of endpoint, lower is better. endpoint without latency is preferred so that it can be measured
+func (l *endpointLoad) cost() float64 {
+	l.mu.Lock()
+	ewma := l.ewma
+	l.mu.Unlock()
+	return float64(atomic.LoadInt64(&l.inflight)+1) * (ewma + 1)
+}
+
+//loadAddr return address of the endpoint which will be called, it chooses endpoint as load balance handler does
+func loadAddr(ins *registry.MicroServiceInstance, protocol string) string {
+	if ep, ok := ins.EndpointsMap[protocol]; ok {
+		return ep.Address
+	}
+	if ep, ok := ins.EndpointsMap[ins.DefaultProtocol]; ok {
+		return ep.Address
+	}
+	for _, ep := range ins.EndpointsMap {
+		return ep.Address
+	}
+	return ""
+}
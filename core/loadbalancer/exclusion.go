@@ -0,0 +1,43 @@
+package loadbalancer
+
+import (
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/invocation"
+)
+
+//MetaExclusion is the invocation metadata key of Exclusion
+const MetaExclusion = "lb-exclusion"
+
+//Exclusion records endpoints picked by invocations which share it,
+//load balance handler tries to pick other endpoints, for example hedged requests go to different instances
+type Exclusion struct {
+	mu        sync.RWMutex
+	endpoints map[string]struct{}
+}
+
+//NewExclusion create a exclusion
+func NewExclusion() *Exclusion {
+	return &Exclusion{endpoints: make(map[string]struct{})}
+}
+
+//Add records an endpoint
+func (e *Exclusion) Add(endpoint string) {
+	e.mu.Lock()
+	e.endpoints[endpoint] = struct{}{}
+	e.mu.Unlock()
+}
+
+//Has return whether endpoint is excluded
+func (e *Exclusion) Has(endpoint string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.endpoints[endpoint]
+	return ok
+}
+
+//GetExclusion return exclusion of invocation, nil if there is not
+func GetExclusion(inv *invocation.Invocation) *Exclusion {
+	e, _ := inv.Metadata[MetaExclusion].(*Exclusion)
+	return e
+}
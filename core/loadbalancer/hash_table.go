@@ -0,0 +1,154 @@
+package loadbalancer
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+// consistent hash algorithms
+const (
+	HashRing   = "ring"
+	HashMaglev = "maglev"
+)
+
+//hashTable maps a hash to instances, Lookup returns instances in preference order,
+//the first one is the owner of hash, the others are candidates when owner is overloaded
+type hashTable interface {
+	Lookup(h uint64, each func(ins *registry.MicroServiceInstance) bool)
+}
+
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	//fnv spreads similar strings poorly, mix it with splitmix64 finalizer
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+//nodeName identifies an instance in hash table, it must not change when other instances join or leave
+func nodeName(ins *registry.MicroServiceInstance) string {
+	if ins.InstanceID != "" {
+		return ins.InstanceID
+	}
+	return loadAddr(ins, "")
+}
+
+type ringNode struct {
+	hash uint64
+	ins  *registry.MicroServiceInstance
+}
+
+//hashRing is ketama like ring, each instance has replicas virtual nodes
+type hashRing struct {
+	nodes []ringNode
+}
+
+func newHashRing(instances []*registry.MicroServiceInstance, replicas int) *hashRing {
+	r := &hashRing{nodes: make([]ringNode, 0, len(instances)*replicas)}
+	for _, ins := range instances {
+		name := nodeName(ins)
+		for i := 0; i < replicas; i++ {
+			r.nodes = append(r.nodes, ringNode{hash: hash64(name + "#" + strconv.Itoa(i)), ins: ins})
+		}
+	}
+	sort.Slice(r.nodes, func(i, j int) bool { return r.nodes[i].hash < r.nodes[j].hash })
+	return r
+}
+
+//Lookup walks clockwise from hash
+func (r *hashRing) Lookup(h uint64, each func(ins *registry.MicroServiceInstance) bool) {
+	if len(r.nodes) == 0 {
+		return
+	}
+	start := sort.Search(len(r.nodes), func(i int) bool { return r.nodes[i].hash >= h })
+	for n := 0; n < len(r.nodes); n++ {
+		if !each(r.nodes[(start+n)%len(r.nodes)].ins) {
+			return
+		}
+	}
+}
+
+//maglev is the lookup table described in Maglev paper, size must be a prime larger than instance number
+type maglev struct {
+	table     []int
+	instances []*registry.MicroServiceInstance
+}
+
+func newMaglev(instances []*registry.MicroServiceInstance, size int) *maglev {
+	//every permutation covers the whole table only if size is a prime
+	size = nextPrime(size)
+	if size <= len(instances) {
+		size = nextPrime(len(instances) * 100)
+	}
+	m := &maglev{table: make([]int, size), instances: instances}
+	if len(instances) == 0 {
+		return m
+	}
+	offsets := make([]uint64, len(instances))
+	skips := make([]uint64, len(instances))
+	next := make([]uint64, len(instances))
+	for i, ins := range instances {
+		name := nodeName(ins)
+		offsets[i] = hash64(name+"#offset") % uint64(size)
+		skips[i] = hash64(name+"#skip")%uint64(size-1) + 1
+	}
+	for i := range m.table {
+		m.table[i] = -1
+	}
+	filled := 0
+	for filled < size {
+		for i := range instances {
+			c := (offsets[i] + next[i]*skips[i]) % uint64(size)
+			for m.table[c] >= 0 {
+				next[i]++
+				c = (offsets[i] + next[i]*skips[i]) % uint64(size)
+			}
+			m.table[c] = i
+			next[i]++
+			filled++
+			if filled == size {
+				break
+			}
+		}
+	}
+	return m
+}
+
+func nextPrime(n int) int {
+	if n < 2 {
+		return 2
+	}
+	for ; ; n++ {
+		prime := true
+		for d := 2; d*d <= n; d++ {
+			if n%d == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			return n
+		}
+	}
+}
+
+//Lookup walks the table from hash
+func (m *maglev) Lookup(h uint64, each func(ins *registry.MicroServiceInstance) bool) {
+	if len(m.instances) == 0 {
+		return
+	}
+	start := h % uint64(len(m.table))
+	for n := uint64(0); n < uint64(len(m.table)); n++ {
+		if !each(m.instances[m.table[(start+n)%uint64(len(m.table))]]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package loadbalancer
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+// strategy names based on in-flight calls
+const (
+	StrategyLeastRequest = "LeastRequest"
+	StrategyP2C          = "P2C"
+)
+
+var randMu sync.Mutex
+
+func init() {
+	InstallStrategy(StrategyLeastRequest, newLeastRequestStrategy)
+	InstallStrategy(StrategyP2C, newP2CStrategy)
+}
+
+func randIntn(n int) int {
+	randMu.Lock()
+	defer randMu.Unlock()
+	return rand.Intn(n)
+}
+
+//LeastRequestStrategy picks the instance with least in-flight calls
+type LeastRequestStrategy struct {
+	instances []*registry.MicroServiceInstance
+	protocol  string
+}
+
+func newLeastRequestStrategy() Strategy {
+	return &LeastRequestStrategy{}
+}
+
+//ReceiveData receive data
+func (r *LeastRequestStrategy) ReceiveData(inv *invocation.Invocation, instances []*registry.MicroServiceInstance, serviceKey string) {
+	r.instances = instances
+	r.protocol = inv.Protocol
+}
+
+//Pick return instance, it starts from a random instance, so that instances with same load are picked evenly
+func (r *LeastRequestStrategy) Pick() (*registry.MicroServiceInstance, error) {
+	if len(r.instances) == 0 {
+		return nil, ErrNoneAvailableInstance
+	}
+	start := randIntn(len(r.instances))
+	var picked *registry.MicroServiceInstance
+	var least int64
+	for n := 0; n < len(r.instances); n++ {
+		ins := r.instances[(start+n)%len(r.instances)]
+		inflight := GetInflight(loadAddr(ins, r.protocol))
+		if picked == nil || inflight < least {
+			picked, least = ins, inflight
+		}
+	}
+	return picked, nil
+}
+
+//P2CStrategy picks two instances randomly, and return the one with lower cost,
+//cost is calculated by in-flight calls and ewma latency
+type P2CStrategy struct {
+	instances []*registry.MicroServiceInstance
+	protocol  string
+}
+
+func newP2CStrategy() Strategy {
+	return &P2CStrategy{}
+}
+
+//ReceiveData receive data
+func (r *P2CStrategy) ReceiveData(inv *invocation.Invocation, instances []*registry.MicroServiceInstance, serviceKey string) {
+	r.instances = instances
+	r.protocol = inv.Protocol
+}
+
+//Pick return instance
+func (r *P2CStrategy) Pick() (*registry.MicroServiceInstance, error) {
+	switch len(r.instances) {
+	case 0:
+		return nil, ErrNoneAvailableInstance
+	case 1:
+		return r.instances[0], nil
+	}
+	a := randIntn(len(r.instances))
+	b := randIntn(len(r.instances) - 1)
+	if b >= a {
+		b++
+	}
+	insA, insB := r.instances[a], r.instances[b]
+	if getEndpointLoad(loadAddr(insB, r.protocol)).cost() < getEndpointLoad(loadAddr(insA, r.protocol)).cost() {
+		return insB, nil
+	}
+	return insA, nil
+}
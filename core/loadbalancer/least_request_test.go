@@ -0,0 +1,69 @@
+package loadbalancer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoadInstances(addrs ...string) []*registry.MicroServiceInstance {
+	instances := make([]*registry.MicroServiceInstance, 0, len(addrs))
+	for _, addr := range addrs {
+		instances = append(instances, &registry.MicroServiceInstance{
+			InstanceID:   addr,
+			EndpointsMap: map[string]*registry.Endpoint{"rest": {Address: addr}},
+		})
+	}
+	return instances
+}
+
+func TestLeastRequestStrategy_Pick(t *testing.T) {
+	instances := newLoadInstances("10.0.1.1:8080", "10.0.1.2:8080", "10.0.1.3:8080")
+	loadbalancer.IncreaseInflight("10.0.1.1:8080")
+	loadbalancer.IncreaseInflight("10.0.1.3:8080")
+	defer loadbalancer.DecreaseInflight("10.0.1.1:8080", time.Millisecond)
+	defer loadbalancer.DecreaseInflight("10.0.1.3:8080", time.Millisecond)
+
+	f, err := loadbalancer.GetStrategyPlugin(loadbalancer.StrategyLeastRequest)
+	assert.NoError(t, err)
+	s := f()
+	s.ReceiveData(&invocation.Invocation{Protocol: "rest"}, instances, "Server")
+	for n := 0; n < 10; n++ {
+		ins, err := s.Pick()
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.1.2:8080", ins.InstanceID)
+	}
+
+	s.ReceiveData(&invocation.Invocation{}, nil, "Server")
+	_, err = s.Pick()
+	assert.Equal(t, loadbalancer.ErrNoneAvailableInstance, err)
+}
+
+func TestP2CStrategy_Pick(t *testing.T) {
+	instances := newLoadInstances("10.0.2.1:8080", "10.0.2.2:8080")
+	loadbalancer.IncreaseInflight("10.0.2.1:8080")
+	loadbalancer.DecreaseInflight("10.0.2.1:8080", 500*time.Millisecond)
+	loadbalancer.IncreaseInflight("10.0.2.2:8080")
+	loadbalancer.DecreaseInflight("10.0.2.2:8080", time.Millisecond)
+	assert.Equal(t, 500*time.Millisecond, loadbalancer.GetEWMALatency("10.0.2.1:8080"))
+	assert.Equal(t, int64(0), loadbalancer.GetInflight("10.0.2.1:8080"))
+
+	f, err := loadbalancer.GetStrategyPlugin(loadbalancer.StrategyP2C)
+	assert.NoError(t, err)
+	s := f()
+	s.ReceiveData(&invocation.Invocation{}, instances, "Server")
+	for n := 0; n < 10; n++ {
+		ins, err := s.Pick()
+		assert.NoError(t, err)
+		assert.Equal(t, "10.0.2.2:8080", ins.InstanceID)
+	}
+
+	s.ReceiveData(&invocation.Invocation{}, instances[:1], "Server")
+	ins, err := s.Pick()
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.2.1:8080", ins.InstanceID)
+}
@@ -31,6 +31,9 @@ const (
 	OperatorGreater = ">"
 	OperatorSmaller = "<"
 	OperatorPattern = "Pattern"
+
+	//CriteriaService is the key of criteria whose value is the name of target service
+	CriteriaService = "service"
 )
 
 var (
@@ -70,7 +73,6 @@ func BuildStrategy(i *invocation.Invocation,
 		openlogging.GetLogger().Errorf("Lb err: %s", err)
 		return nil, lbErr
 	}
-
 	if isFilterExist {
 		filterFuncs := make([]Filter, 0)
 		//append filters in config
@@ -81,10 +83,14 @@ func BuildStrategy(i *invocation.Invocation,
 				continue
 			}
 		}
+		criteria := []*Criteria{{Key: CriteriaService, Operator: OperatorEqual, Value: i.MicroServiceName}}
 		for _, filter := range filterFuncs {
-			instances = filter(instances, nil)
+			instances = filter(instances, criteria)
 		}
 	}
+	//instances marked down by active health check are never picked,
+	//they are removed after filters, so that filters know how many instances are down
+	instances = registry.UpInstances(instances)
 
 	if len(instances) == 0 {
 		lbErr := LBError{fmt.Sprintf("No available instance, key: %s(%v)", i.MicroServiceName, i.RouteTags)}
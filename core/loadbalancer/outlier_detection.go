@@ -0,0 +1,127 @@
+package loadbalancer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-mesh/openlogging"
+)
+
+//OutlierDetection is the filter name, endpoint which fails continuously is ejected for a while
+const OutlierDetection = "outlierDetection"
+
+func init() {
+	InstallFilter(OutlierDetection, FilterOutlier)
+}
+
+type outlierState struct {
+	service string
+	errors  int
+	//ejections is how many times endpoint has been ejected, ejection time grows with it
+	ejections    int
+	ejectedUntil time.Time
+}
+
+var (
+	outliers   = make(map[string]*outlierState)
+	outliersMu sync.RWMutex
+)
+
+//ReportResult records result of a call to endpoint, consecutive errors eject the endpoint,
+//ejection time is base ejection time multiplied by ejection times and capped by max ejection time
+func ReportResult(service, endpoint string, failed bool) {
+	outliersMu.Lock()
+	defer outliersMu.Unlock()
+	s, ok := outliers[endpoint]
+	if !ok {
+		if !failed {
+			return
+		}
+		s = &outlierState{}
+		outliers[endpoint] = s
+	}
+	s.service = service
+	if !failed {
+		s.errors = 0
+		return
+	}
+	s.errors++
+	now := time.Now()
+	if s.errors < config.OutlierConsecutiveErrors(service) || now.Before(s.ejectedUntil) {
+		return
+	}
+	max := config.OutlierMaxEjectionTime(service)
+	//endpoint kept healthy for a long time, eject it as the first time
+	if now.Sub(s.ejectedUntil) > max {
+		s.ejections = 0
+	}
+	s.ejections++
+	d := config.OutlierBaseEjectionTime(service) * time.Duration(s.ejections)
+	if d > max {
+		d = max
+	}
+	s.ejectedUntil = now.Add(d)
+	s.errors = 0
+	openlogging.GetLogger().Warnf("eject [%s] of [%s] for %s", endpoint, service, d)
+}
+
+//IsEjected return whether endpoint is ejected
+func IsEjected(endpoint string) bool {
+	outliersMu.RLock()
+	defer outliersMu.RUnlock()
+	s, ok := outliers[endpoint]
+	return ok && time.Now().Before(s.ejectedUntil)
+}
+
+//ResetOutliers clears all records of outlier detection
+func ResetOutliers() {
+	outliersMu.Lock()
+	outliers = make(map[string]*outlierState)
+	outliersMu.Unlock()
+}
+
+//FilterOutlier removes ejected instances, ejected instances never exceed max ejection percent,
+//so that there are always instances to be picked, but one instance can be ejected if there are more than one
+func FilterOutlier(instances []*registry.MicroServiceInstance, c []*Criteria) []*registry.MicroServiceInstance {
+	if len(instances) == 0 {
+		return instances
+	}
+	now := time.Now()
+	outliersMu.RLock()
+	defer outliersMu.RUnlock()
+	if len(outliers) == 0 {
+		return instances
+	}
+	result := make([]*registry.MicroServiceInstance, 0, len(instances))
+	ejected := 0
+	for _, ins := range instances {
+		s := ejectedState(ins, now)
+		if s == nil || ejected >= maxEjection(s.service, len(instances)) {
+			result = append(result, ins)
+			continue
+		}
+		ejected++
+	}
+	return result
+}
+
+func maxEjection(service string, total int) int {
+	percent := config.OutlierMaxEjectionPercent(service)
+	max := percent * total / 100
+	if max == 0 && percent > 0 && total > 1 {
+		return 1
+	}
+	return max
+}
+
+//ejectedState return state of the ejected endpoint of instance, nil if no endpoint is ejected
+func ejectedState(ins *registry.MicroServiceInstance, now time.Time) *outlierState {
+	for _, ep := range ins.EndpointsMap {
+		if s, ok := outliers[ep.Address]; ok && now.Before(s.ejectedUntil) {
+			return s
+		}
+	}
+	return nil
+}
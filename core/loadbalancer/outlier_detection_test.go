@@ -0,0 +1,53 @@
+package loadbalancer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterOutlier(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.loadbalance.OutlierServer.outlierDetection.consecutiveErrors", 2)
+	archaius.Set("cse.loadbalance.OutlierServer.outlierDetection.baseEjectionTime", "100ms")
+	archaius.Set("cse.loadbalance.OutlierServer.outlierDetection.maxEjectionPercent", 50)
+	defer loadbalancer.ResetOutliers()
+
+	instances := make([]*registry.MicroServiceInstance, 0)
+	for _, addr := range []string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3"} {
+		instances = append(instances, &registry.MicroServiceInstance{
+			EndpointsMap: map[string]*registry.Endpoint{"rest": {Address: addr}},
+		})
+	}
+	assert.Equal(t, instances, loadbalancer.FilterOutlier(instances, nil))
+
+	t.Run("consecutive errors eject endpoint", func(t *testing.T) {
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:1", true)
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:1", false)
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:1", true)
+		assert.False(t, loadbalancer.IsEjected("127.0.0.1:1"))
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:1", true)
+		assert.True(t, loadbalancer.IsEjected("127.0.0.1:1"))
+		assert.Equal(t, instances[1:], loadbalancer.FilterOutlier(instances, nil))
+	})
+	t.Run("max ejection percent", func(t *testing.T) {
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:2", true)
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:2", true)
+		assert.True(t, loadbalancer.IsEjected("127.0.0.1:2"))
+		assert.Equal(t, 2, len(loadbalancer.FilterOutlier(instances, nil)))
+	})
+	t.Run("ejection time grows", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+		assert.False(t, loadbalancer.IsEjected("127.0.0.1:1"))
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:1", true)
+		loadbalancer.ReportResult("OutlierServer", "127.0.0.1:1", true)
+		time.Sleep(150 * time.Millisecond)
+		assert.True(t, loadbalancer.IsEjected("127.0.0.1:1"))
+		time.Sleep(100 * time.Millisecond)
+		assert.False(t, loadbalancer.IsEjected("127.0.0.1:1"))
+	})
+}
@@ -1,6 +1,8 @@
 package loadbalancer
 
 import (
+	"math"
+	"sort"
 	"time"
 )
 
@@ -31,3 +33,26 @@ func (ps *ProtocolStats) SaveLatency(l time.Duration) {
 	}
 	ps.Latency = append(ps.Latency, l)
 }
+
+// LatencyPercentile return latency percentile of recent calls to all endpoints of a service,
+// false is returned if there is no latency record
+func LatencyPercentile(microServiceName, tags, protocol string, percentile float64) (time.Duration, bool) {
+	LatencyMapRWMutex.RLock()
+	latencies := make([]time.Duration, 0)
+	for _, stats := range ProtocolStatsMap[BuildKey(microServiceName, tags, protocol)] {
+		latencies = append(latencies, stats.Latency...)
+	}
+	LatencyMapRWMutex.RUnlock()
+	if len(latencies) == 0 {
+		return 0, false
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	i := int(math.Ceil(percentile/100*float64(len(latencies)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(latencies) {
+		i = len(latencies) - 1
+	}
+	return latencies[i], true
+}
@@ -0,0 +1,170 @@
+package loadbalancer
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+//StrategyWeightedRoundRobin is name
+const StrategyWeightedRoundRobin = "WeightedRoundRobin"
+
+// weight of instance
+const (
+	DefaultWeight = 100
+	//minWarmupPercent keeps new instance receiving a few requests at the beginning of slow start
+	minWarmupPercent = 10
+)
+
+//wrrNodeTTL is how long state of an instance is kept after it is absent from picks,
+//instances may be absent for a while because of filters and exclusion, they are not new when they come back
+const wrrNodeTTL = 10 * time.Minute
+
+func init() {
+	InstallStrategy(StrategyWeightedRoundRobin, newWeightedRoundRobinStrategy)
+}
+
+type wrrNode struct {
+	current   int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+//wrrState is the smooth weighted round robin state of a service key, key of nodes is instance id
+type wrrState struct {
+	mu    sync.Mutex
+	nodes map[string]*wrrNode
+	//instances of the first pick were running before this process, they need no warmup
+	started  bool
+	prunedAt time.Time
+}
+
+var (
+	wrrStates   = make(map[string]*wrrState)
+	wrrStatesMu sync.Mutex
+)
+
+func getWRRState(key string) *wrrState {
+	wrrStatesMu.Lock()
+	defer wrrStatesMu.Unlock()
+	s, ok := wrrStates[key]
+	if !ok {
+		s = &wrrState{nodes: make(map[string]*wrrNode)}
+		wrrStates[key] = s
+	}
+	return s
+}
+
+//prune forgets instances which are absent longer than ttl, they are new instances if they come back
+func (s *wrrState) prune(now time.Time) {
+	if now.Sub(s.prunedAt) < wrrNodeTTL {
+		return
+	}
+	s.prunedAt = now
+	for id, n := range s.nodes {
+		if now.Sub(n.lastSeen) >= wrrNodeTTL {
+			delete(s.nodes, id)
+		}
+	}
+}
+
+//InstanceWeight return weight in metadata of instance, default weight is returned if it is missing or invalid
+func InstanceWeight(ins *registry.MicroServiceInstance, key string) int {
+	v, ok := ins.Metadata[key]
+	if !ok {
+		return DefaultWeight
+	}
+	w, err := strconv.Atoi(v)
+	if err != nil || w < 0 {
+		return DefaultWeight
+	}
+	return w
+}
+
+//WeightedRoundRobinStrategy picks instances in proportion to their weights in metadata,
+//it is smooth, instances with high weight are not picked continuously.
+//weights are read for each pick, so weights updated in registry take effect at once
+type WeightedRoundRobinStrategy struct {
+	instances  []*registry.MicroServiceInstance
+	serviceKey string
+	weightKey  string
+	slowStart  time.Duration
+}
+
+func newWeightedRoundRobinStrategy() Strategy {
+	return &WeightedRoundRobinStrategy{}
+}
+
+//ReceiveData receive data
+func (r *WeightedRoundRobinStrategy) ReceiveData(inv *invocation.Invocation, instances []*registry.MicroServiceInstance, serviceKey string) {
+	r.instances = instances
+	r.serviceKey = serviceKey
+	r.weightKey = config.WeightKey(inv.MicroServiceName)
+	r.slowStart = config.SlowStartWindow(inv.MicroServiceName)
+}
+
+//effectiveWeight grows linearly from min warmup percent to full weight in slow start window,
+//instance is new when it joins after the first pick of this process
+func (r *WeightedRoundRobinStrategy) effectiveWeight(weight int, n *wrrNode, now time.Time) int {
+	if r.slowStart <= 0 || weight == 0 {
+		return weight
+	}
+	age := now.Sub(n.firstSeen)
+	if age >= r.slowStart {
+		return weight
+	}
+	percent := int(age * 100 / r.slowStart)
+	if percent < minWarmupPercent {
+		percent = minWarmupPercent
+	}
+	if w := weight * percent / 100; w > 0 {
+		return w
+	}
+	return 1
+}
+
+//Pick return instance, instances with weight 0 are picked only if all weights are 0
+func (r *WeightedRoundRobinStrategy) Pick() (*registry.MicroServiceInstance, error) {
+	if len(r.instances) == 0 {
+		return nil, ErrNoneAvailableInstance
+	}
+	s := getWRRState(r.serviceKey)
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var picked *registry.MicroServiceInstance
+	var pickedNode *wrrNode
+	total := 0
+	for _, ins := range r.instances {
+		id := nodeName(ins)
+		n, ok := s.nodes[id]
+		if !ok {
+			n = &wrrNode{}
+			if s.started {
+				n.firstSeen = now
+			}
+			s.nodes[id] = n
+		}
+		n.lastSeen = now
+		w := r.effectiveWeight(InstanceWeight(ins, r.weightKey), n, now)
+		if w == 0 {
+			continue
+		}
+		n.current += w
+		total += w
+		if pickedNode == nil || n.current > pickedNode.current {
+			picked, pickedNode = ins, n
+		}
+	}
+	s.prune(now)
+	s.started = true
+	if picked == nil {
+		return r.instances[randIntn(len(r.instances))], nil
+	}
+	pickedNode.current -= total
+	return picked, nil
+}
@@ -0,0 +1,80 @@
+package loadbalancer_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/loadbalancer"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/stretchr/testify/assert"
+)
+
+func countPicks(t *testing.T, instances []*registry.MicroServiceInstance, key string, n int) map[string]int {
+	s := &loadbalancer.WeightedRoundRobinStrategy{}
+	s.ReceiveData(&invocation.Invocation{MicroServiceName: "WeightServer"}, instances, key)
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		ins, err := s.Pick()
+		assert.NoError(t, err)
+		counts[ins.InstanceID]++
+	}
+	return counts
+}
+
+func TestWeightedRoundRobinStrategy_Pick(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	instances := newLoadInstances("10.0.5.1:8080", "10.0.5.2:8080", "10.0.5.3:8080")
+	instances[0].Metadata = map[string]string{"weight": "30"}
+	instances[1].Metadata = map[string]string{"weight": "10"}
+	instances[2].Metadata = map[string]string{"weight": "0"}
+
+	counts := countPicks(t, instances, "WeightServer|a", 40)
+	assert.Equal(t, 30, counts["10.0.5.1:8080"])
+	assert.Equal(t, 10, counts["10.0.5.2:8080"])
+	assert.Equal(t, 0, counts["10.0.5.3:8080"])
+
+	t.Run("weight is updated", func(t *testing.T) {
+		updated := append([]*registry.MicroServiceInstance{}, instances...)
+		updated[2] = &registry.MicroServiceInstance{
+			InstanceID:   "10.0.5.3:8080",
+			EndpointsMap: instances[2].EndpointsMap,
+			Metadata:     map[string]string{"weight": "40"},
+		}
+		counts := countPicks(t, updated, "WeightServer|a", 80)
+		assert.Equal(t, 30, counts["10.0.5.1:8080"])
+		assert.Equal(t, 10, counts["10.0.5.2:8080"])
+		assert.Equal(t, 40, counts["10.0.5.3:8080"])
+	})
+	t.Run("all weights are 0", func(t *testing.T) {
+		counts := countPicks(t, instances[2:], "WeightServer|b", 3)
+		assert.Equal(t, 3, counts["10.0.5.3:8080"])
+	})
+}
+
+func TestWeightedRoundRobinStrategy_SlowStart(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.loadbalance.WeightServer.weight.slowStartWindow", "1h")
+	defer archaius.Delete("cse.loadbalance.WeightServer.weight.slowStartWindow")
+	instances := newLoadInstances("10.0.6.1:8080", "10.0.6.2:8080")
+
+	//instances of the first pick need no warmup
+	countPicks(t, instances[:1], "WeightServer|c", 1)
+	time.Sleep(10 * time.Millisecond)
+
+	counts := countPicks(t, instances, "WeightServer|c", 110)
+	assert.Equal(t, 100, counts["10.0.6.1:8080"])
+	assert.Equal(t, 10, counts["10.0.6.2:8080"])
+
+	t.Run("instance absent from a pick is not new", func(t *testing.T) {
+		countPicks(t, instances, "WeightServer|d", 2)
+		//instance is filtered out for a while
+		countPicks(t, instances[:1], "WeightServer|d", 5)
+		time.Sleep(10 * time.Millisecond)
+
+		counts := countPicks(t, instances, "WeightServer|d", 20)
+		assert.Equal(t, 10, counts["10.0.6.1:8080"])
+		assert.Equal(t, 10, counts["10.0.6.2:8080"])
+	})
+}
@@ -35,6 +35,7 @@ type Operate func(value, expression string) bool
 
 var operatorPlugin = map[string]Operate{
 	"exact":     exact,
+	"prefix":    prefix,
 	"contains":  contains,
 	"regex":     regex,
 	"noEqu":     noEqu,
@@ -120,6 +121,12 @@ func headsMatch(headers map[string]string, headPolicy map[string]map[string]stri
 	return true
 }
 
+//Installed return whether an operator is installed
+func Installed(name string) bool {
+	_, ok := operatorPlugin[name]
+	return ok
+}
+
 //Match compare value and expression
 func Match(strategy, value, expression string) (bool, error) {
 	f, ok := operatorPlugin[strategy]
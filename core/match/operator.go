@@ -10,6 +10,10 @@ func exact(value, express string) bool {
 	return value == express
 }
 
+func prefix(value, express string) bool {
+	return strings.HasPrefix(value, express)
+}
+
 func contains(value, express string) bool {
 	return strings.Contains(value, express)
 }
@@ -73,6 +73,18 @@ func TestOperator(t *testing.T) {
 			"2",
 			true,
 		},
+		"11": {
+			"prefix",
+			"/v1/users",
+			"/v1/",
+			true,
+		},
+		"12": {
+			"prefix",
+			"/v2/users",
+			"/v1/",
+			false,
+		},
 	}
 
 	for _, tc := range testCase {
@@ -3,6 +3,7 @@ package core
 import (
 	"time"
 
+	"github.com/go-chassis/go-chassis/core/common"
 	"github.com/go-chassis/go-chassis/core/invocation"
 	"github.com/go-chassis/go-chassis/pkg/util/tags"
 )
@@ -147,4 +148,7 @@ func wrapInvocationWithOpts(i *invocation.Invocation, opts InvokeOptions) {
 	}
 
 	i.RouteTags = opts.RouteTags
+	if opts.Stream {
+		i.SetMetadata(common.Stream, true)
+	}
 }
@@ -0,0 +1,501 @@
+//Package aggregation is a registry plugin which combines several registry plugins,
+//it is useful when micro services are split between different registries, for example during migration.
+//instances are discovered from all service discovery plugins, the first registrator is the primary one,
+//and instance can be registered to other registrators as well
+package aggregation
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/go-mesh/openlogging"
+)
+
+//Name is the name of aggregation registry plugin
+const Name = "aggregation"
+
+//config keys of aggregation plugin
+const (
+	//ServiceDiscoveryKey is a comma separated list of service discovery plugins
+	ServiceDiscoveryKey = "cse.service.registry.aggregation.serviceDiscovery"
+	//RegistratorKey is a comma separated list of registrator plugins, the first one is primary
+	RegistratorKey = "cse.service.registry.aggregation.registrator"
+	//DoubleRegisterKey decides whether to register to all registrators
+	DoubleRegisterKey = "cse.service.registry.aggregation.doubleRegister"
+)
+
+//MetaOrigin is the instance metadata key of the registry which instance is discovered from
+const MetaOrigin = "registry"
+
+//ErrNoBackend happens if no registry plugin is configured
+var ErrNoBackend = errors.New("no registry plugin is configured for aggregation")
+
+//AddressKey return address config key of a registry plugin,
+//if it is not set, address of aggregation plugin is used
+func AddressKey(plugin string) string {
+	return "cse.service.registry.aggregation." + plugin + ".address"
+}
+
+//plugins parse plugin list from config, aggregation itself is ignored
+func plugins(key string) []string {
+	result := make([]string, 0)
+	for _, p := range strings.Split(archaius.GetString(key, registry.DefaultServiceDiscoveryPlugin), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || p == Name {
+			continue
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+//backendOptions return options of a registry plugin
+func backendOptions(plugin string, opts registry.Options) registry.Options {
+	addr := archaius.GetString(AddressKey(plugin), "")
+	if addr == "" {
+		return opts
+	}
+	hosts, scheme, err := registry.URIs2Hosts(strings.Split(addr, ","))
+	if err != nil {
+		openlogging.GetLogger().Errorf("invalid address of [%s]: %s", plugin, err)
+		return opts
+	}
+	opts.Addrs = hosts
+	opts.EnableSSL = scheme == "https"
+	if !opts.EnableSSL {
+		opts.TLSConfig = nil
+	}
+	return opts
+}
+
+type discovery struct {
+	name string
+	sd   registry.ServiceDiscovery
+}
+
+// ServiceDiscovery fans out to several service discovery plugins, each plugin has its own instance cache
+type ServiceDiscovery struct {
+	Name     string
+	backends []discovery
+}
+
+// GetMicroService return the micro service from the first plugin which knows it
+func (d *ServiceDiscovery) GetMicroService(microServiceID string) (*registry.MicroService, error) {
+	err := ErrNoBackend
+	for _, b := range d.backends {
+		var ms *registry.MicroService
+		if ms, err = b.sd.GetMicroService(microServiceID); err == nil {
+			return ms, nil
+		}
+	}
+	return nil, err
+}
+
+// FindMicroServiceInstances find instances from all plugins concurrently,
+// instances are merged in order of plugins, and the one with same endpoint as a former one is dropped
+func (d *ServiceDiscovery) FindMicroServiceInstances(consumerID, microServiceName string, t utiltags.Tags) ([]*registry.MicroServiceInstance, error) {
+	results := make([][]*registry.MicroServiceInstance, len(d.backends))
+	errs := make([]error, len(d.backends))
+	var wg sync.WaitGroup
+	for i, b := range d.backends {
+		wg.Add(1)
+		go func(i int, b discovery) {
+			defer wg.Done()
+			results[i], errs[i] = b.sd.FindMicroServiceInstances(consumerID, microServiceName, copyTags(t))
+		}(i, b)
+	}
+	wg.Wait()
+
+	var lastErr error
+	seen := make(map[string]struct{})
+	instances := make([]*registry.MicroServiceInstance, 0)
+	for i, b := range d.backends {
+		if errs[i] != nil {
+			openlogging.GetLogger().Warnf("find instances of [%s] from [%s] failed: %s", microServiceName, b.name, errs[i])
+			lastErr = errs[i]
+			continue
+		}
+		for _, ins := range results[i] {
+			if duplicated(ins, seen) {
+				continue
+			}
+			instances = append(instances, ins)
+		}
+	}
+	if len(instances) == 0 {
+		return nil, lastErr
+	}
+	return instances, nil
+}
+
+// AutoSync starts sync of all plugins
+func (d *ServiceDiscovery) AutoSync() {
+	for _, b := range d.backends {
+		b.sd.AutoSync()
+	}
+}
+
+// Close closes all plugins
+func (d *ServiceDiscovery) Close() error {
+	var err error
+	for _, b := range d.backends {
+		if e := b.sd.Close(); e != nil {
+			openlogging.GetLogger().Errorf("close [%s] failed: %s", b.name, e)
+			err = e
+		}
+	}
+	return err
+}
+
+//copyTags avoids plugins changing tags concurrently
+func copyTags(t utiltags.Tags) utiltags.Tags {
+	if t.KV == nil {
+		return t
+	}
+	kv := make(map[string]string, len(t.KV))
+	for k, v := range t.KV {
+		kv[k] = v
+	}
+	return utiltags.Tags{KV: kv, Label: t.Label}
+}
+
+//duplicated checks whether instance has an endpoint which is seen before, and records its endpoints
+func duplicated(ins *registry.MicroServiceInstance, seen map[string]struct{}) bool {
+	eps := registry.GetProtocolList(ins.EndpointsMap)
+	for _, ep := range eps {
+		if _, ok := seen[ep]; ok {
+			return true
+		}
+	}
+	for _, ep := range eps {
+		seen[ep] = struct{}{}
+	}
+	return false
+}
+
+//originIndex is the instance cache of a plugin, instances are marked where they come from when they are cached,
+//so that instances are not copied in every discovery
+type originIndex struct {
+	registry.CacheIndex
+	origin string
+}
+
+//Set copies instances and marks their origin, instances of plugin must not be changed
+func (c *originIndex) Set(service string, instances []*registry.MicroServiceInstance) {
+	marked := make([]*registry.MicroServiceInstance, len(instances))
+	for i, ins := range instances {
+		marked[i] = withOrigin(ins, c.origin)
+	}
+	c.CacheIndex.Set(service, marked)
+}
+
+func withOrigin(ins *registry.MicroServiceInstance, origin string) *registry.MicroServiceInstance {
+	if ins == nil || ins.Metadata[MetaOrigin] == origin {
+		return ins
+	}
+	c := *ins
+	c.Metadata = make(map[string]string, len(ins.Metadata)+1)
+	for k, v := range ins.Metadata {
+		c.Metadata[k] = v
+	}
+	c.Metadata[MetaOrigin] = origin
+	return &c
+}
+
+type registrator struct {
+	name string
+	r    registry.Registrator
+}
+
+// Registrator registers to primary registrator, and also to the others if double register is enabled.
+// primary decides the result and ids, failures of others are logged and retried in heartbeat
+type Registrator struct {
+	Name     string
+	backends []registrator
+
+	mu sync.Mutex
+	//key is service id in primary, value is the service and its ids in each registrator
+	services map[string]*registeredService
+	//key is instance id in primary
+	instances map[string]*registeredInstance
+}
+
+type registeredService struct {
+	ms  *registry.MicroService
+	ids []string
+}
+
+type registeredInstance struct {
+	sid string
+	ins *registry.MicroServiceInstance
+	ids []string
+}
+
+// RegisterService register micro service to all registrators
+func (r *Registrator) RegisterService(ms *registry.MicroService) (string, error) {
+	origin := *ms
+	sid, err := r.backends[0].r.RegisterService(ms)
+	if err != nil {
+		return "", err
+	}
+	rs := &registeredService{ms: &origin, ids: make([]string, len(r.backends))}
+	rs.ids[0] = sid
+	for i := 1; i < len(r.backends); i++ {
+		rs.ids[i] = r.registerService(i, rs.ms)
+	}
+	r.mu.Lock()
+	r.services[sid] = rs
+	r.mu.Unlock()
+	return sid, nil
+}
+
+func (r *Registrator) registerService(i int, ms *registry.MicroService) string {
+	c := *ms
+	sid, err := r.backends[i].r.RegisterService(&c)
+	if err != nil {
+		openlogging.GetLogger().Errorf("register service [%s] to [%s] failed: %s", ms.ServiceName, r.backends[i].name, err)
+		return ""
+	}
+	return sid
+}
+
+// RegisterServiceInstance register instance to all registrators
+func (r *Registrator) RegisterServiceInstance(sid string, instance *registry.MicroServiceInstance) (string, error) {
+	origin := *instance
+	iid, err := r.backends[0].r.RegisterServiceInstance(sid, instance)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	ri, ok := r.instances[iid]
+	if !ok {
+		ri = &registeredInstance{ids: make([]string, len(r.backends))}
+		r.instances[iid] = ri
+	}
+	ri.sid = sid
+	ri.ins = &origin
+	ri.ids[0] = iid
+	r.mu.Unlock()
+	for i := 1; i < len(r.backends); i++ {
+		r.registerInstance(i, ri)
+	}
+	return iid, nil
+}
+
+//registerInstance register instance to the ith registrator, service is registered again if it is failed before
+func (r *Registrator) registerInstance(i int, ri *registeredInstance) {
+	r.mu.Lock()
+	rs, ok := r.services[ri.sid]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	sid, iid := rs.ids[i], ri.ids[i]
+	r.mu.Unlock()
+	if sid == "" {
+		if sid = r.registerService(i, rs.ms); sid == "" {
+			return
+		}
+	}
+	c := *ri.ins
+	c.InstanceID = iid
+	iid, err := r.backends[i].r.RegisterServiceInstance(sid, &c)
+	if err != nil {
+		openlogging.GetLogger().Errorf("register instance to [%s] failed: %s", r.backends[i].name, err)
+		iid = ""
+	}
+	r.mu.Lock()
+	rs.ids[i] = sid
+	ri.ids[i] = iid
+	r.mu.Unlock()
+}
+
+// RegisterServiceAndInstance register micro service and instance to all registrators
+func (r *Registrator) RegisterServiceAndInstance(ms *registry.MicroService, instance *registry.MicroServiceInstance) (string, string, error) {
+	sid, err := r.RegisterService(ms)
+	if err != nil {
+		return "", "", err
+	}
+	iid, err := r.RegisterServiceInstance(sid, instance)
+	if err != nil {
+		return sid, "", err
+	}
+	return sid, iid, nil
+}
+
+// Heartbeat sends heartbeat to all registrators, instance is registered again if heartbeat of other registrator fails
+func (r *Registrator) Heartbeat(microServiceID, microServiceInstanceID string) (bool, error) {
+	ok, err := r.backends[0].r.Heartbeat(microServiceID, microServiceInstanceID)
+	for i := 1; i < len(r.backends); i++ {
+		r.mu.Lock()
+		ri, known := r.instances[microServiceInstanceID]
+		var sid, iid string
+		if known {
+			iid = ri.ids[i]
+			if rs, ok := r.services[ri.sid]; ok {
+				sid = rs.ids[i]
+			}
+		}
+		r.mu.Unlock()
+		if !known {
+			continue
+		}
+		if sid != "" && iid != "" {
+			if _, err := r.backends[i].r.Heartbeat(sid, iid); err == nil {
+				continue
+			}
+		}
+		r.registerInstance(i, ri)
+	}
+	return ok, err
+}
+
+// UnRegisterMicroServiceInstance remove instance from all registrators
+func (r *Registrator) UnRegisterMicroServiceInstance(microServiceID, microServiceInstanceID string) error {
+	err := r.backends[0].r.UnRegisterMicroServiceInstance(microServiceID, microServiceInstanceID)
+	r.each(microServiceID, microServiceInstanceID, func(b registry.Registrator, sid, iid string) error {
+		return b.UnRegisterMicroServiceInstance(sid, iid)
+	})
+	if err == nil {
+		r.mu.Lock()
+		delete(r.instances, microServiceInstanceID)
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// UpdateMicroServiceInstanceStatus update instance status in all registrators
+func (r *Registrator) UpdateMicroServiceInstanceStatus(microServiceID, microServiceInstanceID, status string) error {
+	r.mu.Lock()
+	if ri, ok := r.instances[microServiceInstanceID]; ok {
+		ri.ins.Status = status
+	}
+	r.mu.Unlock()
+	err := r.backends[0].r.UpdateMicroServiceInstanceStatus(microServiceID, microServiceInstanceID, status)
+	r.each(microServiceID, microServiceInstanceID, func(b registry.Registrator, sid, iid string) error {
+		return b.UpdateMicroServiceInstanceStatus(sid, iid, status)
+	})
+	return err
+}
+
+// UpdateMicroServiceProperties update micro service properties in all registrators
+func (r *Registrator) UpdateMicroServiceProperties(microServiceID string, properties map[string]string) error {
+	err := r.backends[0].r.UpdateMicroServiceProperties(microServiceID, properties)
+	r.each(microServiceID, "", func(b registry.Registrator, sid, iid string) error {
+		return b.UpdateMicroServiceProperties(sid, properties)
+	})
+	return err
+}
+
+// UpdateMicroServiceInstanceProperties update instance properties in all registrators
+func (r *Registrator) UpdateMicroServiceInstanceProperties(microServiceID, microServiceInstanceID string, properties map[string]string) error {
+	err := r.backends[0].r.UpdateMicroServiceInstanceProperties(microServiceID, microServiceInstanceID, properties)
+	r.each(microServiceID, microServiceInstanceID, func(b registry.Registrator, sid, iid string) error {
+		return b.UpdateMicroServiceInstanceProperties(sid, iid, properties)
+	})
+	return err
+}
+
+// AddSchemas add schema to all registrators
+func (r *Registrator) AddSchemas(microServiceID, schemaName, schemaInfo string) error {
+	err := r.backends[0].r.AddSchemas(microServiceID, schemaName, schemaInfo)
+	r.each(microServiceID, "", func(b registry.Registrator, sid, iid string) error {
+		return b.AddSchemas(sid, schemaName, schemaInfo)
+	})
+	return err
+}
+
+// Close closes all registrators
+func (r *Registrator) Close() error {
+	var err error
+	for _, b := range r.backends {
+		if e := b.r.Close(); e != nil {
+			openlogging.GetLogger().Errorf("close [%s] failed: %s", b.name, e)
+			err = e
+		}
+	}
+	return err
+}
+
+//each calls f with ids of other registrators, errors are only logged.
+//if instance id is not empty, f is called only when instance is known
+func (r *Registrator) each(sid, iid string, f func(b registry.Registrator, sid, iid string) error) {
+	for i := 1; i < len(r.backends); i++ {
+		r.mu.Lock()
+		rs, ok := r.services[sid]
+		ri, iok := r.instances[iid]
+		var bsid, biid string
+		if ok {
+			bsid = rs.ids[i]
+		}
+		if iok {
+			biid = ri.ids[i]
+		}
+		r.mu.Unlock()
+		if !ok || bsid == "" || (iid != "" && !iok) {
+			continue
+		}
+		if err := f(r.backends[i].r, bsid, biid); err != nil {
+			openlogging.GetLogger().Errorf("call [%s] failed: %s", r.backends[i].name, err)
+		}
+	}
+}
+
+//NewRegistrator new aggregation registrator,
+//it returns primary registrator if double register is disabled, and nil if no registrator is available
+func NewRegistrator(options registry.Options) registry.Registrator {
+	names := plugins(RegistratorKey)
+	if !archaius.GetBool(DoubleRegisterKey, false) && len(names) > 1 {
+		names = names[:1]
+	}
+	backends := make([]registrator, 0, len(names))
+	for _, name := range names {
+		b, err := registry.NewRegistrator(name, backendOptions(name, options))
+		if err != nil {
+			openlogging.GetLogger().Errorf("can not aggregate registrator [%s]: %s", name, err)
+			continue
+		}
+		backends = append(backends, registrator{name: name, r: b})
+	}
+	if len(backends) == 0 {
+		openlogging.Error(ErrNoBackend.Error())
+		return nil
+	}
+	if len(backends) == 1 {
+		return backends[0].r
+	}
+	openlogging.GetLogger().Infof("register to %s", strings.Join(names, ","))
+	return &Registrator{
+		Name:      Name,
+		backends:  backends,
+		services:  make(map[string]*registeredService),
+		instances: make(map[string]*registeredInstance),
+	}
+}
+
+//NewServiceDiscovery new aggregation service discovery, every plugin gets its own instance cache
+func NewServiceDiscovery(options registry.Options) registry.ServiceDiscovery {
+	names := plugins(ServiceDiscoveryKey)
+	d := &ServiceDiscovery{Name: Name}
+	for _, name := range names {
+		opts := backendOptions(name, options)
+		opts.Index = &originIndex{CacheIndex: registry.NewIndexCache(), origin: name}
+		sd, err := registry.NewDiscovery(name, opts)
+		if err != nil {
+			openlogging.GetLogger().Errorf("can not aggregate service discovery [%s]: %s", name, err)
+			continue
+		}
+		d.backends = append(d.backends, discovery{name: name, sd: sd})
+	}
+	openlogging.GetLogger().Infof("discover instances from %s", strings.Join(names, ","))
+	return d
+}
+
+func init() {
+	registry.InstallRegistrator(Name, NewRegistrator)
+	registry.InstallServiceDiscovery(Name, NewServiceDiscovery)
+}
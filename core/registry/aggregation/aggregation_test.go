@@ -0,0 +1,226 @@
+package aggregation_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/core/registry/aggregation"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+	archaius.Init(archaius.WithMemorySource())
+}
+
+func instance(id, addr, version string) *registry.MicroServiceInstance {
+	ins := &registry.MicroServiceInstance{
+		InstanceID:   id,
+		Version:      version,
+		EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {Address: addr}},
+		Metadata:     map[string]string{common.BuildinTagVersion: version},
+	}
+	return ins.WithAppID(common.DefaultApp)
+}
+
+//fakeDiscovery caches static instances in its own cache
+type fakeDiscovery struct {
+	index     registry.CacheIndex
+	instances []*registry.MicroServiceInstance
+	err       error
+}
+
+func (d *fakeDiscovery) GetMicroService(sid string) (*registry.MicroService, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &registry.MicroService{ServiceID: sid, ServiceName: "Server"}, nil
+}
+func (d *fakeDiscovery) FindMicroServiceInstances(consumerID, name string, t utiltags.Tags) ([]*registry.MicroServiceInstance, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	if _, ok := registry.IndexOrDefault(d.index).Get(name, nil); !ok {
+		d.AutoSync()
+	}
+	ins, _ := registry.IndexOrDefault(d.index).Get(name, t.KV)
+	return ins, nil
+}
+func (d *fakeDiscovery) AutoSync() {
+	registry.IndexOrDefault(d.index).Set("Server", d.instances)
+}
+func (d *fakeDiscovery) Close() error { return nil }
+
+//fakeRegistrator generates ids with its name
+type fakeRegistrator struct {
+	name       string
+	fail       bool
+	heartbeats map[string]int
+	registered map[string]*registry.MicroServiceInstance
+}
+
+func (r *fakeRegistrator) Close() error { return nil }
+func (r *fakeRegistrator) RegisterService(ms *registry.MicroService) (string, error) {
+	if r.fail {
+		return "", errors.New("unavailable")
+	}
+	return r.name + "-" + ms.ServiceName, nil
+}
+func (r *fakeRegistrator) RegisterServiceInstance(sid string, ins *registry.MicroServiceInstance) (string, error) {
+	if r.fail {
+		return "", errors.New("unavailable")
+	}
+	if ins.InstanceID == "" {
+		ins.InstanceID = fmt.Sprintf("%s-%d", r.name, len(r.registered))
+	}
+	r.registered[ins.InstanceID] = ins
+	return ins.InstanceID, nil
+}
+func (r *fakeRegistrator) RegisterServiceAndInstance(ms *registry.MicroService, ins *registry.MicroServiceInstance) (string, string, error) {
+	return "", "", nil
+}
+func (r *fakeRegistrator) Heartbeat(sid, iid string) (bool, error) {
+	if _, ok := r.registered[iid]; !ok || r.fail {
+		return false, errors.New("not found")
+	}
+	r.heartbeats[iid]++
+	return true, nil
+}
+func (r *fakeRegistrator) UnRegisterMicroServiceInstance(sid, iid string) error {
+	delete(r.registered, iid)
+	return nil
+}
+func (r *fakeRegistrator) UpdateMicroServiceInstanceStatus(sid, iid, status string) error {
+	r.registered[iid].Status = status
+	return nil
+}
+func (r *fakeRegistrator) UpdateMicroServiceProperties(sid string, properties map[string]string) error {
+	return nil
+}
+func (r *fakeRegistrator) UpdateMicroServiceInstanceProperties(sid, iid string, properties map[string]string) error {
+	return nil
+}
+func (r *fakeRegistrator) AddSchemas(sid, schemaName, schemaInfo string) error { return nil }
+
+func TestServiceDiscovery(t *testing.T) {
+	registry.EnableRegistryCache()
+	broken := &fakeDiscovery{err: errors.New("unavailable")}
+	a := []*registry.MicroServiceInstance{
+		instance("a1", "127.0.0.1:8080", "1.0"),
+		instance("a2", "127.0.0.1:8081", "2.0"),
+	}
+	registry.InstallServiceDiscovery("a", func(opts registry.Options) registry.ServiceDiscovery {
+		return &fakeDiscovery{index: opts.Index, instances: a}
+	})
+	registry.InstallServiceDiscovery("b", func(opts registry.Options) registry.ServiceDiscovery {
+		return &fakeDiscovery{index: opts.Index, instances: []*registry.MicroServiceInstance{
+			instance("b1", "127.0.0.1:8081", "2.0"),
+			instance("b2", "127.0.0.1:9090", "1.0"),
+		}}
+	})
+	registry.InstallServiceDiscovery("broken", func(opts registry.Options) registry.ServiceDiscovery {
+		return broken
+	})
+	archaius.Set(aggregation.ServiceDiscoveryKey, "a, b, broken")
+	sd, err := registry.NewDiscovery(aggregation.Name, registry.Options{})
+	assert.NoError(t, err)
+
+	t.Run("merge and deduplicate", func(t *testing.T) {
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(ins))
+		assert.Equal(t, "a1", ins[0].InstanceID)
+		assert.Equal(t, "a", ins[0].Metadata[aggregation.MetaOrigin])
+		assert.Equal(t, "a2", ins[1].InstanceID)
+		assert.Equal(t, "b2", ins[2].InstanceID)
+		assert.Equal(t, "b", ins[2].Metadata[aggregation.MetaOrigin])
+	})
+	t.Run("plugin caches are isolated", func(t *testing.T) {
+		_, ok := registry.MicroserviceInstanceIndex.Get("Server", nil)
+		assert.False(t, ok)
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.NewDefaultTag("1.0", common.DefaultApp))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(ins))
+	})
+	t.Run("origin is marked once when cache is filled", func(t *testing.T) {
+		first, _ := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{})
+		second, _ := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{})
+		assert.Equal(t, len(first), len(second))
+		for i := range first {
+			assert.True(t, first[i] == second[i], "instance is not copied in discovery")
+		}
+		_, ok := a[0].Metadata[aggregation.MetaOrigin]
+		assert.False(t, ok, "instances of plugin are not changed")
+		ins, _ := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{KV: map[string]string{aggregation.MetaOrigin: "b"}})
+		assert.Equal(t, 2, len(ins))
+		for _, i := range ins {
+			assert.Equal(t, "b", i.Metadata[aggregation.MetaOrigin])
+		}
+	})
+	t.Run("all plugins fail", func(t *testing.T) {
+		archaius.Set(aggregation.ServiceDiscoveryKey, "broken")
+		sd := aggregation.NewServiceDiscovery(registry.Options{})
+		_, err := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{})
+		assert.Equal(t, broken.err, err)
+		_, err = sd.GetMicroService("x")
+		assert.Equal(t, broken.err, err)
+	})
+}
+
+func TestRegistrator(t *testing.T) {
+	primary := &fakeRegistrator{name: "p", heartbeats: map[string]int{}, registered: map[string]*registry.MicroServiceInstance{}}
+	secondary := &fakeRegistrator{name: "s", fail: true, heartbeats: map[string]int{}, registered: map[string]*registry.MicroServiceInstance{}}
+	registry.InstallRegistrator("p", func(opts registry.Options) registry.Registrator { return primary })
+	registry.InstallRegistrator("s", func(opts registry.Options) registry.Registrator { return secondary })
+	archaius.Set(aggregation.RegistratorKey, "p,s")
+
+	t.Run("no registrator", func(t *testing.T) {
+		archaius.Set(aggregation.RegistratorKey, "none")
+		defer archaius.Set(aggregation.RegistratorKey, "p,s")
+		r, err := registry.NewRegistrator(aggregation.Name, registry.Options{})
+		assert.Error(t, err)
+		assert.Nil(t, r)
+	})
+	t.Run("primary only", func(t *testing.T) {
+		archaius.Set(aggregation.DoubleRegisterKey, false)
+		assert.Equal(t, primary, aggregation.NewRegistrator(registry.Options{}))
+	})
+
+	archaius.Set(aggregation.DoubleRegisterKey, true)
+	r := aggregation.NewRegistrator(registry.Options{})
+	sid, iid, err := r.RegisterServiceAndInstance(&registry.MicroService{ServiceName: "Server"}, &registry.MicroServiceInstance{})
+	t.Run("secondary failure is ignored", func(t *testing.T) {
+		assert.NoError(t, err)
+		assert.Equal(t, "p-Server", sid)
+		assert.Equal(t, "p-0", iid)
+		assert.Equal(t, 0, len(secondary.registered))
+	})
+	t.Run("heartbeat registers again", func(t *testing.T) {
+		secondary.fail = false
+		ok, err := r.Heartbeat(sid, iid)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(secondary.registered))
+		assert.NotNil(t, secondary.registered["s-0"])
+
+		_, err = r.Heartbeat(sid, iid)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, secondary.heartbeats["s-0"])
+		assert.Equal(t, 2, primary.heartbeats[iid])
+	})
+	t.Run("ids are mapped", func(t *testing.T) {
+		assert.NoError(t, r.UpdateMicroServiceInstanceStatus(sid, iid, "DOWN"))
+		assert.Equal(t, "DOWN", secondary.registered["s-0"].Status)
+		assert.NoError(t, r.UnRegisterMicroServiceInstance(sid, iid))
+		assert.Equal(t, 0, len(secondary.registered))
+		assert.Equal(t, 0, len(primary.registered))
+	})
+}
@@ -41,6 +41,14 @@ func EnableRegistryCache() {
 	ProvidersMicroServiceCache = initCache()
 }
 
+//IndexOrDefault return index, or MicroserviceInstanceIndex if index is nil
+func IndexOrDefault(index CacheIndex) CacheIndex {
+	if index != nil {
+		return index
+	}
+	return MicroserviceInstanceIndex
+}
+
 // CacheIndex is a unified local instances cache manager
 type CacheIndex interface {
 	Get(service string, tags map[string]string) ([]*MicroServiceInstance, bool)
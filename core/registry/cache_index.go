@@ -19,6 +19,10 @@ type IndexCache struct {
 	indexedCache *cache.Cache
 
 	CriteriaStore []map[string]string //all criteria need to be saved in here so that we can update indexedCache, during Set process
+
+	//revision of every service increases when its instances are set or deleted
+	revision    map[string]uint64
+	muxRevision sync.RWMutex
 }
 
 //NewIndexCache create a cache which saves and manage instances
@@ -28,9 +32,23 @@ func NewIndexCache() *IndexCache {
 		latestV:      map[string]string{},
 		indexedCache: cache.New(DefaultExpireTime, 0),
 		muxLatestV:   sync.RWMutex{},
+		revision:     map[string]uint64{},
 	}
 }
 
+//Revision return revision of service instances, it changes every time instances are set or deleted
+func (ic *IndexCache) Revision(k string) uint64 {
+	ic.muxRevision.RLock()
+	defer ic.muxRevision.RUnlock()
+	return ic.revision[k]
+}
+
+func (ic *IndexCache) bump(k string) {
+	ic.muxRevision.Lock()
+	ic.revision[k]++
+	ic.muxRevision.Unlock()
+}
+
 //FullCache return all instances
 func (ic *IndexCache) FullCache() *cache.Cache { return ic.simpleCache }
 
@@ -38,6 +56,7 @@ func (ic *IndexCache) FullCache() *cache.Cache { return ic.simpleCache }
 func (ic *IndexCache) Delete(k string) {
 	ic.simpleCache.Delete(k)
 	ic.indexedCache.Delete(k)
+	ic.bump(k)
 }
 
 //Set overwrite instances cache
@@ -70,7 +89,7 @@ func (ic *IndexCache) Set(k string, instances []*MicroServiceInstance) {
 	//ic.muxCriteria.RUnlock()
 
 	ic.simpleCache.Set(k, instances, 0)
-
+	ic.bump(k)
 }
 
 //Get return instances cache by criteria
@@ -0,0 +1,291 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+//ErrNotFound happens if consul returns 404
+var ErrNotFound = errors.New("not found in consul")
+
+//HeaderIndex is the response header of consul which carries index for blocking query
+const HeaderIndex = "X-Consul-Index"
+
+//DefaultTimeout is the timeout of a request, blocking query waits longer by its wait time
+const DefaultTimeout = 10 * time.Second
+
+//agentPrefix is the path prefix of agent APIs, they change state of the agent which receives request
+const agentPrefix = "/v1/agent/"
+
+//AgentServiceCheck is the check of a service registration
+type AgentServiceCheck struct {
+	CheckID                        string `json:",omitempty"`
+	TTL                            string `json:",omitempty"`
+	DeregisterCriticalServiceAfter string `json:",omitempty"`
+}
+
+//AgentServiceRegistration is the body of service register API
+type AgentServiceRegistration struct {
+	ID      string
+	Name    string
+	Tags    []string           `json:",omitempty"`
+	Address string             `json:",omitempty"`
+	Port    int                `json:",omitempty"`
+	Meta    map[string]string  `json:",omitempty"`
+	Check   *AgentServiceCheck `json:",omitempty"`
+}
+
+//AgentService is a service in health API response
+type AgentService struct {
+	ID      string
+	Service string
+	Tags    []string
+	Address string
+	Port    int
+	Meta    map[string]string
+}
+
+//Node is a consul node
+type Node struct {
+	Node       string
+	Address    string
+	Datacenter string
+}
+
+//ServiceEntry is an element of health service API response
+type ServiceEntry struct {
+	Node    *Node
+	Service *AgentService
+}
+
+//Client talks to consul agent by HTTP API
+type Client struct {
+	addrs   []string
+	scheme  string
+	token   string
+	timeout time.Duration
+	http    *http.Client
+	next    uint32
+}
+
+//NewClient create consul client, opts.Timeout is the timeout of a request
+func NewClient(opts registry.Options, token string) *Client {
+	c := &Client{
+		addrs:   opts.Addrs,
+		scheme:  "http",
+		token:   token,
+		timeout: opts.Timeout,
+	}
+	if len(c.addrs) == 0 {
+		c.addrs = []string{DefaultAddr}
+	}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	//client has no total timeout because of blocking query, every request has its own deadline
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: c.timeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   c.timeout,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if opts.EnableSSL {
+		c.scheme = "https"
+		t.TLSClientConfig = opts.TLSConfig
+	}
+	c.http = &http.Client{Transport: t}
+	return c
+}
+
+//do send request to consul, if an agent is not reachable, next one is tried,
+//except agent APIs which are always sent to the first agent.
+//request is canceled after timeout, wait is the wait time of blocking query
+func (c *Client) do(ctx context.Context, wait time.Duration, method, path string, query url.Values, body io.Reader, out interface{}) (http.Header, error) {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = ioutil.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasPrefix(path, agentPrefix) {
+		return c.send(ctx, wait, c.addrs[0], method, path, query, b, out)
+	}
+	var lastErr error
+	for i := 0; i < len(c.addrs); i++ {
+		addr := c.addrs[int(atomic.LoadUint32(&c.next))%len(c.addrs)]
+		h, err := c.send(ctx, wait, addr, method, path, query, b, out)
+		if err == nil || h != nil || ctx.Err() != nil {
+			return h, err
+		}
+		lastErr = err
+		atomic.AddUint32(&c.next, 1)
+	}
+	return nil, lastErr
+}
+
+//send request to an agent, it returns nil header if agent is not reachable
+func (c *Client) send(ctx context.Context, wait time.Duration, addr, method, path string, query url.Values, body []byte, out interface{}) (http.Header, error) {
+	//consul adds a random jitter up to wait/16 to wait time
+	ctx, cancel := context.WithTimeout(ctx, c.timeout+wait+wait/16)
+	defer cancel()
+	u := url.URL{Scheme: c.scheme, Host: addr, Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.Header, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return resp.Header, fmt.Errorf("consul returns %d: %s", resp.StatusCode, msg)
+	}
+	if out == nil {
+		return resp.Header, nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		*raw, err = ioutil.ReadAll(resp.Body)
+		return resp.Header, err
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) put(path string, query url.Values, v interface{}) error {
+	var body io.Reader
+	switch b := v.(type) {
+	case nil:
+	case []byte:
+		body = bytes.NewReader(b)
+	default:
+		js, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(js)
+	}
+	_, err := c.do(context.Background(), 0, http.MethodPut, path, query, body, nil)
+	return err
+}
+
+//Register register a service to local agent
+func (c *Client) Register(r *AgentServiceRegistration) error {
+	return c.put("/v1/agent/service/register", nil, r)
+}
+
+//Deregister remove a service from local agent
+func (c *Client) Deregister(id string) error {
+	return c.put("/v1/agent/service/deregister/"+url.PathEscape(id), nil, nil)
+}
+
+//PassTTL set TTL check to passing
+func (c *Client) PassTTL(checkID string) error {
+	return c.put("/v1/agent/check/pass/"+url.PathEscape(checkID), nil, nil)
+}
+
+//Maintenance put service into maintenance mode, so that it is not discovered
+func (c *Client) Maintenance(id string, enable bool, reason string) error {
+	q := url.Values{}
+	q.Set("enable", strconv.FormatBool(enable))
+	if reason != "" {
+		q.Set("reason", reason)
+	}
+	return c.put("/v1/agent/service/maintenance/"+url.PathEscape(id), q, nil)
+}
+
+//HealthService return passing instances of service,
+//if index is not 0, it blocks until services changes or wait time is over
+func (c *Client) HealthService(ctx context.Context, name string, index uint64, wait time.Duration) ([]*ServiceEntry, uint64, error) {
+	entries := make([]*ServiceEntry, 0)
+	h, err := c.do(ctx, blockingWait(index, wait), http.MethodGet, "/v1/health/service/"+url.PathEscape(name),
+		blocking(index, wait, true), nil, &entries)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, parseIndex(h), nil
+}
+
+//Services return all service names in catalog, it supports blocking query
+func (c *Client) Services(ctx context.Context, index uint64, wait time.Duration) (map[string][]string, uint64, error) {
+	services := make(map[string][]string)
+	h, err := c.do(ctx, blockingWait(index, wait), http.MethodGet, "/v1/catalog/services", blocking(index, wait, false), nil, &services)
+	if err != nil {
+		return nil, 0, err
+	}
+	return services, parseIndex(h), nil
+}
+
+//PutKV write value of a key
+func (c *Client) PutKV(key string, value []byte) error {
+	return c.put("/v1/kv/"+key, nil, value)
+}
+
+//GetKV read raw value of a key
+func (c *Client) GetKV(key string) ([]byte, error) {
+	var b []byte
+	q := url.Values{}
+	q.Set("raw", "")
+	_, err := c.do(context.Background(), 0, http.MethodGet, "/v1/kv/"+key, q, nil, &b)
+	return b, err
+}
+
+//Keys list keys with prefix
+func (c *Client) Keys(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	q := url.Values{}
+	q.Set("keys", "")
+	_, err := c.do(context.Background(), 0, http.MethodGet, "/v1/kv/"+prefix, q, nil, &keys)
+	if err == ErrNotFound {
+		return keys, nil
+	}
+	return keys, err
+}
+
+//blockingWait return how long consul may hold the request
+func blockingWait(index uint64, wait time.Duration) time.Duration {
+	if index == 0 {
+		return 0
+	}
+	return wait
+}
+
+func blocking(index uint64, wait time.Duration, passing bool) url.Values {
+	q := url.Values{}
+	if index != 0 {
+		q.Set("index", strconv.FormatUint(index, 10))
+		q.Set("wait", wait.String())
+	}
+	if passing {
+		q.Set("passing", "true")
+	}
+	return q
+}
+
+func parseIndex(h http.Header) uint64 {
+	i, _ := strconv.ParseUint(h.Get(HeaderIndex), 10, 64)
+	return i
+}
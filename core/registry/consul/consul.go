@@ -0,0 +1,494 @@
+//Package consul is a registry plugin backed by consul,
+//instances are registered into consul catalog with TTL check, heartbeat passes the check.
+//micro services and schemas are saved in consul KV.
+//service discovery watches catalog by blocking query and feeds instance cache
+package consul
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	//Name is the name of consul registry plugin
+	Name = "consul"
+	//DefaultAddr is the address of local consul agent
+	DefaultAddr = "127.0.0.1:8500"
+)
+
+//config keys of consul plugin
+const (
+	TokenKey           = "cse.service.registry.consul.token"
+	PrefixKey          = "cse.service.registry.consul.prefix"
+	TTLKey             = "cse.service.registry.consul.ttl"
+	DeregisterAfterKey = "cse.service.registry.consul.deregisterCriticalServiceAfter"
+	WaitTimeKey        = "cse.service.registry.consul.waitTime"
+	TimeoutKey         = "cse.service.registry.consul.timeout"
+)
+
+func newStore(opts registry.Options) *store {
+	if opts.Timeout <= 0 {
+		if d, err := time.ParseDuration(archaius.GetString(TimeoutKey, "")); err == nil && d > 0 {
+			opts.Timeout = d
+		}
+	}
+	return &store{
+		c:      NewClient(opts, archaius.GetString(TokenKey, "")),
+		prefix: archaius.GetString(PrefixKey, "go-chassis"),
+	}
+}
+
+//checkID is the TTL check id of an instance
+func checkID(iid string) string {
+	return "service:" + iid
+}
+
+// Registrator registers instances into consul catalog
+type Registrator struct {
+	Name string
+	s    *store
+
+	mu sync.Mutex
+	//key is instance id, they are kept to update instance
+	instances map[string]*registry.MicroServiceInstance
+}
+
+// RegisterService save micro service in KV
+func (r *Registrator) RegisterService(ms *registry.MicroService) (string, error) {
+	if ms.ServiceID == "" {
+		ms.ServiceID = ServiceID(ms)
+	}
+	if err := r.s.saveService(ms); err != nil {
+		return "", err
+	}
+	openlogging.GetLogger().Infof("register service [%s] in consul", ms.ServiceID)
+	return ms.ServiceID, nil
+}
+
+// RegisterServiceInstance register instance into consul with a TTL check
+func (r *Registrator) RegisterServiceInstance(sid string, instance *registry.MicroServiceInstance) (string, error) {
+	ms, err := r.s.service(sid)
+	if err != nil {
+		return "", err
+	}
+	if instance.InstanceID == "" {
+		instance.InstanceID = ms.ServiceName + "-" + instance.HostName
+		if ep := defaultEndpoint(instance); ep != nil {
+			instance.InstanceID += "-" + strings.Replace(ep.Address, ":", "-", -1)
+		}
+	}
+	if err := r.register(ms, instance); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.instances[instance.InstanceID] = instance
+	r.mu.Unlock()
+	openlogging.GetLogger().Infof("register instance [%s] of service [%s] in consul", instance.InstanceID, sid)
+	return instance.InstanceID, nil
+}
+
+func (r *Registrator) register(ms *registry.MicroService, instance *registry.MicroServiceInstance) error {
+	check := &AgentServiceCheck{
+		CheckID:                        checkID(instance.InstanceID),
+		TTL:                            archaius.GetString(TTLKey, "60s"),
+		DeregisterCriticalServiceAfter: archaius.GetString(DeregisterAfterKey, "5m"),
+	}
+	if err := r.s.c.Register(ToRegistration(ms, instance, check)); err != nil {
+		return err
+	}
+	//do not wait for first heartbeat
+	if err := r.s.c.PassTTL(check.CheckID); err != nil {
+		return err
+	}
+	if instance.Status != "" && instance.Status != common.DefaultStatus {
+		return r.s.c.Maintenance(instance.InstanceID, true, instance.Status)
+	}
+	return nil
+}
+
+// RegisterServiceAndInstance register micro service and instance
+func (r *Registrator) RegisterServiceAndInstance(ms *registry.MicroService, instance *registry.MicroServiceInstance) (string, string, error) {
+	sid, err := r.RegisterService(ms)
+	if err != nil {
+		return "", "", err
+	}
+	iid, err := r.RegisterServiceInstance(sid, instance)
+	if err != nil {
+		return sid, "", err
+	}
+	return sid, iid, nil
+}
+
+// Heartbeat pass the TTL check of instance
+func (r *Registrator) Heartbeat(microServiceID, microServiceInstanceID string) (bool, error) {
+	if err := r.s.c.PassTTL(checkID(microServiceInstanceID)); err != nil {
+		openlogging.GetLogger().Errorf("heartbeat of instance [%s] failed: %s", microServiceInstanceID, err)
+		return false, err
+	}
+	return true, nil
+}
+
+// UnRegisterMicroServiceInstance remove instance from consul
+func (r *Registrator) UnRegisterMicroServiceInstance(microServiceID, microServiceInstanceID string) error {
+	if err := r.s.c.Deregister(microServiceInstanceID); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	delete(r.instances, microServiceInstanceID)
+	r.mu.Unlock()
+	return nil
+}
+
+// UpdateMicroServiceInstanceStatus put instance into maintenance mode if status is not UP
+func (r *Registrator) UpdateMicroServiceInstanceStatus(microServiceID, microServiceInstanceID, status string) error {
+	if err := r.s.c.Maintenance(microServiceInstanceID, status != common.DefaultStatus, status); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if ins, ok := r.instances[microServiceInstanceID]; ok {
+		ins.Status = status
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// UpdateMicroServiceProperties update micro service metadata in KV
+func (r *Registrator) UpdateMicroServiceProperties(microServiceID string, properties map[string]string) error {
+	ms, err := r.s.service(microServiceID)
+	if err != nil {
+		return err
+	}
+	if ms.Metadata == nil {
+		ms.Metadata = make(map[string]string, len(properties))
+	}
+	for k, v := range properties {
+		ms.Metadata[k] = v
+	}
+	return r.s.saveService(ms)
+}
+
+// UpdateMicroServiceInstanceProperties register instance again with new metadata
+func (r *Registrator) UpdateMicroServiceInstanceProperties(microServiceID, microServiceInstanceID string, properties map[string]string) error {
+	r.mu.Lock()
+	ins, ok := r.instances[microServiceInstanceID]
+	r.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+	ms, err := r.s.service(microServiceID)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if ins.Metadata == nil {
+		ins.Metadata = make(map[string]string, len(properties))
+	}
+	for k, v := range properties {
+		ins.Metadata[k] = v
+	}
+	r.mu.Unlock()
+	return r.register(ms, ins)
+}
+
+// AddSchemas save schema in KV
+func (r *Registrator) AddSchemas(microServiceID, schemaName, schemaInfo string) error {
+	return r.s.saveSchema(microServiceID, schemaName, schemaInfo)
+}
+
+// Close is noop
+func (r *Registrator) Close() error {
+	return nil
+}
+
+// ServiceDiscovery discovers instances from consul catalog
+type ServiceDiscovery struct {
+	Name string
+	s    *store
+	wait time.Duration
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	watchers map[string]context.CancelFunc
+	cache    registry.CacheIndex
+}
+
+func (d *ServiceDiscovery) index() registry.CacheIndex {
+	return registry.IndexOrDefault(d.cache)
+}
+
+// GetMicroService read micro service from KV
+func (d *ServiceDiscovery) GetMicroService(microServiceID string) (*registry.MicroService, error) {
+	return d.s.service(microServiceID)
+}
+
+// FindMicroServiceInstances find passing instances by service name and tags
+func (d *ServiceDiscovery) FindMicroServiceInstances(consumerID, microServiceName string, tags utiltags.Tags) ([]*registry.MicroServiceInstance, error) {
+	tags = wrapTags(tags)
+	instances, ok := d.index().Get(microServiceName, tags.KV)
+	if !ok || instances == nil {
+		entries, _, err := d.s.c.HealthService(context.Background(), microServiceName, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		d.setInstances(microServiceName, entries)
+		instances, ok = d.index().Get(microServiceName, tags.KV)
+		if !ok || instances == nil {
+			openlogging.GetLogger().Debugf("find no micro service instances for %s from consul", microServiceName)
+			return nil, nil
+		}
+	}
+	return instances, nil
+}
+
+// AutoSync watches catalog services, and watches instances of each service
+func (d *ServiceDiscovery) AutoSync() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.watchServices(ctx)
+}
+
+// Close stop all watches
+func (d *ServiceDiscovery) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+	d.watchers = make(map[string]context.CancelFunc)
+	return nil
+}
+
+func (d *ServiceDiscovery) watchServices(ctx context.Context) {
+	var index uint64
+	retry := newBackOff()
+	for ctx.Err() == nil {
+		services, newIndex, err := d.s.c.Services(ctx, index, d.wait)
+		if err != nil {
+			if ctx.Err() == nil {
+				openlogging.GetLogger().Errorf("watch consul services failed: %s", err)
+				retry.wait(ctx)
+			}
+			continue
+		}
+		retry.reset()
+		index = resetIndex(index, newIndex)
+		d.syncWatchers(ctx, services)
+	}
+}
+
+//syncWatchers starts watchers for new services, and stops watchers of services which are removed
+func (d *ServiceDiscovery) syncWatchers(ctx context.Context, services map[string][]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name, cancel := range d.watchers {
+		if _, ok := services[name]; !ok {
+			cancel()
+			delete(d.watchers, name)
+			d.index().Delete(name)
+			openlogging.GetLogger().Infof("Delete the service [%s] in the cache", name)
+		}
+	}
+	for name := range services {
+		if _, ok := d.watchers[name]; ok {
+			continue
+		}
+		wctx, cancel := context.WithCancel(ctx)
+		d.watchers[name] = cancel
+		go d.watchService(wctx, name)
+	}
+}
+
+func (d *ServiceDiscovery) watchService(ctx context.Context, name string) {
+	var index uint64
+	retry := newBackOff()
+	for ctx.Err() == nil {
+		entries, newIndex, err := d.s.c.HealthService(ctx, name, index, d.wait)
+		if err != nil {
+			if ctx.Err() == nil {
+				openlogging.GetLogger().Errorf("watch consul service [%s] failed: %s", name, err)
+				retry.wait(ctx)
+			}
+			continue
+		}
+		retry.reset()
+		if newIndex != index && ctx.Err() == nil {
+			d.setInstances(name, entries)
+		}
+		index = resetIndex(index, newIndex)
+	}
+}
+
+func (d *ServiceDiscovery) setInstances(name string, entries []*ServiceEntry) {
+	instances := make([]*registry.MicroServiceInstance, 0, len(entries))
+	for _, e := range entries {
+		if e.Service == nil {
+			continue
+		}
+		instances = append(instances, ToMicroServiceInstance(e))
+	}
+	if len(instances) == 0 {
+		d.index().Delete(name)
+		return
+	}
+	d.index().Set(name, instances)
+	openlogging.GetLogger().Debugf("Cached [%d] Instances of service [%s]", len(instances), name)
+}
+
+//resetIndex follows consul suggestion, index must be reset if it goes backwards
+func resetIndex(old, new uint64) uint64 {
+	if new < old {
+		return 0
+	}
+	return new
+}
+
+// ContractDiscovery discovers schemas from consul KV
+type ContractDiscovery struct {
+	Name string
+	s    *store
+}
+
+// GetMicroServicesByInterface return micro services which has schema of interface
+func (c *ContractDiscovery) GetMicroServicesByInterface(interfaceName string) []*registry.MicroService {
+	result := make([]*registry.MicroService, 0)
+	services, err := c.s.services()
+	if err != nil {
+		openlogging.GetLogger().Errorf("get micro services from consul failed: %s", err)
+		return result
+	}
+	for _, ms := range services {
+		if _, ok := c.schemaOf(ms.ServiceID, interfaceName); ok {
+			result = append(result, ms)
+		}
+	}
+	return result
+}
+
+// GetSchemaContentByInterface return schema of interface
+func (c *ContractDiscovery) GetSchemaContentByInterface(interfaceName string) registry.SchemaContent {
+	services, err := c.s.services()
+	if err != nil {
+		openlogging.GetLogger().Errorf("get micro services from consul failed: %s", err)
+		return registry.SchemaContent{}
+	}
+	for _, ms := range services {
+		if sc, ok := c.schemaOf(ms.ServiceID, interfaceName); ok {
+			return *sc
+		}
+	}
+	return registry.SchemaContent{}
+}
+
+// GetSchemaContentByServiceName return schemas of a micro service
+func (c *ContractDiscovery) GetSchemaContentByServiceName(svcName, version, appID, env string) []*registry.SchemaContent {
+	sid := ServiceID(&registry.MicroService{ServiceName: svcName, Version: version, AppID: appID})
+	schemas, err := c.s.schemas(sid)
+	if err != nil {
+		openlogging.GetLogger().Errorf("get schemas of [%s] from consul failed: %s", sid, err)
+		return nil
+	}
+	return schemas
+}
+
+func (c *ContractDiscovery) schemaOf(sid, interfaceName string) (*registry.SchemaContent, bool) {
+	schemas, err := c.s.schemas(sid)
+	if err != nil {
+		return nil, false
+	}
+	for _, sc := range schemas {
+		if sc.Info["x-java-interface"] == interfaceName {
+			return sc, true
+		}
+	}
+	return nil, false
+}
+
+// Close is noop
+func (c *ContractDiscovery) Close() error {
+	return nil
+}
+
+//wrapTags query latest version if version is not specified
+func wrapTags(t utiltags.Tags) utiltags.Tags {
+	if t.KV != nil {
+		if v, ok := t.KV[common.BuildinTagVersion]; !ok || v == "" {
+			t.KV[common.BuildinTagVersion] = common.LatestVersion
+			t.Label += "|" + common.BuildinLabelVersion
+		}
+	}
+	return t
+}
+
+type backOff struct {
+	d time.Duration
+}
+
+func newBackOff() *backOff {
+	return &backOff{d: time.Second}
+}
+
+func (b *backOff) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(b.d):
+	}
+	if b.d *= 2; b.d > 30*time.Second {
+		b.d = 30 * time.Second
+	}
+}
+
+func (b *backOff) reset() {
+	b.d = time.Second
+}
+
+//NewRegistrator new consul registrator
+func NewRegistrator(options registry.Options) registry.Registrator {
+	return &Registrator{
+		Name:      Name,
+		s:         newStore(options),
+		instances: make(map[string]*registry.MicroServiceInstance),
+	}
+}
+
+//NewServiceDiscovery new consul service discovery
+func NewServiceDiscovery(options registry.Options) registry.ServiceDiscovery {
+	wait, err := time.ParseDuration(archaius.GetString(WaitTimeKey, "30s"))
+	if err != nil {
+		wait = 30 * time.Second
+	}
+	return &ServiceDiscovery{
+		Name:     Name,
+		s:        newStore(options),
+		wait:     wait,
+		watchers: make(map[string]context.CancelFunc),
+		cache:    options.Index,
+	}
+}
+
+//NewContractDiscovery new consul contract discovery
+func NewContractDiscovery(options registry.Options) registry.ContractDiscovery {
+	return &ContractDiscovery{
+		Name: Name,
+		s:    newStore(options),
+	}
+}
+
+func init() {
+	registry.InstallRegistrator(Name, NewRegistrator)
+	registry.InstallServiceDiscovery(Name, NewServiceDiscovery)
+	registry.InstallContractDiscovery(Name, NewContractDiscovery)
+}
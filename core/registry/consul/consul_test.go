@@ -0,0 +1,343 @@
+package consul_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/core/registry/consul"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set(consul.WaitTimeKey, "1s")
+}
+
+//fakeConsul implements a small part of consul HTTP API
+type fakeConsul struct {
+	mu       sync.Mutex
+	index    uint64
+	changed  chan struct{}
+	services map[string]*consul.AgentServiceRegistration
+	passing  map[string]bool
+	maint    map[string]bool
+	kv       map[string][]byte
+}
+
+func newFakeConsul() *fakeConsul {
+	return &fakeConsul{
+		index:    1,
+		changed:  make(chan struct{}),
+		services: map[string]*consul.AgentServiceRegistration{},
+		passing:  map[string]bool{},
+		maint:    map[string]bool{},
+		kv:       map[string][]byte{},
+	}
+}
+
+//bump must be called with lock held
+func (f *fakeConsul) bump() {
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+//block waits until index changes, like consul blocking query
+func (f *fakeConsul) block(r *http.Request) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	f.mu.Lock()
+	if index == 0 || index < f.index {
+		f.mu.Unlock()
+		return
+	}
+	changed := f.changed
+	f.mu.Unlock()
+	wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+	select {
+	case <-changed:
+	case <-time.After(wait):
+	case <-r.Context().Done():
+	}
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Path
+	switch {
+	case p == "/v1/agent/service/register":
+		s := &consul.AgentServiceRegistration{}
+		json.NewDecoder(r.Body).Decode(s)
+		f.mu.Lock()
+		f.services[s.ID] = s
+		f.bump()
+		f.mu.Unlock()
+	case strings.HasPrefix(p, "/v1/agent/service/deregister/"):
+		id := strings.TrimPrefix(p, "/v1/agent/service/deregister/")
+		f.mu.Lock()
+		delete(f.services, id)
+		f.bump()
+		f.mu.Unlock()
+	case strings.HasPrefix(p, "/v1/agent/check/pass/"):
+		id := strings.TrimPrefix(p, "/v1/agent/check/pass/service:")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.services[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !f.passing[id] {
+			f.passing[id] = true
+			f.bump()
+		}
+	case strings.HasPrefix(p, "/v1/agent/service/maintenance/"):
+		id := strings.TrimPrefix(p, "/v1/agent/service/maintenance/")
+		f.mu.Lock()
+		f.maint[id] = r.URL.Query().Get("enable") == "true"
+		f.bump()
+		f.mu.Unlock()
+	case strings.HasPrefix(p, "/v1/health/service/"):
+		f.block(r)
+		name := strings.TrimPrefix(p, "/v1/health/service/")
+		f.mu.Lock()
+		entries := make([]*consul.ServiceEntry, 0)
+		for id, s := range f.services {
+			if s.Name != name || !f.passing[id] || f.maint[id] {
+				continue
+			}
+			entries = append(entries, &consul.ServiceEntry{
+				Node: &consul.Node{Node: "node1"},
+				Service: &consul.AgentService{
+					ID: s.ID, Service: s.Name, Tags: s.Tags, Address: s.Address, Port: s.Port, Meta: s.Meta,
+				},
+			})
+		}
+		f.write(w, entries)
+		f.mu.Unlock()
+	case p == "/v1/catalog/services":
+		f.block(r)
+		f.mu.Lock()
+		services := map[string][]string{}
+		for _, s := range f.services {
+			services[s.Name] = s.Tags
+		}
+		f.write(w, services)
+		f.mu.Unlock()
+	case strings.HasPrefix(p, "/v1/kv/"):
+		key := strings.TrimPrefix(p, "/v1/kv/")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.Method == http.MethodPut {
+			f.kv[key], _ = ioutil.ReadAll(r.Body)
+			return
+		}
+		if _, ok := r.URL.Query()["keys"]; ok {
+			keys := make([]string, 0)
+			for k := range f.kv {
+				if strings.HasPrefix(k, key) {
+					keys = append(keys, k)
+				}
+			}
+			if len(keys) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			sort.Strings(keys)
+			f.write(w, keys)
+			return
+		}
+		v, ok := f.kv[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(v)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+//write must be called with lock held
+func (f *fakeConsul) write(w http.ResponseWriter, v interface{}) {
+	w.Header().Set(consul.HeaderIndex, strconv.FormatUint(f.index, 10))
+	json.NewEncoder(w).Encode(v)
+}
+
+const schema = `
+swagger: "2.0"
+info:
+  version: 1.0.0
+  title: Server
+  x-java-interface: com.example.Hello
+paths:
+  /hello:
+    get:
+      operationId: sayHello
+`
+
+func TestConsul(t *testing.T) {
+	f := newFakeConsul()
+	s := httptest.NewServer(f)
+	defer s.Close()
+	//agent APIs go to the first agent
+	opts := registry.Options{Addrs: []string{strings.TrimPrefix(s.URL, "http://"), "127.0.0.1:1"}}
+
+	registry.EnableRegistryCache()
+	r := consul.NewRegistrator(opts)
+	sd := consul.NewServiceDiscovery(opts)
+	cd := consul.NewContractDiscovery(opts)
+	defer sd.Close()
+
+	ms := &registry.MicroService{ServiceName: "Server", AppID: "default", Version: "1.0.0"}
+	sid, iid, err := r.RegisterServiceAndInstance(ms, &registry.MicroServiceInstance{
+		InstanceID: "i1",
+		HostName:   "host1",
+		EndpointsMap: map[string]*registry.Endpoint{
+			common.ProtocolRest: {Address: "127.0.0.1:8080"},
+			"grpc":              {Address: "127.0.0.1:9090"},
+		},
+		Metadata:       map[string]string{"zone": "z1"},
+		DataCenterInfo: &registry.DataCenterInfo{Name: "dc", Region: "r1", AvailableZone: "az1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "default:Server:1.0.0", sid)
+	assert.Equal(t, "i1", iid)
+
+	t.Run("register", func(t *testing.T) {
+		reg := f.services[iid]
+		assert.Equal(t, "Server", reg.Name)
+		assert.Equal(t, []string{"grpc://127.0.0.1:9090", "rest://127.0.0.1:8080"}, reg.Tags)
+		assert.Equal(t, 8080, reg.Port)
+		assert.Equal(t, "service:i1", reg.Check.CheckID)
+		assert.Equal(t, "60s", reg.Check.TTL)
+		assert.True(t, f.passing[iid])
+
+		got, err := sd.GetMicroService(sid)
+		assert.NoError(t, err)
+		assert.Equal(t, "Server", got.ServiceName)
+		_, err = sd.GetMicroService("none")
+		assert.Equal(t, consul.ErrNotFound, err)
+	})
+	t.Run("find instances", func(t *testing.T) {
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.NewDefaultTag("1.0.0", "default"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ins))
+		assert.Equal(t, "i1", ins[0].InstanceID)
+		assert.Equal(t, "host1", ins[0].HostName)
+		assert.Equal(t, "127.0.0.1:8080", ins[0].EndpointsMap[common.ProtocolRest].Address)
+		assert.Equal(t, "127.0.0.1:9090", ins[0].EndpointsMap["grpc"].Address)
+		assert.Equal(t, common.ProtocolRest, ins[0].DefaultProtocol)
+		assert.Equal(t, "az1", ins[0].DataCenterInfo.AvailableZone)
+		assert.Equal(t, "z1", ins[0].Metadata["zone"])
+
+		ins, err = sd.FindMicroServiceInstances("", "Server", utiltags.NewDefaultTag("2.0.0", "default"))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(ins))
+	})
+	t.Run("heartbeat", func(t *testing.T) {
+		ok, err := r.Heartbeat(sid, iid)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = r.Heartbeat(sid, "none")
+		assert.Error(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("schemas", func(t *testing.T) {
+		assert.NoError(t, r.AddSchemas(sid, "hello", schema))
+		schemas := cd.GetSchemaContentByServiceName("Server", "1.0.0", "default", "")
+		assert.Equal(t, 1, len(schemas))
+		assert.Equal(t, "sayHello", schemas[0].Paths["/hello"]["get"].OperationID)
+		assert.Equal(t, "Server", cd.GetSchemaContentByInterface("com.example.Hello").Info["title"])
+		assert.Equal(t, 1, len(cd.GetMicroServicesByInterface("com.example.Hello")))
+		assert.Equal(t, 0, len(cd.GetMicroServicesByInterface("com.example.None")))
+	})
+	t.Run("update properties", func(t *testing.T) {
+		assert.NoError(t, r.UpdateMicroServiceProperties(sid, map[string]string{"owner": "team"}))
+		got, err := sd.GetMicroService(sid)
+		assert.NoError(t, err)
+		assert.Equal(t, "team", got.Metadata["owner"])
+
+		assert.NoError(t, r.UpdateMicroServiceInstanceProperties(sid, iid, map[string]string{"zone": "z2"}))
+		assert.Equal(t, "z2", f.services[iid].Meta["zone"])
+	})
+	t.Run("watch instances", func(t *testing.T) {
+		sd.AutoSync()
+		_, _, err := r.RegisterServiceAndInstance(ms, &registry.MicroServiceInstance{
+			InstanceID:   "i2",
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {Address: "127.0.0.1:8081"}},
+		})
+		assert.NoError(t, err)
+		waitInstances(t, "Server", 2)
+
+		assert.NoError(t, r.UpdateMicroServiceInstanceStatus(sid, "i2", runtime.StatusDown))
+		waitInstances(t, "Server", 1)
+		assert.NoError(t, r.UpdateMicroServiceInstanceStatus(sid, "i2", common.DefaultStatus))
+		waitInstances(t, "Server", 2)
+
+		assert.NoError(t, r.UnRegisterMicroServiceInstance(sid, "i2"))
+		assert.NoError(t, r.UnRegisterMicroServiceInstance(sid, iid))
+		waitInstances(t, "Server", 0)
+	})
+}
+
+func waitInstances(t *testing.T, name string, n int) {
+	var ins []*registry.MicroServiceInstance
+	for i := 0; i < 50; i++ {
+		ins, _ = registry.MicroserviceInstanceIndex.Get(name, nil)
+		if len(ins) == n {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, n, len(ins))
+}
+
+func TestClient(t *testing.T) {
+	f := newFakeConsul()
+	s := httptest.NewServer(f)
+	defer s.Close()
+	addr := strings.TrimPrefix(s.URL, "http://")
+
+	t.Run("catalog and kv fail over, agent does not", func(t *testing.T) {
+		c := consul.NewClient(registry.Options{Addrs: []string{"127.0.0.1:1", addr}}, "")
+		assert.NoError(t, c.PutKV("k", []byte("v")))
+		v, err := c.GetKV("k")
+		assert.NoError(t, err)
+		assert.Equal(t, "v", string(v))
+		assert.Error(t, c.Register(&consul.AgentServiceRegistration{ID: "i1", Name: "Server"}))
+		assert.Empty(t, f.services)
+	})
+	t.Run("request times out if agent hangs", func(t *testing.T) {
+		done := make(chan struct{})
+		defer close(done)
+		hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-done:
+			case <-r.Context().Done():
+			}
+		}))
+		defer hung.Close()
+		c := consul.NewClient(registry.Options{Addrs: []string{strings.TrimPrefix(hung.URL, "http://")},
+			Timeout: 100 * time.Millisecond}, "")
+		start := time.Now()
+		assert.Error(t, c.PassTTL("service:i1"))
+		_, err := c.GetKV("k")
+		assert.Error(t, err)
+		assert.True(t, time.Since(start) < time.Second)
+	})
+}
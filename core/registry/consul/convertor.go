@@ -0,0 +1,148 @@
+package consul
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+//reserved meta keys, they are not part of instance metadata
+const (
+	MetaServiceID     = "serviceID"
+	MetaHostName      = "hostName"
+	MetaEnvironment   = "environment"
+	MetaDataCenter    = "dataCenter"
+	MetaRegion        = "region"
+	MetaAvailableZone = "availableZone"
+)
+
+var metaKey = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+
+//ServiceID generate micro service id by app, name and version
+func ServiceID(ms *registry.MicroService) string {
+	app := ms.AppID
+	if app == "" {
+		app = common.DefaultApp
+	}
+	version := ms.Version
+	if version == "" {
+		version = common.DefaultVersion
+	}
+	return strings.Join([]string{app, ms.ServiceName, version}, ":")
+}
+
+//ToRegistration convert instance to consul service registration,
+//all endpoints are saved in tags in format of protocol://host:port, address and port is the default endpoint
+func ToRegistration(ms *registry.MicroService, ins *registry.MicroServiceInstance, check *AgentServiceCheck) *AgentServiceRegistration {
+	r := &AgentServiceRegistration{
+		ID:    ins.InstanceID,
+		Name:  ms.ServiceName,
+		Tags:  registry.GetProtocolList(ins.EndpointsMap),
+		Meta:  make(map[string]string, len(ins.Metadata)+6),
+		Check: check,
+	}
+	sort.Strings(r.Tags)
+	for k, v := range ins.Metadata {
+		if metaKey.MatchString(k) {
+			r.Meta[k] = v
+		}
+	}
+	r.Meta[common.BuildinTagVersion] = ms.Version
+	r.Meta[common.BuildinTagApp] = ms.AppID
+	r.Meta[MetaServiceID] = ms.ServiceID
+	r.Meta[MetaHostName] = ins.HostName
+	if ms.Environment != "" {
+		r.Meta[MetaEnvironment] = ms.Environment
+	}
+	if dc := ins.DataCenterInfo; dc != nil {
+		r.Meta[MetaDataCenter] = dc.Name
+		r.Meta[MetaRegion] = dc.Region
+		r.Meta[MetaAvailableZone] = dc.AvailableZone
+	}
+	if ep := defaultEndpoint(ins); ep != nil {
+		host, port, err := net.SplitHostPort(ep.Address)
+		if err == nil {
+			r.Address = host
+			r.Port, _ = strconv.Atoi(port)
+		}
+	}
+	return r
+}
+
+func defaultEndpoint(ins *registry.MicroServiceInstance) *registry.Endpoint {
+	if ep, ok := ins.EndpointsMap[ins.DefaultProtocol]; ok {
+		return ep
+	}
+	if ep, ok := ins.EndpointsMap[common.ProtocolRest]; ok {
+		return ep
+	}
+	for _, ep := range ins.EndpointsMap {
+		return ep
+	}
+	return nil
+}
+
+//ToMicroServiceInstance convert consul health entry to instance
+func ToMicroServiceInstance(e *ServiceEntry) *registry.MicroServiceInstance {
+	s := e.Service
+	ins := &registry.MicroServiceInstance{
+		InstanceID:  s.ID,
+		ServiceName: s.Service,
+		ServiceID:   s.Meta[MetaServiceID],
+		HostName:    s.Meta[MetaHostName],
+		Version:     s.Meta[common.BuildinTagVersion],
+		App:         s.Meta[common.BuildinTagApp],
+		Status:      common.DefaultStatus,
+		Metadata:    make(map[string]string, len(s.Meta)),
+	}
+	for k, v := range s.Meta {
+		switch k {
+		case MetaServiceID, MetaHostName, MetaEnvironment, MetaDataCenter, MetaRegion, MetaAvailableZone:
+		default:
+			ins.Metadata[k] = v
+		}
+	}
+	if ins.Version == "" {
+		ins.Version = common.DefaultVersion
+		ins.Metadata[common.BuildinTagVersion] = ins.Version
+	}
+	if ins.App == "" {
+		ins.App = common.DefaultApp
+		ins.WithAppID(ins.App)
+	}
+	if ins.HostName == "" && e.Node != nil {
+		ins.HostName = e.Node.Node
+	}
+	if s.Meta[MetaRegion] != "" || s.Meta[MetaAvailableZone] != "" {
+		ins.DataCenterInfo = &registry.DataCenterInfo{
+			Name:          s.Meta[MetaDataCenter],
+			Region:        s.Meta[MetaRegion],
+			AvailableZone: s.Meta[MetaAvailableZone],
+		}
+	}
+	eps := make([]string, 0, len(s.Tags))
+	for _, t := range s.Tags {
+		if strings.Contains(t, "://") {
+			eps = append(eps, t)
+		}
+	}
+	if len(eps) == 0 && s.Address != "" {
+		//registered by other framework
+		eps = append(eps, common.ProtocolRest+"://"+net.JoinHostPort(s.Address, strconv.Itoa(s.Port)))
+	}
+	m, p := registry.GetProtocolMap(eps)
+	ins.EndpointsMap = m
+	if _, ok := m[common.ProtocolRest]; ok {
+		p = common.ProtocolRest
+	}
+	if len(m) != 0 {
+		ins.DefaultProtocol = p
+		ins.DefaultEndpoint = m[p].GenEndpoint()
+	}
+	return ins
+}
@@ -0,0 +1,90 @@
+package consul
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-mesh/openlogging"
+	"gopkg.in/yaml.v2"
+)
+
+//store saves micro services and schemas in consul KV,
+//because consul catalog only knows instances.
+//micro service is saved in {prefix}/services/{serviceID}, schema in {prefix}/schemas/{serviceID}/{schemaID}
+type store struct {
+	c      *Client
+	prefix string
+}
+
+func (s *store) serviceKey(sid string) string {
+	return path.Join(s.prefix, "services", sid)
+}
+
+func (s *store) schemaKey(sid, schemaID string) string {
+	return path.Join(s.prefix, "schemas", sid, schemaID)
+}
+
+func (s *store) service(sid string) (*registry.MicroService, error) {
+	b, err := s.c.GetKV(s.serviceKey(sid))
+	if err != nil {
+		return nil, err
+	}
+	ms := &registry.MicroService{}
+	if err := json.Unmarshal(b, ms); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+func (s *store) saveService(ms *registry.MicroService) error {
+	b, err := json.Marshal(ms)
+	if err != nil {
+		return err
+	}
+	return s.c.PutKV(s.serviceKey(ms.ServiceID), b)
+}
+
+func (s *store) services() ([]*registry.MicroService, error) {
+	keys, err := s.c.Keys(s.serviceKey("") + "/")
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*registry.MicroService, 0, len(keys))
+	for _, k := range keys {
+		ms, err := s.service(k[strings.LastIndex(k, "/")+1:])
+		if err != nil {
+			openlogging.GetLogger().Warnf("can not read micro service [%s]: %s", k, err)
+			continue
+		}
+		services = append(services, ms)
+	}
+	return services, nil
+}
+
+func (s *store) saveSchema(sid, schemaID, content string) error {
+	return s.c.PutKV(s.schemaKey(sid, schemaID), []byte(content))
+}
+
+func (s *store) schemas(sid string) ([]*registry.SchemaContent, error) {
+	keys, err := s.c.Keys(s.schemaKey(sid, "") + "/")
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]*registry.SchemaContent, 0, len(keys))
+	for _, k := range keys {
+		b, err := s.c.GetKV(k)
+		if err != nil {
+			openlogging.GetLogger().Warnf("can not read schema [%s]: %s", k, err)
+			continue
+		}
+		sc := &registry.SchemaContent{}
+		if err := yaml.Unmarshal(b, sc); err != nil {
+			openlogging.GetLogger().Warnf("invalid schema [%s]: %s", k, err)
+			continue
+		}
+		schemas = append(schemas, sc)
+	}
+	return schemas, nil
+}
@@ -24,7 +24,11 @@ func NewDiscovery(name string, opts Options) (ServiceDiscovery, error) {
 	if f == nil {
 		return nil, fmt.Errorf("no service discovery plugin: %s", name)
 	}
-	return f(opts), nil
+	sd := f(opts)
+	if sd == nil {
+		return nil, fmt.Errorf("service discovery plugin [%s] is not available", name)
+	}
+	return sd, nil
 }
 
 //InstallContractDiscovery install contract service client
@@ -0,0 +1,146 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"gopkg.in/yaml.v2"
+)
+
+//Definition is the content of registry file
+type Definition struct {
+	Services []*ServiceDefinition `yaml:"services"`
+}
+
+//ServiceDefinition describes a micro service and its instances
+type ServiceDefinition struct {
+	ServiceID   string                `yaml:"serviceID"`
+	ServiceName string                `yaml:"name"`
+	AppID       string                `yaml:"app"`
+	Version     string                `yaml:"version"`
+	Environment string                `yaml:"environment"`
+	Schemas     []string              `yaml:"schemas"`
+	Metadata    map[string]string     `yaml:"metadata"`
+	Instances   []*InstanceDefinition `yaml:"instances"`
+}
+
+//InstanceDefinition describes a micro service instance,
+//endpoints are in format of {protocol}://{host}:{port}, for example rest://127.0.0.1:8080?sslEnabled=true
+type InstanceDefinition struct {
+	InstanceID     string            `yaml:"instanceID"`
+	HostName       string            `yaml:"hostName"`
+	Status         string            `yaml:"status"`
+	Endpoints      []string          `yaml:"endpoints"`
+	Metadata       map[string]string `yaml:"metadata"`
+	DataCenterInfo *DataCenterInfo   `yaml:"dataCenterInfo"`
+}
+
+//DataCenterInfo describes where the instance is located
+type DataCenterInfo struct {
+	Name          string `yaml:"name"`
+	Region        string `yaml:"region"`
+	AvailableZone string `yaml:"availableZone"`
+}
+
+//ErrEmptyDefinition happens if registry file has no services key, it may be read while it is being written,
+//use "services: []" to define no service
+var ErrEmptyDefinition = errors.New("registry file is empty")
+
+//ReadDefinition read and parse registry file
+func ReadDefinition(path string) (*Definition, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(b)) == "" {
+		return nil, ErrEmptyDefinition
+	}
+	d := &Definition{}
+	if err := yaml.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("invalid registry file [%s]: %s", path, err)
+	}
+	if d.Services == nil {
+		return nil, ErrEmptyDefinition
+	}
+	return d, nil
+}
+
+//ToMicroService convert service definition to micro service
+func (s *ServiceDefinition) ToMicroService() *registry.MicroService {
+	return &registry.MicroService{
+		ServiceID:   s.ID(),
+		AppID:       s.app(),
+		ServiceName: s.ServiceName,
+		Version:     s.version(),
+		Environment: s.Environment,
+		Status:      common.DefaultStatus,
+		Schemas:     s.Schemas,
+		Metadata:    s.Metadata,
+	}
+}
+
+//ID return service id, if it is not defined, it is generated by app, name and version
+func (s *ServiceDefinition) ID() string {
+	if s.ServiceID != "" {
+		return s.ServiceID
+	}
+	return strings.Join([]string{s.app(), s.ServiceName, s.version()}, ":")
+}
+
+func (s *ServiceDefinition) app() string {
+	if s.AppID == "" {
+		return common.DefaultApp
+	}
+	return s.AppID
+}
+
+func (s *ServiceDefinition) version() string {
+	if s.Version == "" {
+		return common.DefaultVersion
+	}
+	return s.Version
+}
+
+//ToMicroServiceInstance convert instance definition to micro service instance,
+//app and version tags are set into metadata, so that instances can be filtered by tags
+func (s *ServiceDefinition) ToMicroServiceInstance(i *InstanceDefinition) *registry.MicroServiceInstance {
+	msi := &registry.MicroServiceInstance{
+		App:         s.app(),
+		ServiceName: s.ServiceName,
+		Version:     s.version(),
+		InstanceID:  i.InstanceID,
+		HostName:    i.HostName,
+		ServiceID:   s.ID(),
+		Status:      i.Status,
+		Metadata:    make(map[string]string, len(i.Metadata)+2),
+	}
+	if msi.InstanceID == "" {
+		msi.InstanceID = s.ID() + "/" + strings.Join(i.Endpoints, ",")
+	}
+	if msi.Status == "" {
+		msi.Status = common.DefaultStatus
+	}
+	for k, v := range i.Metadata {
+		msi.Metadata[k] = v
+	}
+	msi.Metadata[common.BuildinTagVersion] = msi.Version
+	msi.WithAppID(msi.App)
+	m, p := registry.GetProtocolMap(i.Endpoints)
+	msi.EndpointsMap = m
+	if len(m) != 0 {
+		msi.DefaultEndpoint = m[p].GenEndpoint()
+		msi.DefaultProtocol = p
+	}
+	if i.DataCenterInfo != nil {
+		msi.DataCenterInfo = &registry.DataCenterInfo{
+			Name:          i.DataCenterInfo.Name,
+			Region:        i.DataCenterInfo.Region,
+			AvailableZone: i.DataCenterInfo.AvailableZone,
+		}
+	}
+	return msi
+}
@@ -0,0 +1,307 @@
+//Package file is a registry plugin which reads micro services and instances from a local yaml file,
+//it is useful when you have no service center, for example in local development and test environment.
+//the file is reloaded when it changes
+package file
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/util/fileutil"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	//Name is the name of file registry plugin
+	Name = "file"
+	//PathKey is the config key of registry file path
+	PathKey = "cse.service.registry.file.path"
+	//DefaultFileName is the registry file name in conf dir
+	DefaultFileName = "registry.yaml"
+)
+
+//debounce is how long to wait for more events before reload,
+//a file may be truncated and written in several events
+const debounce = 200 * time.Millisecond
+
+//Path return the registry file path
+func Path() string {
+	p := archaius.GetString(PathKey, "")
+	if p == "" {
+		p = filepath.Join(fileutil.GetConfDir(), DefaultFileName)
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	return abs
+}
+
+// Registrator keeps registered micro services and instances in memory,
+// so that they can be discovered by file service discovery in same process
+type Registrator struct {
+	Name string
+	s    *store
+}
+
+// RegisterService register micro service
+func (r *Registrator) RegisterService(ms *registry.MicroService) (string, error) {
+	sid := r.s.register(ms)
+	openlogging.GetLogger().Infof("register service [%s] in file registry", sid)
+	return sid, nil
+}
+
+// RegisterServiceInstance register micro service instance
+func (r *Registrator) RegisterServiceInstance(sid string, instance *registry.MicroServiceInstance) (string, error) {
+	return r.s.registerInstance(sid, instance)
+}
+
+// RegisterServiceAndInstance register micro service and instance
+func (r *Registrator) RegisterServiceAndInstance(ms *registry.MicroService, instance *registry.MicroServiceInstance) (string, string, error) {
+	sid, err := r.RegisterService(ms)
+	if err != nil {
+		return "", "", err
+	}
+	iid, err := r.RegisterServiceInstance(sid, instance)
+	if err != nil {
+		return sid, "", err
+	}
+	return sid, iid, nil
+}
+
+// Heartbeat check the instance is still registered
+func (r *Registrator) Heartbeat(microServiceID, microServiceInstanceID string) (bool, error) {
+	if !r.s.hasInstance(microServiceID, microServiceInstanceID) {
+		return false, ErrNotFound
+	}
+	return true, nil
+}
+
+// UnRegisterMicroServiceInstance remove instance
+func (r *Registrator) UnRegisterMicroServiceInstance(microServiceID, microServiceInstanceID string) error {
+	return r.s.unregisterInstance(microServiceID, microServiceInstanceID)
+}
+
+// UpdateMicroServiceInstanceStatus update instance status
+func (r *Registrator) UpdateMicroServiceInstanceStatus(microServiceID, microServiceInstanceID, status string) error {
+	return r.s.updateInstance(microServiceID, microServiceInstanceID, func(ins *registry.MicroServiceInstance) {
+		ins.Status = status
+	})
+}
+
+// UpdateMicroServiceProperties update micro service metadata
+func (r *Registrator) UpdateMicroServiceProperties(microServiceID string, properties map[string]string) error {
+	return r.s.updateService(microServiceID, func(ms *registry.MicroService) {
+		if ms.Metadata == nil {
+			ms.Metadata = make(map[string]string, len(properties))
+		}
+		for k, v := range properties {
+			ms.Metadata[k] = v
+		}
+	})
+}
+
+// UpdateMicroServiceInstanceProperties update instance metadata
+func (r *Registrator) UpdateMicroServiceInstanceProperties(microServiceID, microServiceInstanceID string, properties map[string]string) error {
+	return r.s.updateInstance(microServiceID, microServiceInstanceID, func(ins *registry.MicroServiceInstance) {
+		for k, v := range properties {
+			ins.Metadata[k] = v
+		}
+	})
+}
+
+// AddSchemas save schema content
+func (r *Registrator) AddSchemas(microServiceID, schemaName, schemaInfo string) error {
+	r.s.addSchema(microServiceID, schemaName, schemaInfo)
+	return nil
+}
+
+// Close is noop
+func (r *Registrator) Close() error {
+	return nil
+}
+
+// ServiceDiscovery discovers micro service instances from registry file
+type ServiceDiscovery struct {
+	Name    string
+	path    string
+	s       *store
+	watcher *fsnotify.Watcher
+	mu      sync.Mutex
+	cache   registry.CacheIndex
+}
+
+func (d *ServiceDiscovery) index() registry.CacheIndex {
+	return registry.IndexOrDefault(d.cache)
+}
+
+// GetMicroService return micro service by service id
+func (d *ServiceDiscovery) GetMicroService(microServiceID string) (*registry.MicroService, error) {
+	ms, ok := d.s.service(microServiceID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return ms, nil
+}
+
+// FindMicroServiceInstances find instances by service name and tags
+func (d *ServiceDiscovery) FindMicroServiceInstances(consumerID, microServiceName string, tags utiltags.Tags) ([]*registry.MicroServiceInstance, error) {
+	tags = wrapTags(tags)
+	instances, ok := d.index().Get(microServiceName, tags.KV)
+	if !ok || instances == nil {
+		d.refreshService(microServiceName)
+		instances, ok = d.index().Get(microServiceName, tags.KV)
+		if !ok || instances == nil {
+			openlogging.GetLogger().Debugf("find no micro service instances for %s from file registry", microServiceName)
+			return nil, nil
+		}
+	}
+	return instances, nil
+}
+
+// AutoSync load registry file into cache, and reload it when file changes
+func (d *ServiceDiscovery) AutoSync() {
+	d.s.setOnChange(d.refreshCache)
+	if err := d.reload(); err != nil {
+		openlogging.Error("load registry file failed: " + err.Error())
+	}
+	if err := d.watch(); err != nil {
+		openlogging.Error("can not watch registry file: " + err.Error())
+	}
+}
+
+// Close stop watching registry file
+func (d *ServiceDiscovery) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.watcher == nil {
+		return nil
+	}
+	err := d.watcher.Close()
+	d.watcher = nil
+	return err
+}
+
+func (d *ServiceDiscovery) reload() error {
+	def, err := ReadDefinition(d.path)
+	if err != nil {
+		return err
+	}
+	d.s.load(def)
+	openlogging.GetLogger().Infof("loaded [%d] services from registry file %s", len(def.Services), d.path)
+	return nil
+}
+
+//watch the directory instead of file, because editors may replace file by renaming,
+//events are coalesced, file is reloaded after no event comes in debounce time
+func (d *ServiceDiscovery) watch() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := w.Add(filepath.Dir(d.path)); err != nil {
+		w.Close()
+		return err
+	}
+	d.mu.Lock()
+	d.watcher = w
+	d.mu.Unlock()
+	reload := func() {
+		if err := d.reload(); err != nil {
+			openlogging.Warn("reload registry file failed, keep using old data: " + err.Error())
+		}
+	}
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					if timer != nil {
+						timer.Stop()
+					}
+					return
+				}
+				if filepath.Clean(e.Name) != d.path || e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				openlogging.Debug("registry file changed: " + e.String())
+				if timer == nil {
+					timer = time.AfterFunc(debounce, reload)
+				} else {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				openlogging.Error("watch registry file error: " + err.Error())
+			}
+		}
+	}()
+	return nil
+}
+
+//refreshCache overwrite instance cache, services which no longer exist are removed
+func (d *ServiceDiscovery) refreshCache() {
+	names := d.s.serviceNames()
+	for old := range d.index().FullCache().Items() {
+		if !names.Has(old) {
+			d.index().Delete(old)
+			openlogging.GetLogger().Infof("Delete the service [%s] in the cache", old)
+		}
+	}
+	for _, name := range names.List() {
+		d.refreshService(name)
+	}
+}
+
+func (d *ServiceDiscovery) refreshService(name string) {
+	ups := d.s.instancesOf(name)
+	if len(ups) == 0 {
+		d.index().Delete(name)
+		return
+	}
+	d.index().Set(name, ups)
+	openlogging.GetLogger().Debugf("Cached [%d] Instances of service [%s]", len(ups), name)
+}
+
+//wrapTags query latest version if version is not specified
+func wrapTags(t utiltags.Tags) utiltags.Tags {
+	if t.KV != nil {
+		if v, ok := t.KV[common.BuildinTagVersion]; !ok || v == "" {
+			t.KV[common.BuildinTagVersion] = common.LatestVersion
+			t.Label += "|" + common.BuildinLabelVersion
+		}
+	}
+	return t
+}
+
+//NewRegistrator new file registrator
+func NewRegistrator(options registry.Options) registry.Registrator {
+	return &Registrator{
+		Name: Name,
+		s:    defaultStore,
+	}
+}
+
+//NewServiceDiscovery new file service discovery
+func NewServiceDiscovery(options registry.Options) registry.ServiceDiscovery {
+	return &ServiceDiscovery{
+		Name:  Name,
+		path:  Path(),
+		s:     defaultStore,
+		cache: options.Index,
+	}
+}
+
+func init() {
+	registry.InstallRegistrator(Name, NewRegistrator)
+	registry.InstallServiceDiscovery(Name, NewServiceDiscovery)
+}
@@ -0,0 +1,195 @@
+package file_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/core/registry/file"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/stretchr/testify/assert"
+)
+
+const content = `
+services:
+  - name: Server
+    app: default
+    version: 1.0.0
+    instances:
+      - instanceID: i1
+        endpoints:
+          - rest://127.0.0.1:8080
+        metadata:
+          zone: z1
+      - instanceID: i2
+        endpoints:
+          - rest://127.0.0.1:8081?sslEnabled=true
+        dataCenterInfo:
+          region: r1
+          availableZone: z2
+      - instanceID: i3
+        status: DOWN
+        endpoints:
+          - rest://127.0.0.1:8082
+  - name: Server
+    app: default
+    version: 2.0.0
+    instances:
+      - endpoints:
+          - rest://127.0.0.1:9090
+`
+
+const changed = `
+services:
+  - name: Server
+    app: default
+    version: 1.0.0
+    instances:
+      - instanceID: i1
+        endpoints:
+          - rest://127.0.0.1:8080
+`
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+	archaius.Init(archaius.WithMemorySource())
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "registry.yaml")
+	assert.NoError(t, ioutil.WriteFile(p, []byte(content), 0600))
+	archaius.Set(file.PathKey, p)
+
+	registry.EnableRegistryCache()
+	sd, err := registry.NewDiscovery(file.Name, registry.Options{})
+	assert.NoError(t, err)
+	sd.AutoSync()
+	defer sd.Close()
+
+	t.Run("find all instances", func(t *testing.T) {
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(ins))
+	})
+	t.Run("find instances by tags", func(t *testing.T) {
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.NewDefaultTag("1.0.0", "default"))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(ins))
+		ins, err = sd.FindMicroServiceInstances("", "Server", utiltags.Tags{KV: map[string]string{"app": "default"}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ins))
+		assert.Equal(t, "2.0.0", ins[0].Version)
+		ins, err = sd.FindMicroServiceInstances("", "Server", utiltags.Tags{KV: map[string]string{"zone": "z1", "version": "1.0.0"}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ins))
+		assert.Equal(t, "127.0.0.1:8080", ins[0].EndpointsMap[common.ProtocolRest].Address)
+	})
+	t.Run("get micro service", func(t *testing.T) {
+		ms, err := sd.GetMicroService("default:Server:1.0.0")
+		assert.NoError(t, err)
+		assert.Equal(t, "Server", ms.ServiceName)
+		_, err = sd.GetMicroService("none")
+		assert.Equal(t, file.ErrNotFound, err)
+	})
+	t.Run("reload after file changes", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(p, []byte(changed), 0600))
+		var ins []*registry.MicroServiceInstance
+		for i := 0; i < 50; i++ {
+			ins, _ = registry.MicroserviceInstanceIndex.Get("Server", nil)
+			if len(ins) == 1 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.Equal(t, 1, len(ins))
+	})
+	t.Run("keep old data if file is empty", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(p, []byte(""), 0600))
+		//wait for debounce and reload
+		time.Sleep(500 * time.Millisecond)
+		ins, _ := registry.MicroserviceInstanceIndex.Get("Server", nil)
+		assert.Equal(t, 1, len(ins))
+	})
+	t.Run("discover registered instances", func(t *testing.T) {
+		r, err := registry.NewRegistrator(file.Name, registry.Options{})
+		assert.NoError(t, err)
+		sid, iid, err := r.RegisterServiceAndInstance(&registry.MicroService{
+			ServiceName: "Client",
+			AppID:       "default",
+			Version:     "0.1",
+		}, &registry.MicroServiceInstance{
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {Address: "127.0.0.1:5000"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "default:Client:0.1", sid)
+		ok, err := r.Heartbeat(sid, iid)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		ins, err := sd.FindMicroServiceInstances("", "Client", utiltags.NewDefaultTag("0.1", "default"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ins))
+
+		assert.NoError(t, r.UnRegisterMicroServiceInstance(sid, iid))
+		_, ok = registry.MicroserviceInstanceIndex.Get("Client", nil)
+		assert.False(t, ok)
+		ok, err = r.Heartbeat(sid, iid)
+		assert.Equal(t, file.ErrNotFound, err)
+		assert.False(t, ok)
+	})
+	t.Run("instance id does not change and update does not modify cached instance", func(t *testing.T) {
+		r, err := registry.NewRegistrator(file.Name, registry.Options{})
+		assert.NoError(t, err)
+		sid, iid, err := r.RegisterServiceAndInstance(&registry.MicroService{
+			ServiceName: "Client",
+			AppID:       "default",
+			Version:     "0.1",
+		}, &registry.MicroServiceInstance{
+			HostName: "host",
+			EndpointsMap: map[string]*registry.Endpoint{
+				common.ProtocolRest:    {Address: "127.0.0.1:5000"},
+				common.ProtocolHighway: {Address: "127.0.0.1:5001"},
+				common.ProtocolGRPC:    {Address: "127.0.0.1:5002"},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "default:Client:0.1/host/grpc://127.0.0.1:5002/highway://127.0.0.1:5001/rest://127.0.0.1:5000", iid)
+
+		cached, _ := registry.MicroserviceInstanceIndex.Get("Client", nil)
+		assert.NoError(t, r.UpdateMicroServiceInstanceProperties(sid, iid, map[string]string{"zone": "z1"}))
+		assert.Equal(t, "", cached[0].Metadata["zone"])
+		ins, _ := registry.MicroserviceInstanceIndex.Get("Client", nil)
+		assert.Equal(t, "z1", ins[0].Metadata["zone"])
+		assert.NoError(t, r.UnRegisterMicroServiceInstance(sid, iid))
+	})
+}
+
+func TestReadDefinition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "registry.yaml")
+	for _, c := range []string{"", " \n", "# no service\n"} {
+		assert.NoError(t, ioutil.WriteFile(p, []byte(c), 0600))
+		_, err := file.ReadDefinition(p)
+		assert.Equal(t, file.ErrEmptyDefinition, err)
+	}
+	assert.NoError(t, ioutil.WriteFile(p, []byte("services: ["), 0600))
+	_, err = file.ReadDefinition(p)
+	assert.Error(t, err)
+	assert.NoError(t, ioutil.WriteFile(p, []byte("services: []"), 0600))
+	d, err := file.ReadDefinition(p)
+	assert.NoError(t, err)
+	assert.Empty(t, d.Services)
+}
@@ -0,0 +1,247 @@
+package file
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+//ErrNotFound happens if micro service or instance is not in registry file or registered by registrator
+var ErrNotFound = errors.New("not found in file registry")
+
+//store saves micro services defined in registry file and registered by registrator,
+//file data is replaced as a whole after reload, registered data is kept
+type store struct {
+	mu sync.RWMutex
+	//key is service id
+	services map[string]*registry.MicroService
+	//key is service name
+	instances map[string][]*registry.MicroServiceInstance
+
+	//key is service id
+	registered map[string]*registry.MicroService
+	//key is service id, then instance id
+	registeredInstances map[string]map[string]*registry.MicroServiceInstance
+	schemas             map[string]string
+
+	onChange func()
+}
+
+var defaultStore = newStore()
+
+func newStore() *store {
+	return &store{
+		services:            make(map[string]*registry.MicroService),
+		instances:           make(map[string][]*registry.MicroServiceInstance),
+		registered:          make(map[string]*registry.MicroService),
+		registeredInstances: make(map[string]map[string]*registry.MicroServiceInstance),
+		schemas:             make(map[string]string),
+	}
+}
+
+//load replace file data with new definition
+func (s *store) load(d *Definition) {
+	services := make(map[string]*registry.MicroService, len(d.Services))
+	instances := make(map[string][]*registry.MicroServiceInstance, len(d.Services))
+	for _, sd := range d.Services {
+		if sd == nil || sd.ServiceName == "" {
+			continue
+		}
+		services[sd.ID()] = sd.ToMicroService()
+		for _, id := range sd.Instances {
+			if id == nil {
+				continue
+			}
+			instances[sd.ServiceName] = append(instances[sd.ServiceName], sd.ToMicroServiceInstance(id))
+		}
+	}
+	s.mu.Lock()
+	s.services = services
+	s.instances = instances
+	s.mu.Unlock()
+	s.changed()
+}
+
+func (s *store) changed() {
+	s.mu.RLock()
+	f := s.onChange
+	s.mu.RUnlock()
+	if f != nil {
+		f()
+	}
+}
+
+func (s *store) setOnChange(f func()) {
+	s.mu.Lock()
+	s.onChange = f
+	s.mu.Unlock()
+}
+
+func (s *store) service(sid string) (*registry.MicroService, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if ms, ok := s.registered[sid]; ok {
+		return ms, true
+	}
+	ms, ok := s.services[sid]
+	return ms, ok
+}
+
+//serviceNames return all service names which has instances
+func (s *store) serviceNames() sets.String {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := sets.NewString()
+	for name := range s.instances {
+		names.Insert(name)
+	}
+	for sid, m := range s.registeredInstances {
+		if ms, ok := s.registered[sid]; ok && len(m) != 0 {
+			names.Insert(ms.ServiceName)
+		}
+	}
+	return names
+}
+
+//instancesOf return available instances of a service
+func (s *store) instancesOf(name string) []*registry.MicroServiceInstance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	all := make([]*registry.MicroServiceInstance, 0, len(s.instances[name]))
+	all = append(all, s.instances[name]...)
+	for sid, m := range s.registeredInstances {
+		if ms, ok := s.registered[sid]; !ok || ms.ServiceName != name {
+			continue
+		}
+		for _, ins := range m {
+			all = append(all, ins)
+		}
+	}
+	result := make([]*registry.MicroServiceInstance, 0, len(all))
+	for _, ins := range all {
+		if ins.Status != common.DefaultStatus && ins.Status != common.TESTINGStatus {
+			continue
+		}
+		result = append(result, ins)
+	}
+	return result
+}
+
+func (s *store) register(ms *registry.MicroService) string {
+	s.mu.Lock()
+	if ms.ServiceID == "" {
+		ms.ServiceID = (&ServiceDefinition{ServiceName: ms.ServiceName, AppID: ms.AppID, Version: ms.Version}).ID()
+	}
+	s.registered[ms.ServiceID] = ms
+	s.mu.Unlock()
+	return ms.ServiceID
+}
+
+func (s *store) registerInstance(sid string, ins *registry.MicroServiceInstance) (string, error) {
+	s.mu.Lock()
+	ms, ok := s.registered[sid]
+	if !ok {
+		s.mu.Unlock()
+		return "", ErrNotFound
+	}
+	ins.ServiceID = sid
+	ins.ServiceName = ms.ServiceName
+	ins.App = ms.AppID
+	ins.Version = ms.Version
+	if ins.Metadata == nil {
+		ins.Metadata = make(map[string]string)
+	}
+	ins.Metadata[common.BuildinTagVersion] = ms.Version
+	ins.WithAppID(ms.AppID)
+	if ins.Status == "" {
+		ins.Status = common.DefaultStatus
+	}
+	if ins.InstanceID == "" {
+		ins.InstanceID = instanceID(sid, ins)
+	}
+	m, ok := s.registeredInstances[sid]
+	if !ok {
+		m = make(map[string]*registry.MicroServiceInstance)
+		s.registeredInstances[sid] = m
+	}
+	m[ins.InstanceID] = ins
+	s.mu.Unlock()
+	s.changed()
+	return ins.InstanceID, nil
+}
+
+//instanceID is the same for the same host and endpoints, so that it does not change after restart
+func instanceID(sid string, ins *registry.MicroServiceInstance) string {
+	protocols := make([]string, 0, len(ins.EndpointsMap))
+	for p := range ins.EndpointsMap {
+		protocols = append(protocols, p)
+	}
+	sort.Strings(protocols)
+	id := sid + "/" + ins.HostName
+	for _, p := range protocols {
+		id += "/" + p + "://" + ins.EndpointsMap[p].GenEndpoint()
+	}
+	return id
+}
+
+//updateInstance modify a copy of registered instance and replace it,
+//instances in cache may be read by load balancer, they are never modified
+func (s *store) updateInstance(sid, iid string, f func(*registry.MicroServiceInstance)) error {
+	s.mu.Lock()
+	ins, ok := s.registeredInstances[sid][iid]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	c := *ins
+	c.Metadata = make(map[string]string, len(ins.Metadata))
+	for k, v := range ins.Metadata {
+		c.Metadata[k] = v
+	}
+	f(&c)
+	s.registeredInstances[sid][iid] = &c
+	s.mu.Unlock()
+	s.changed()
+	return nil
+}
+
+func (s *store) unregisterInstance(sid, iid string) error {
+	s.mu.Lock()
+	if _, ok := s.registeredInstances[sid][iid]; !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	delete(s.registeredInstances[sid], iid)
+	s.mu.Unlock()
+	s.changed()
+	return nil
+}
+
+func (s *store) hasInstance(sid, iid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.registeredInstances[sid][iid]
+	return ok
+}
+
+func (s *store) addSchema(sid, name, content string) {
+	s.mu.Lock()
+	s.schemas[sid+"/"+name] = content
+	s.mu.Unlock()
+}
+
+func (s *store) updateService(sid string, f func(*registry.MicroService)) error {
+	s.mu.Lock()
+	ms, ok := s.registered[sid]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	f(ms)
+	s.mu.Unlock()
+	return nil
+}
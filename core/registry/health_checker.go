@@ -0,0 +1,411 @@
+package registry
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/pkg/metrics"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
+	"github.com/go-mesh/openlogging"
+)
+
+//HealthCheckPrefix is the config prefix of active health check,
+//cse.healthCheck.{key} is global config, cse.healthCheck.{service}.{key} overrides it
+const HealthCheckPrefix = "cse.healthCheck"
+
+//default value of active health check
+const (
+	DefaultHealthCheckInterval  = 10 * time.Second
+	DefaultHealthCheckTimeout   = time.Second
+	DefaultHealthyThreshold     = 2
+	DefaultUnhealthyThreshold   = 3
+	DefaultHealthCheckPath      = "/healthz"
+	healthCheckSchedulerTick    = time.Second
+	MetricInstanceHealthStatus  = "instance_health_status"
+	MetricInstanceHealthChecks  = "instance_health_check_total"
+	healthCheckResultSuccessful = "success"
+	healthCheckResultFailed     = "failure"
+)
+
+//HealthCheckConfig is active health check config of a service
+type HealthCheckConfig struct {
+	Enabled            bool
+	Interval           time.Duration
+	Timeout            time.Duration
+	HealthyThreshold   int
+	UnhealthyThreshold int
+	//Type is the probe name, tcp, http or grpc
+	Type string
+	//Path is http path of http probe, or service name of grpc probe which is empty by default
+	Path string
+	//Protocol decides which endpoint is checked, default is rest, or default endpoint of instance
+	Protocol string
+}
+
+func healthCheckKey(service, key string) string {
+	return strings.Join([]string{HealthCheckPrefix, service, key}, ".")
+}
+
+func healthCheckString(service, key, def string) string {
+	if v := archaius.GetString(healthCheckKey(service, key), ""); v != "" {
+		return v
+	}
+	return archaius.GetString(HealthCheckPrefix+"."+key, def)
+}
+
+func healthCheckInt(service, key string, def int) int {
+	if v := archaius.GetInt(healthCheckKey(service, key), 0); v > 0 {
+		return v
+	}
+	if v := archaius.GetInt(HealthCheckPrefix+"."+key, 0); v > 0 {
+		return v
+	}
+	return def
+}
+
+func healthCheckDuration(service, key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(healthCheckString(service, key, def.String()))
+	if err != nil || d <= 0 {
+		openlogging.GetLogger().Warnf("invalid health check %s of [%s], use %s", key, service, def)
+		return def
+	}
+	return d
+}
+
+//GetHealthCheckConfig return active health check config of a service
+func GetHealthCheckConfig(service string) HealthCheckConfig {
+	enabled := archaius.GetBool(HealthCheckPrefix+".enabled", false)
+	if v := archaius.GetString(healthCheckKey(service, "enabled"), ""); v != "" {
+		enabled = v == "true"
+	}
+	typ := healthCheckString(service, "type", ProbeTCP)
+	//empty service name means the overall health of grpc server
+	path := DefaultHealthCheckPath
+	if typ == ProbeGRPC {
+		path = ""
+	}
+	return HealthCheckConfig{
+		Enabled:            enabled,
+		Interval:           healthCheckDuration(service, "interval", DefaultHealthCheckInterval),
+		Timeout:            healthCheckDuration(service, "timeout", DefaultHealthCheckTimeout),
+		HealthyThreshold:   healthCheckInt(service, "healthyThreshold", DefaultHealthyThreshold),
+		UnhealthyThreshold: healthCheckInt(service, "unhealthyThreshold", DefaultUnhealthyThreshold),
+		Type:               typ,
+		Path:               healthCheckString(service, "path", path),
+		Protocol:           healthCheckString(service, "protocol", ""),
+	}
+}
+
+//HealthEvent is emitted when an instance is marked down or recovered
+type HealthEvent struct {
+	Service  string
+	Instance *MicroServiceInstance
+	Healthy  bool
+	//Err is the last probe error when instance is marked down
+	Err error
+}
+
+//HealthListener receives health events
+type HealthListener func(e *HealthEvent)
+
+var (
+	healthListeners   []HealthListener
+	healthListenersMu sync.RWMutex
+)
+
+//AddHealthListener add a listener of health events
+func AddHealthListener(l HealthListener) {
+	healthListenersMu.Lock()
+	healthListeners = append(healthListeners, l)
+	healthListenersMu.Unlock()
+}
+
+func emitHealthEvent(e *HealthEvent) {
+	healthListenersMu.RLock()
+	defer healthListenersMu.RUnlock()
+	for _, l := range healthListeners {
+		l(e)
+	}
+}
+
+type instanceHealth struct {
+	successes int
+	failures  int
+	down      bool
+}
+
+//ActiveHealthChecker probes instances in cache periodically, unhealthy instance is marked as DOWN in cache
+//instead of being deleted, and it is marked as UP again after it recovers
+type ActiveHealthChecker struct {
+	mu      sync.Mutex
+	next    map[string]time.Time
+	running map[string]bool
+	//key is service name, then instance id
+	states map[string]map[string]*instanceHealth
+	stopCh chan struct{}
+}
+
+//NewActiveHealthChecker create a health checker
+func NewActiveHealthChecker() *ActiveHealthChecker {
+	return &ActiveHealthChecker{
+		next:    make(map[string]time.Time),
+		running: make(map[string]bool),
+		states:  make(map[string]map[string]*instanceHealth),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+//Start schedules health checks of services in cache
+func (hc *ActiveHealthChecker) Start() {
+	createHealthMetrics()
+	go func() {
+		ticker := time.NewTicker(healthCheckSchedulerTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hc.stopCh:
+				return
+			case now := <-ticker.C:
+				hc.schedule(now)
+			}
+		}
+	}()
+}
+
+//Stop stops health checks
+func (hc *ActiveHealthChecker) Stop() {
+	close(hc.stopCh)
+}
+
+func (hc *ActiveHealthChecker) schedule(now time.Time) {
+	for service := range MicroserviceInstanceIndex.FullCache().Items() {
+		c := GetHealthCheckConfig(service)
+		if !c.Enabled {
+			continue
+		}
+		hc.mu.Lock()
+		due := !hc.running[service] && !now.Before(hc.next[service])
+		if due {
+			hc.running[service] = true
+			hc.next[service] = now.Add(c.Interval)
+		}
+		hc.mu.Unlock()
+		if !due {
+			//registry may refresh instances between check rounds
+			hc.mark(service)
+			continue
+		}
+		go func(service string) {
+			hc.Check(service, c)
+			hc.mu.Lock()
+			hc.running[service] = false
+			hc.mu.Unlock()
+		}(service)
+	}
+}
+
+//Check probes all instances of a service once, and updates their status in cache
+func (hc *ActiveHealthChecker) Check(service string, c HealthCheckConfig) {
+	instances, ok := MicroserviceInstanceIndex.Get(service, nil)
+	if !ok {
+		return
+	}
+	p, ok := probes[c.Type]
+	if !ok {
+		openlogging.GetLogger().Errorf("unknown health check type [%s] of [%s]", c.Type, service)
+		return
+	}
+	errs := make([]error, len(instances))
+	var wg sync.WaitGroup
+	for i, ins := range instances {
+		ep := probeEndpoint(ins, c.Protocol)
+		if ep == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ep *Endpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+			defer cancel()
+			errs[i] = p(ctx, service, ep, c)
+		}(i, ep)
+	}
+	wg.Wait()
+
+	hc.mu.Lock()
+	states, ok := hc.states[service]
+	if !ok {
+		states = make(map[string]*instanceHealth)
+		hc.states[service] = states
+	}
+	alive := make(map[string]struct{}, len(instances))
+	events := make([]*HealthEvent, 0)
+	for i, ins := range instances {
+		alive[ins.InstanceID] = struct{}{}
+		s, ok := states[ins.InstanceID]
+		if !ok {
+			s = &instanceHealth{}
+			states[ins.InstanceID] = s
+		}
+		if e := s.record(errs[i], c); e != nil {
+			e.Service, e.Instance = service, ins
+			events = append(events, e)
+		}
+		reportHealth(service, ins.InstanceID, errs[i], s.down)
+	}
+	for id := range states {
+		if _, ok := alive[id]; !ok {
+			delete(states, id)
+		}
+	}
+	hc.mu.Unlock()
+
+	hc.mark(service)
+	for _, e := range events {
+		if e.Healthy {
+			openlogging.GetLogger().Warnf("instance [%s] of [%s] recovered", e.Instance.InstanceID, service)
+		} else {
+			openlogging.GetLogger().Warnf("instance [%s] of [%s] is marked down: %s", e.Instance.InstanceID, service, e.Err)
+		}
+		emitHealthEvent(e)
+	}
+}
+
+//record updates counters by probe result, it returns event if health status changes
+func (s *instanceHealth) record(err error, c HealthCheckConfig) *HealthEvent {
+	if err == nil {
+		s.successes++
+		s.failures = 0
+		if s.down && s.successes >= c.HealthyThreshold {
+			s.down = false
+			return &HealthEvent{Healthy: true}
+		}
+		return nil
+	}
+	s.failures++
+	s.successes = 0
+	if !s.down && s.failures >= c.UnhealthyThreshold {
+		s.down = true
+		return &HealthEvent{Healthy: false, Err: err}
+	}
+	return nil
+}
+
+//mark sets status of instances in cache by health states,
+//instances are copied, because they are shared with readers of cache.
+//registry may refresh cache at any time, so instances are read again right before setting
+func (hc *ActiveHealthChecker) mark(service string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	states := hc.states[service]
+	if len(states) == 0 {
+		return
+	}
+	instances, ok := MicroserviceInstanceIndex.Get(service, nil)
+	if !ok {
+		return
+	}
+	var marked []*MicroServiceInstance
+	for i, ins := range instances {
+		s, ok := states[ins.InstanceID]
+		if !ok {
+			continue
+		}
+		status := ins.Status
+		switch {
+		case s.down && ins.Status != runtime.StatusDown:
+			status = runtime.StatusDown
+		case !s.down && ins.Status == runtime.StatusDown:
+			status = common.DefaultStatus
+		default:
+			continue
+		}
+		if marked == nil {
+			marked = make([]*MicroServiceInstance, len(instances))
+			copy(marked, instances)
+		}
+		c := *ins
+		c.Status = status
+		marked[i] = &c
+	}
+	if marked != nil {
+		before := cacheRevision(service)
+		MicroserviceInstanceIndex.Set(service, marked)
+		keepStale(service, before)
+	}
+}
+
+//probeEndpoint chooses endpoint by protocol, rest endpoint is preferred
+func probeEndpoint(ins *MicroServiceInstance, protocol string) *Endpoint {
+	for _, p := range []string{protocol, common.ProtocolRest, ins.DefaultProtocol} {
+		if ep, ok := ins.EndpointsMap[p]; ok && p != "" {
+			return ep
+		}
+	}
+	for _, ep := range ins.EndpointsMap {
+		return ep
+	}
+	return nil
+}
+
+//UpInstances return instances which are not marked down
+func UpInstances(instances []*MicroServiceInstance) []*MicroServiceInstance {
+	for i, ins := range instances {
+		if ins.Status != runtime.StatusDown {
+			continue
+		}
+		//copy only if there is a down instance
+		ups := make([]*MicroServiceInstance, i, len(instances))
+		copy(ups, instances[:i])
+		for _, ins := range instances[i+1:] {
+			if ins.Status != runtime.StatusDown {
+				ups = append(ups, ins)
+			}
+		}
+		return ups
+	}
+	return instances
+}
+
+func createHealthMetrics() {
+	if err := metrics.CreateGauge(metrics.GaugeOpts{
+		Name:   MetricInstanceHealthStatus,
+		Help:   "1 if instance is healthy, 0 if it is marked down by health check",
+		Labels: []string{"service", "instance"},
+	}); err != nil {
+		openlogging.Warn(err.Error())
+	}
+	if err := metrics.CreateCounter(metrics.CounterOpts{
+		Name:   MetricInstanceHealthChecks,
+		Help:   "total health checks of instance",
+		Labels: []string{"service", "instance", "result"},
+	}); err != nil {
+		openlogging.Warn(err.Error())
+	}
+}
+
+func reportHealth(service, instance string, err error, down bool) {
+	result := healthCheckResultSuccessful
+	if err != nil {
+		result = healthCheckResultFailed
+	}
+	labels := map[string]string{"service": service, "instance": instance}
+	status := 1.0
+	if down {
+		status = 0
+	}
+	if e := metrics.GaugeSet(MetricInstanceHealthStatus, status, labels); e != nil {
+		openlogging.GetLogger().Debugf("report health status failed: %s", e)
+	}
+	labels["result"] = result
+	if e := metrics.CounterAdd(MetricInstanceHealthChecks, 1, labels); e != nil {
+		openlogging.GetLogger().Debugf("report health check failed: %s", e)
+	}
+}
+
+//DefaultActiveHealthChecker is started when registry is enabled
+var DefaultActiveHealthChecker = NewActiveHealthChecker()
@@ -0,0 +1,212 @@
+package registry_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/config/model"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/metrics"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGetHealthCheckConfig(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.healthCheck.enabled", true)
+	archaius.Set("cse.healthCheck.interval", "5s")
+	archaius.Set("cse.healthCheck.Server.type", "http")
+	archaius.Set("cse.healthCheck.Server.unhealthyThreshold", 1)
+	defer archaius.Delete("cse.healthCheck.enabled")
+
+	c := registry.GetHealthCheckConfig("Server")
+	assert.True(t, c.Enabled)
+	assert.Equal(t, 5*time.Second, c.Interval)
+	assert.Equal(t, registry.DefaultHealthCheckTimeout, c.Timeout)
+	assert.Equal(t, registry.ProbeHTTP, c.Type)
+	assert.Equal(t, 1, c.UnhealthyThreshold)
+	assert.Equal(t, registry.DefaultHealthyThreshold, c.HealthyThreshold)
+	assert.Equal(t, registry.DefaultHealthCheckPath, c.Path)
+	assert.Equal(t, registry.ProbeTCP, registry.GetHealthCheckConfig("Other").Type)
+
+	archaius.Set("cse.healthCheck.GrpcServer.type", "grpc")
+	assert.Equal(t, "", registry.GetHealthCheckConfig("GrpcServer").Path)
+}
+
+func TestActiveHealthChecker_Check(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	metrics.Init()
+	healthy := true
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy || r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closed := l.Addr().String()
+	l.Close()
+
+	registry.EnableRegistryCache()
+	registry.MicroserviceInstanceIndex.Set("HealthServer", []*registry.MicroServiceInstance{
+		{InstanceID: "1", Status: common.DefaultStatus,
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {Address: strings.TrimPrefix(s.URL, "http://")}}},
+		{InstanceID: "2", Status: common.DefaultStatus,
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {Address: closed}}},
+	})
+	events := make([]*registry.HealthEvent, 0)
+	registry.AddHealthListener(func(e *registry.HealthEvent) {
+		events = append(events, e)
+	})
+	c := registry.HealthCheckConfig{
+		Enabled:            true,
+		Timeout:            time.Second,
+		HealthyThreshold:   1,
+		UnhealthyThreshold: 2,
+		Type:               registry.ProbeHTTP,
+		Path:               "/health",
+	}
+	status := func() []string {
+		instances, _ := registry.MicroserviceInstanceIndex.Get("HealthServer", nil)
+		return []string{instances[0].Status, instances[1].Status}
+	}
+	hc := registry.NewActiveHealthChecker()
+
+	t.Run("mark down after unhealthy threshold", func(t *testing.T) {
+		hc.Check("HealthServer", c)
+		assert.Equal(t, []string{common.DefaultStatus, common.DefaultStatus}, status())
+		hc.Check("HealthServer", c)
+		assert.Equal(t, []string{common.DefaultStatus, runtime.StatusDown}, status())
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, "2", events[0].Instance.InstanceID)
+		assert.False(t, events[0].Healthy)
+		assert.Error(t, events[0].Err)
+
+		instances, _ := registry.MicroserviceInstanceIndex.Get("HealthServer", nil)
+		ups := registry.UpInstances(instances)
+		assert.Equal(t, 1, len(ups))
+		assert.Equal(t, "1", ups[0].InstanceID)
+	})
+	t.Run("recover after healthy threshold", func(t *testing.T) {
+		healthy = false
+		hc.Check("HealthServer", c)
+		hc.Check("HealthServer", c)
+		assert.Equal(t, []string{runtime.StatusDown, runtime.StatusDown}, status())
+		healthy = true
+		hc.Check("HealthServer", c)
+		assert.Equal(t, []string{common.DefaultStatus, runtime.StatusDown}, status())
+		assert.True(t, events[len(events)-1].Healthy)
+	})
+	t.Run("tcp probe", func(t *testing.T) {
+		c.Type = registry.ProbeTCP
+		hc.Check("HealthServer", c)
+		assert.Equal(t, []string{common.DefaultStatus, runtime.StatusDown}, status())
+	})
+}
+
+func TestActiveHealthChecker_HTTPSProbe(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	if config.GlobalDefinition == nil {
+		config.GlobalDefinition = &model.GlobalCfg{}
+	}
+	config.GlobalDefinition.Ssl = map[string]string{"HealthTLSServer.Consumer.verifyPeer": "false"}
+	defer func() { config.GlobalDefinition.Ssl = nil }()
+	var conns int32
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	registry.EnableRegistryCache()
+	registry.MicroserviceInstanceIndex.Set("HealthTLSServer", []*registry.MicroServiceInstance{
+		{InstanceID: "1", Status: common.DefaultStatus,
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {
+				Address: strings.TrimPrefix(s.URL, "https://"), SSLEnabled: true}}},
+	})
+	c := registry.HealthCheckConfig{
+		Enabled:            true,
+		Timeout:            time.Second,
+		HealthyThreshold:   1,
+		UnhealthyThreshold: 1,
+		Type:               registry.ProbeHTTP,
+		Path:               "/health",
+	}
+	hc := registry.NewActiveHealthChecker()
+	for i := 0; i < 3; i++ {
+		hc.Check("HealthTLSServer", c)
+		instances, _ := registry.MicroserviceInstanceIndex.Get("HealthTLSServer", nil)
+		assert.Equal(t, common.DefaultStatus, instances[0].Status)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&conns), "connection is reused by probes")
+}
+
+func TestActiveHealthChecker_GRPCProbe(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.healthCheck.GrpcHealthServer.type", "grpc")
+	archaius.Set("cse.healthCheck.GrpcHealthServer.protocol", common.ProtocolGRPC)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	hs := health.NewServer()
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(l)
+	defer s.Stop()
+
+	registry.EnableRegistryCache()
+	registry.MicroserviceInstanceIndex.Set("GrpcHealthServer", []*registry.MicroServiceInstance{
+		{InstanceID: "1", Status: common.DefaultStatus,
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolGRPC: {Address: l.Addr().String()}}},
+	})
+	status := func() string {
+		instances, _ := registry.MicroserviceInstanceIndex.Get("GrpcHealthServer", nil)
+		return instances[0].Status
+	}
+	c := registry.GetHealthCheckConfig("GrpcHealthServer")
+	c.Enabled = true
+	c.Timeout = time.Second
+	c.UnhealthyThreshold = 1
+	c.HealthyThreshold = 1
+	hc := registry.NewActiveHealthChecker()
+
+	t.Run("default service name checks the server", func(t *testing.T) {
+		hc.Check("GrpcHealthServer", c)
+		assert.Equal(t, common.DefaultStatus, status())
+	})
+	t.Run("mark down if server is not serving", func(t *testing.T) {
+		hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		hc.Check("GrpcHealthServer", c)
+		assert.Equal(t, runtime.StatusDown, status())
+		hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		hc.Check("GrpcHealthServer", c)
+		assert.Equal(t, common.DefaultStatus, status())
+	})
+	t.Run("unknown service is not healthy", func(t *testing.T) {
+		c.Path = "/healthz"
+		hc.Check("GrpcHealthServer", c)
+		assert.Equal(t, runtime.StatusDown, status())
+	})
+}
+
+func TestUpInstances(t *testing.T) {
+	instances := []*registry.MicroServiceInstance{{InstanceID: "1"}, {InstanceID: "2"}}
+	assert.Equal(t, instances, registry.UpInstances(instances))
+	instances[0].Status = runtime.StatusDown
+	assert.Equal(t, instances[1:], registry.UpInstances(instances))
+	assert.Equal(t, 2, len(instances))
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	chassisTLS "github.com/go-chassis/go-chassis/core/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// probe types
+const (
+	ProbeTCP  = "tcp"
+	ProbeHTTP = "http"
+	ProbeGRPC = "grpc"
+)
+
+//Probe checks whether an endpoint of service is healthy, it must return before ctx is done
+type Probe func(ctx context.Context, service string, ep *Endpoint, c HealthCheckConfig) error
+
+//probeClients keeps one https client for each service and protocol, so that connections are reused by probes
+var (
+	probeClients   = make(map[string]*http.Client)
+	probeClientsMu sync.Mutex
+)
+
+var probes = map[string]Probe{
+	ProbeTCP:  tcpProbe,
+	ProbeHTTP: httpProbe,
+	ProbeGRPC: grpcProbe,
+}
+
+//InstallProbe install a probe, it can be used by setting cse.healthCheck.type
+func InstallProbe(name string, p Probe) {
+	probes[name] = p
+}
+
+func tcpProbe(ctx context.Context, service string, ep *Endpoint, c HealthCheckConfig) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", ep.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+//httpProbe expects 2xx or 3xx status of GET path
+func httpProbe(ctx context.Context, service string, ep *Endpoint, c HealthCheckConfig) error {
+	scheme := "http"
+	client := http.DefaultClient
+	if ep.SSLEnabled {
+		scheme = "https"
+		client = probeClient(service, c.Protocol)
+	}
+	req, err := http.NewRequest(http.MethodGet, scheme+"://"+ep.Address+c.Path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("health check returns status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+//grpcProbe uses grpc health checking protocol, path is the service name in check request
+func grpcProbe(ctx context.Context, service string, ep *Endpoint, c HealthCheckConfig) error {
+	opt := grpc.WithInsecure()
+	if ep.SSLEnabled {
+		opt = grpc.WithTransportCredentials(credentials.NewTLS(probeTLSConfig(service, c.Protocol)))
+	}
+	conn, err := grpc.DialContext(ctx, ep.Address, opt, grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: c.Path})
+	if err != nil {
+		return err
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health check returns %s", resp.Status)
+	}
+	return nil
+}
+
+func probeClient(service, protocol string) *http.Client {
+	key := service + "|" + protocol
+	probeClientsMu.Lock()
+	defer probeClientsMu.Unlock()
+	client, ok := probeClients[key]
+	if !ok {
+		client = &http.Client{Transport: &http.Transport{TLSClientConfig: probeTLSConfig(service, protocol)}}
+		probeClients[key] = client
+	}
+	return client
+}
+
+func probeTLSConfig(service, protocol string) *tls.Config {
+	c, _, err := chassisTLS.GetTLSConfigByService(service, protocol, common.Consumer)
+	if err != nil {
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,100 @@
+package kube
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+//ToMicroService convert kubernetes service to micro service
+func ToMicroService(svc *v1.Service) *registry.MicroService {
+	ms := &registry.MicroService{
+		ServiceID:   svc.Name,
+		ServiceName: svc.Name,
+		AppID:       svc.Namespace,
+		Version:     svc.Labels[common.BuildinTagVersion],
+		Status:      common.DefaultStatus,
+		Metadata:    make(map[string]string, len(svc.Labels)),
+	}
+	if ms.Version == "" {
+		ms.Version = common.DefaultVersion
+	}
+	for k, v := range svc.Labels {
+		ms.Metadata[k] = v
+	}
+	return ms
+}
+
+//ToMicroServiceInstances convert kubernetes endpoints to micro service instances,
+//one ready address is one instance, instance metadata is pod labels.
+//port name should be in format of [protocol]-[suffix], an unnamed port is treated as rest
+func ToMicroServiceInstances(ep *v1.Endpoints, pods corelisters.PodNamespaceLister) ([]*registry.MicroServiceInstance, error) {
+	instances := make([]*registry.MicroServiceInstance, 0)
+	for _, subset := range ep.Subsets {
+		for _, addr := range subset.Addresses {
+			ins := &registry.MicroServiceInstance{
+				ServiceName:  ep.Name,
+				ServiceID:    ep.Name,
+				InstanceID:   addr.IP,
+				HostName:     addr.Hostname,
+				Status:       common.DefaultStatus,
+				EndpointsMap: make(map[string]*registry.Endpoint, len(subset.Ports)),
+				Metadata:     make(map[string]string),
+			}
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+				ins.InstanceID = string(addr.TargetRef.UID)
+				if ins.HostName == "" {
+					ins.HostName = addr.TargetRef.Name
+				}
+				if pods != nil {
+					pod, err := pods.Get(addr.TargetRef.Name)
+					if err != nil && !errors.IsNotFound(err) {
+						return nil, err
+					}
+					if pod != nil {
+						for k, v := range pod.Labels {
+							ins.Metadata[k] = v
+						}
+					}
+				}
+			}
+			if addr.NodeName != nil {
+				ins.Metadata[NodeNameKey] = *addr.NodeName
+			}
+			ins.Version = ins.Metadata[common.BuildinTagVersion]
+			if ins.Version == "" {
+				ins.Version = common.DefaultVersion
+				ins.Metadata[common.BuildinTagVersion] = ins.Version
+			}
+			ins.App = ins.Metadata[common.BuildinTagApp]
+			for _, port := range subset.Ports {
+				p := portProtocol(port.Name)
+				ins.EndpointsMap[p] = &registry.Endpoint{
+					Address: net.JoinHostPort(addr.IP, strconv.Itoa(int(port.Port))),
+				}
+				if ins.DefaultProtocol == "" || p == common.ProtocolRest {
+					ins.DefaultProtocol = p
+					ins.DefaultEndpoint = ins.EndpointsMap[p].GenEndpoint()
+				}
+			}
+			instances = append(instances, ins)
+		}
+	}
+	return instances, nil
+}
+
+func portProtocol(name string) string {
+	if name == "" {
+		return common.ProtocolRest
+	}
+	if i := strings.Index(name, "-"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
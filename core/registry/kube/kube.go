@@ -0,0 +1,332 @@
+//Package kube is a service discovery plugin which discovers micro service instances from kubernetes Endpoints,
+//pod labels are treated as instance metadata, so that route rules and tag filters work as same as service center.
+//instance cache is updated by watching Endpoints and Pods
+package kube
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/go-mesh/openlogging"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	//Name is the name of kube discovery plugin
+	Name = "kube"
+	//NamespaceKey is the config key of namespace to discover
+	NamespaceKey = "cse.service.registry.kube.namespace"
+	//DefaultNamespace is used if namespace is not configured
+	DefaultNamespace = "default"
+	//NodeNameKey is the metadata key of the node which pod runs on
+	NodeNameKey = "nodeName"
+)
+
+//ErrNoClient happens if kubernetes client is not initialized
+var ErrNoClient = errors.New("kubernetes client is not initialized")
+
+//ServiceDiscovery discovers instances from kubernetes Endpoints in one namespace
+type ServiceDiscovery struct {
+	Name      string
+	namespace string
+	client    kubernetes.Interface
+
+	factory   informers.SharedInformerFactory
+	services  corelisters.ServiceNamespaceLister
+	endpoints corelisters.EndpointsNamespaceLister
+	pods      corelisters.PodNamespaceLister
+
+	once   sync.Once
+	stopCh chan struct{}
+	cache  registry.CacheIndex
+}
+
+func (d *ServiceDiscovery) index() registry.CacheIndex {
+	return registry.IndexOrDefault(d.cache)
+}
+
+// GetMicroService return kubernetes service, micro service id is service name
+func (d *ServiceDiscovery) GetMicroService(microServiceID string) (*registry.MicroService, error) {
+	if d.client == nil {
+		return nil, ErrNoClient
+	}
+	if d.services != nil {
+		svc, err := d.services.Get(microServiceID)
+		if err == nil {
+			return ToMicroService(svc), nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	svc, err := d.client.CoreV1().Services(d.namespace).Get(microServiceID, getOptions)
+	if err != nil {
+		return nil, err
+	}
+	return ToMicroService(svc), nil
+}
+
+// FindMicroServiceInstances find instances by service name and pod labels
+func (d *ServiceDiscovery) FindMicroServiceInstances(consumerID, microServiceName string, tags utiltags.Tags) ([]*registry.MicroServiceInstance, error) {
+	instances, ok := d.index().Get(microServiceName, tags.KV)
+	if !ok || instances == nil {
+		if err := d.refresh(microServiceName); err != nil {
+			return nil, err
+		}
+		instances, ok = d.index().Get(microServiceName, tags.KV)
+		if !ok || instances == nil {
+			openlogging.GetLogger().Debugf("find no micro service instances for %s in namespace %s", microServiceName, d.namespace)
+			return nil, nil
+		}
+	}
+	return instances, nil
+}
+
+// AutoSync starts to watch Endpoints and Pods, instance cache is updated when they change
+func (d *ServiceDiscovery) AutoSync() {
+	if d.client == nil {
+		openlogging.Error(ErrNoClient.Error())
+		return
+	}
+	d.once.Do(func() {
+		d.factory = informers.NewSharedInformerFactoryWithOptions(d.client, resyncPeriod(), informers.WithNamespace(d.namespace))
+		core := d.factory.Core().V1()
+		d.services = core.Services().Lister().Services(d.namespace)
+		epInformer := core.Endpoints()
+		d.endpoints = epInformer.Lister().Endpoints(d.namespace)
+		podInformer := core.Pods()
+		d.pods = podInformer.Lister().Pods(d.namespace)
+
+		epInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: d.onEndpoints,
+			UpdateFunc: func(old, cur interface{}) {
+				d.onEndpoints(cur)
+			},
+			DeleteFunc: d.onEndpointsDelete,
+		})
+		podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    d.onPodAdd,
+			UpdateFunc: d.onPodUpdate,
+		})
+		d.factory.Start(d.stopCh)
+		for t, ok := range d.factory.WaitForCacheSync(d.stopCh) {
+			if !ok {
+				openlogging.GetLogger().Errorf("can not sync %s from kubernetes", t.String())
+			}
+		}
+		d.syncAll()
+	})
+}
+
+// Close stop watching
+func (d *ServiceDiscovery) Close() error {
+	select {
+	case <-d.stopCh:
+	default:
+		close(d.stopCh)
+	}
+	return nil
+}
+
+//syncAll refresh instance cache for all endpoints
+func (d *ServiceDiscovery) syncAll() {
+	eps, err := d.endpoints.List(everything)
+	if err != nil {
+		openlogging.Error("list endpoints failed: " + err.Error())
+		return
+	}
+	for _, ep := range eps {
+		d.setInstances(ep)
+	}
+}
+
+//refresh fetch endpoints of a service and set into cache
+func (d *ServiceDiscovery) refresh(name string) error {
+	if d.client == nil {
+		return ErrNoClient
+	}
+	var ep *v1.Endpoints
+	var err error
+	if d.endpoints != nil {
+		ep, err = d.endpoints.Get(name)
+	} else {
+		ep, err = d.client.CoreV1().Endpoints(d.namespace).Get(name, getOptions)
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	d.setInstances(ep)
+	return nil
+}
+
+func (d *ServiceDiscovery) setInstances(ep *v1.Endpoints) {
+	var pods corelisters.PodNamespaceLister
+	if d.pods != nil {
+		pods = d.pods
+	} else {
+		pods = &podGetter{client: d.client, namespace: d.namespace}
+	}
+	instances, err := ToMicroServiceInstances(ep, pods)
+	if err != nil {
+		openlogging.GetLogger().Errorf("convert endpoints [%s] failed: %s", ep.Name, err)
+		return
+	}
+	if len(instances) == 0 {
+		d.index().Delete(ep.Name)
+		openlogging.GetLogger().Debugf("service [%s] has no ready address", ep.Name)
+		return
+	}
+	d.index().Set(ep.Name, instances)
+	openlogging.GetLogger().Debugf("Cached [%d] Instances of service [%s]", len(instances), ep.Name)
+}
+
+func (d *ServiceDiscovery) onEndpoints(obj interface{}) {
+	ep, ok := obj.(*v1.Endpoints)
+	if !ok {
+		return
+	}
+	d.setInstances(ep)
+}
+
+func (d *ServiceDiscovery) onEndpointsDelete(obj interface{}) {
+	ep, ok := obj.(*v1.Endpoints)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		if ep, ok = tombstone.Obj.(*v1.Endpoints); !ok {
+			return
+		}
+	}
+	d.index().Delete(ep.Name)
+	openlogging.GetLogger().Infof("Delete the service [%s] in the cache", ep.Name)
+}
+
+//onPodAdd refresh endpoints which contains the pod,
+//because endpoints may be updated before the pod is watched
+func (d *ServiceDiscovery) onPodAdd(obj interface{}) {
+	if pod, ok := obj.(*v1.Pod); ok {
+		d.refreshPod(pod.Name)
+	}
+}
+
+//onPodUpdate refresh endpoints which contains the pod if pod labels changes
+func (d *ServiceDiscovery) onPodUpdate(old, cur interface{}) {
+	oldPod, ok := old.(*v1.Pod)
+	if !ok {
+		return
+	}
+	pod, ok := cur.(*v1.Pod)
+	if !ok || labelsEqual(oldPod.Labels, pod.Labels) {
+		return
+	}
+	d.refreshPod(pod.Name)
+}
+
+func (d *ServiceDiscovery) refreshPod(name string) {
+	eps, err := d.endpoints.List(everything)
+	if err != nil {
+		openlogging.Error("list endpoints failed: " + err.Error())
+		return
+	}
+	for _, ep := range eps {
+		if hasPod(ep, name) {
+			d.setInstances(ep)
+		}
+	}
+}
+
+func hasPod(ep *v1.Endpoints, name string) bool {
+	for _, subset := range ep.Subsets {
+		for _, addr := range subset.Addresses {
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" && addr.TargetRef.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func resyncPeriod() time.Duration {
+	d, err := time.ParseDuration(archaius.GetString("cse.service.registry.kube.resyncPeriod", "5m"))
+	if err != nil {
+		return 5 * time.Minute
+	}
+	return d
+}
+
+//NewClient create kubernetes client from kubeconfig file,
+//in cluster config is used if file does not exist
+func NewClient(configPath string) (kubernetes.Interface, error) {
+	var c *rest.Config
+	var err error
+	if _, statErr := os.Stat(configPath); configPath != "" && statErr == nil {
+		c, err = clientcmd.BuildConfigFromFlags("", configPath)
+	} else {
+		c, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, err
+	}
+	cs, err := kubernetes.NewForConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+//NewWithClient new kube service discovery with kubernetes client
+func NewWithClient(client kubernetes.Interface, namespace string) *ServiceDiscovery {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return &ServiceDiscovery{
+		Name:      Name,
+		namespace: namespace,
+		client:    client,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+//NewServiceDiscovery new kube service discovery
+func NewServiceDiscovery(options registry.Options) registry.ServiceDiscovery {
+	c, err := NewClient(options.ConfigPath)
+	if err != nil {
+		openlogging.GetLogger().Errorf("kubernetes client initialization failed: %s", err)
+	}
+	d := NewWithClient(c, archaius.GetString(NamespaceKey, DefaultNamespace))
+	d.cache = options.Index
+	return d
+}
+
+func init() {
+	registry.InstallServiceDiscovery(Name, NewServiceDiscovery)
+}
@@ -0,0 +1,132 @@
+package kube_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/core/registry/kube"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+	archaius.Init(archaius.WithMemorySource())
+	registry.EnableRegistryCache()
+}
+
+func pod(name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name), Labels: labels}}
+}
+
+func address(ip, podName string) v1.EndpointAddress {
+	return v1.EndpointAddress{IP: ip, TargetRef: &v1.ObjectReference{Kind: "Pod", Name: podName, UID: types.UID("uid-" + podName)}}
+}
+
+func endpoints(addresses ...v1.EndpointAddress) *v1.Endpoints {
+	return &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "Server", Namespace: "default"},
+		Subsets: []v1.EndpointSubset{{
+			Addresses: addresses,
+			Ports: []v1.EndpointPort{
+				{Name: "rest-http", Port: 8080},
+				{Name: "grpc", Port: 9090},
+			},
+		}},
+	}
+}
+
+func waitFor(f func() bool) bool {
+	for i := 0; i < 50; i++ {
+		if f() {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+func TestToMicroServiceInstances(t *testing.T) {
+	ins, err := kube.ToMicroServiceInstances(endpoints(v1.EndpointAddress{IP: "10.0.0.1"}), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ins))
+	assert.Equal(t, "10.0.0.1", ins[0].InstanceID)
+	assert.Equal(t, common.DefaultVersion, ins[0].Metadata[common.BuildinTagVersion])
+	assert.Equal(t, "10.0.0.1:8080", ins[0].EndpointsMap[common.ProtocolRest].Address)
+	assert.Equal(t, "10.0.0.1:9090", ins[0].EndpointsMap["grpc"].Address)
+	assert.Equal(t, common.ProtocolRest, ins[0].DefaultProtocol)
+}
+
+func TestServiceDiscovery(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "Server", Namespace: "default", Labels: map[string]string{"version": "1.0"}}},
+		pod("p1", map[string]string{"version": "1.0", "zone": "z1"}),
+		pod("p2", map[string]string{"version": "2.0"}),
+		endpoints(address("10.0.0.1", "p1"), address("10.0.0.2", "p2")),
+	)
+	sd := kube.NewWithClient(client, "")
+
+	t.Run("find before sync", func(t *testing.T) {
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{KV: map[string]string{"version": "2.0"}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ins))
+		assert.Equal(t, "uid-p2", ins[0].InstanceID)
+	})
+
+	sd.AutoSync()
+	defer sd.Close()
+
+	t.Run("get micro service", func(t *testing.T) {
+		ms, err := sd.GetMicroService("Server")
+		assert.NoError(t, err)
+		assert.Equal(t, "1.0", ms.Version)
+		_, err = sd.GetMicroService("none")
+		assert.Error(t, err)
+	})
+	t.Run("filter by pod labels", func(t *testing.T) {
+		ins, err := sd.FindMicroServiceInstances("", "Server", utiltags.Tags{KV: map[string]string{"zone": "z1"}})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ins))
+		assert.Equal(t, "p1", ins[0].HostName)
+		ins, err = sd.FindMicroServiceInstances("", "Server", utiltags.Tags{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(ins))
+	})
+	t.Run("endpoints update", func(t *testing.T) {
+		_, err := client.CoreV1().Pods("default").Create(pod("p3", map[string]string{"version": "3.0"}))
+		assert.NoError(t, err)
+		_, err = client.CoreV1().Endpoints("default").Update(endpoints(address("10.0.0.1", "p1"), address("10.0.0.3", "p3")))
+		assert.NoError(t, err)
+		assert.True(t, waitFor(func() bool {
+			ins, _ := registry.MicroserviceInstanceIndex.Get("Server", map[string]string{"version": "3.0"})
+			return len(ins) == 1
+		}))
+		ins, _ := registry.MicroserviceInstanceIndex.Get("Server", map[string]string{"version": "2.0"})
+		assert.Equal(t, 0, len(ins))
+	})
+	t.Run("pod labels update", func(t *testing.T) {
+		_, err := client.CoreV1().Pods("default").Update(pod("p1", map[string]string{"version": "1.0", "zone": "z2"}))
+		assert.NoError(t, err)
+		assert.True(t, waitFor(func() bool {
+			ins, _ := registry.MicroserviceInstanceIndex.Get("Server", map[string]string{"zone": "z2"})
+			return len(ins) == 1
+		}))
+	})
+	t.Run("endpoints delete", func(t *testing.T) {
+		assert.NoError(t, client.CoreV1().Endpoints("default").Delete("Server", &metav1.DeleteOptions{}))
+		assert.True(t, waitFor(func() bool {
+			_, ok := registry.MicroserviceInstanceIndex.Get("Server", nil)
+			return !ok
+		}))
+	})
+}
@@ -0,0 +1,35 @@
+package kube
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	getOptions = metav1.GetOptions{}
+	everything = labels.Everything()
+)
+
+//podGetter gets pod from api server directly, it is used before informers are started
+type podGetter struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func (g *podGetter) List(selector labels.Selector) ([]*v1.Pod, error) {
+	l, err := g.client.CoreV1().Pods(g.namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0, len(l.Items))
+	for i := range l.Items {
+		pods = append(pods, &l.Items[i])
+	}
+	return pods, nil
+}
+
+func (g *podGetter) Get(name string) (*v1.Pod, error) {
+	return g.client.CoreV1().Pods(g.namespace).Get(name, getOptions)
+}
@@ -16,4 +16,6 @@ type Options struct {
 	Verbose    bool
 	Version    string
 	ConfigPath string
+	//Index caches discovered instances, plugin uses MicroserviceInstanceIndex if it is nil
+	Index CacheIndex
 }
@@ -91,7 +91,11 @@ func NewRegistrator(name string, opts Options) (Registrator, error) {
 	if f == nil {
 		return nil, fmt.Errorf("no registry plugin: %s", name)
 	}
-	return f(opts), nil
+	r := f(opts)
+	if r == nil {
+		return nil, fmt.Errorf("registry plugin [%s] is not available", name)
+	}
+	return r, nil
 }
 func getSpecifiedOptions() (oR, oSD, oCD Options, err error) {
 	hostsR, schemeR, err := URIs2Hosts(strings.Split(config.GetRegistratorAddress(), ","))
@@ -154,12 +158,15 @@ func Enable() (err error) {
 	}
 
 	EnableRegistryCache()
+	s := prepareSnapshot()
+	DefaultActiveHealthChecker.Start()
 	if err := enableRegistrator(oR); err != nil {
 		return err
 	}
 	if err := enableServiceDiscovery(oSD); err != nil {
 		return err
 	}
+	enableSnapshot(s)
 	enableContractDiscovery(oCD)
 
 	openlogging.Info("Enabled Registry")
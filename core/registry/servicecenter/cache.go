@@ -220,9 +220,10 @@ func (c *CacheManager) pullMicroServiceInstance() error {
 	if err != nil {
 		if err == client.ErrNotModified || err == client.ErrEmptyCriteria {
 			openlogging.Debug(err.Error())
-		} else {
-			openlogging.Error("Refresh local instance cache failed: " + err.Error())
+			return nil
 		}
+		//keep local instance cache, registry may be unavailable
+		return err
 	}
 	instances := RegroupInstances(services, response)
 	filter(instances)
@@ -369,6 +370,9 @@ func updateAction(response *client.MicroServiceInstanceChangedEvent) {
 		return
 	}
 	msi := ToMicroServiceInstance(response.Instance).WithAppID(response.Key.AppId)
+	//cached instances are being read by consumers, update a copy of them,
+	//so that new properties such as weight take effect at once without data race
+	microServiceInstances = append(make([]*registry.MicroServiceInstance, 0, len(microServiceInstances)+1), microServiceInstances...)
 	var iidExist = InstanceIDIsNotExist
 	var arrayNum int
 	for k, v := range microServiceInstances {
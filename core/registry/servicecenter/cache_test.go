@@ -1,9 +1,12 @@
 package servicecenter_test
 
 import (
+	"encoding/json"
 	"github.com/go-chassis/go-archaius"
 	"github.com/go-chassis/go-chassis/core/config/model"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -177,3 +180,52 @@ func TestCacheManager_MakeSchemaIndex(t *testing.T) {
 	}
 	assert.True(t, interfaceExistInCache)
 }
+
+func TestCacheManager_AutoSyncKeepsSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry-snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "snapshot.json")
+	b, err := json.Marshal(&registry.Snapshot{Services: map[string]*registry.ServiceSnapshot{
+		"SnapshotServer": {
+			UpdatedAt: time.Now(),
+			Instances: []*registry.MicroServiceInstance{{
+				App:          "default",
+				ServiceName:  "SnapshotServer",
+				Version:      "0.1",
+				InstanceID:   "snapshot1",
+				Status:       client.MSInstanceUP,
+				EndpointsMap: map[string]*registry.Endpoint{"rest": {Address: "127.0.0.1:5080"}},
+			}},
+		},
+	}})
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(p, b, 0600))
+
+	//boot with an unreachable service center
+	registryCfg := config.GlobalDefinition.Cse.Service.Registry
+	defer func() { config.GlobalDefinition.Cse.Service.Registry = registryCfg }()
+	config.GlobalDefinition.Cse.Service.Registry.Address = "http://127.0.0.1:1"
+	config.GlobalDefinition.Cse.Service.Registry.ServiceDiscovery.RefreshInterval = "100ms"
+	archaius.Set("cse.service.registry.registrator.disabled", true)
+	archaius.Set(registry.SnapshotEnabledKey, true)
+	archaius.Set(registry.SnapshotPathKey, p)
+	defer archaius.Set("cse.service.registry.registrator.disabled", false)
+	defer archaius.Set(registry.SnapshotEnabledKey, false)
+	registry.IsEnabled = false
+	defer func() { registry.IsEnabled = false }()
+	defer registry.StopSnapshot()
+
+	assert.NoError(t, registry.Enable())
+	instances, ok := registry.MicroserviceInstanceIndex.Get("SnapshotServer", nil)
+	assert.True(t, ok)
+	assert.Len(t, instances, 1)
+	_, ok = registry.GetSnapshotStatus().Stale["SnapshotServer"]
+	assert.True(t, ok)
+
+	//later refreshes fail too, instances must not be deleted as outdated providers
+	time.Sleep(300 * time.Millisecond)
+	instances, ok = registry.MicroserviceInstanceIndex.Get("SnapshotServer", nil)
+	assert.True(t, ok)
+	assert.Len(t, instances, 1)
+}
@@ -0,0 +1,323 @@
+package registry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/pkg/util/fileutil"
+	"github.com/go-mesh/openlogging"
+)
+
+//config keys of instance cache snapshot
+const (
+	SnapshotEnabledKey  = "cse.service.registry.snapshot.enabled"
+	SnapshotPathKey     = "cse.service.registry.snapshot.path"
+	SnapshotIntervalKey = "cse.service.registry.snapshot.interval"
+)
+
+//DefaultSnapshotInterval is the default interval of saving snapshot
+const DefaultSnapshotInterval = 30 * time.Second
+
+//ServiceSnapshot is the instances of a service in snapshot
+type ServiceSnapshot struct {
+	UpdatedAt time.Time               `json:"updatedAt"`
+	Instances []*MicroServiceInstance `json:"instances"`
+}
+
+//Snapshot is the content of snapshot file, key is service name
+type Snapshot struct {
+	Services map[string]*ServiceSnapshot `json:"services"`
+}
+
+//StaleService is a service which still uses instances loaded from snapshot
+type StaleService struct {
+	UpdatedAt time.Time `json:"updatedAt"`
+	Age       string    `json:"age"`
+}
+
+//SnapshotStatus tells whether consumer is running on cached data
+type SnapshotStatus struct {
+	Enabled  bool                     `json:"enabled"`
+	Path     string                   `json:"path,omitempty"`
+	LoadedAt time.Time                `json:"loadedAt,omitempty"`
+	SavedAt  time.Time                `json:"savedAt,omitempty"`
+	Stale    map[string]*StaleService `json:"stale"`
+}
+
+type snapshotState struct {
+	mu       sync.RWMutex
+	loadedAt time.Time
+	savedAt  time.Time
+	//loaded is what is loaded from snapshot, instances of a service are stale before registry overwrites them
+	loaded map[string]*ServiceSnapshot
+	//revisions are cache revisions of loaded services
+	revisions map[string]uint64
+	stop      chan struct{}
+}
+
+var snapshot = &snapshotState{loaded: make(map[string]*ServiceSnapshot), revisions: make(map[string]uint64)}
+
+//revisioned is a cache index which tells whether instances of a service are changed
+type revisioned interface {
+	Revision(service string) uint64
+}
+
+//cacheRevision return revision of service in instance cache, it is 0 if cache has no revision
+func cacheRevision(service string) uint64 {
+	if r, ok := MicroserviceInstanceIndex.(revisioned); ok {
+		return r.Revision(service)
+	}
+	return 0
+}
+
+//SnapshotEnabled return whether instance cache snapshot is enabled
+func SnapshotEnabled() bool {
+	return archaius.GetBool(SnapshotEnabledKey, false)
+}
+
+//SnapshotPath return snapshot file path, default is registry_snapshot.json in chassis home
+func SnapshotPath() string {
+	return archaius.GetString(SnapshotPathKey, filepath.Join(fileutil.ChassisHomeDir(), "registry_snapshot.json"))
+}
+
+//isStale checks whether instances of service are still the loaded ones, it must be called with lock held.
+//registry overwrites instances after snapshot is loaded, so cache revision changes,
+//if cache has no revision, instance ids are compared
+func (s *snapshotState) isStale(service string, instances []*MicroServiceInstance) bool {
+	loaded, ok := s.loaded[service]
+	if !ok || len(instances) == 0 {
+		return false
+	}
+	if _, ok := MicroserviceInstanceIndex.(revisioned); ok {
+		return cacheRevision(service) == s.revisions[service]
+	}
+	if len(instances) != len(loaded.Instances) {
+		return false
+	}
+	ids := make(map[string]struct{}, len(loaded.Instances))
+	for _, ins := range loaded.Instances {
+		ids[ins.InstanceID] = struct{}{}
+	}
+	for _, ins := range instances {
+		if _, ok := ids[ins.InstanceID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//keepStale is called after instances of service are changed locally, for example status is marked,
+//instances are still stale if they were stale before the change which happened on revision before
+func keepStale(service string, before uint64) {
+	snapshot.mu.Lock()
+	defer snapshot.mu.Unlock()
+	if _, ok := snapshot.loaded[service]; ok && snapshot.revisions[service] == before {
+		snapshot.revisions[service] = cacheRevision(service)
+	}
+}
+
+//SaveSnapshot writes instance cache to file, instances which are loaded from snapshot keep their update time
+func SaveSnapshot(path string) error {
+	s := &Snapshot{Services: make(map[string]*ServiceSnapshot)}
+	now := time.Now()
+	snapshot.mu.RLock()
+	for name, item := range MicroserviceInstanceIndex.FullCache().Items() {
+		instances, ok := item.Object.([]*MicroServiceInstance)
+		if !ok || len(instances) == 0 {
+			continue
+		}
+		if snapshot.isStale(name, instances) {
+			s.Services[name] = snapshot.loaded[name]
+			continue
+		}
+		s.Services[name] = &ServiceSnapshot{UpdatedAt: now, Instances: instances}
+	}
+	snapshot.mu.RUnlock()
+	if len(s.Services) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	//write to temp file and rename, so that a broken file never exists
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	snapshot.mu.Lock()
+	snapshot.savedAt = now
+	snapshot.mu.Unlock()
+	return nil
+}
+
+//readSnapshot reads snapshot file
+func readSnapshot(path string) (*Snapshot, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//LoadSnapshot loads instances from file into cache, services which are already in cache are skipped
+func LoadSnapshot(path string) error {
+	s, err := readSnapshot(path)
+	if err != nil {
+		return err
+	}
+	loadSnapshot(s)
+	return nil
+}
+
+//addSnapshotProviders adds services in snapshot as providers,
+//so that registry is asked for them and they are not deleted as outdated providers
+func addSnapshotProviders(s *Snapshot) {
+	for name, ss := range s.Services {
+		if ss == nil {
+			continue
+		}
+		apps := make(map[string]struct{})
+		for _, ins := range ss.Instances {
+			app := ins.App
+			if app == "" {
+				app = ins.appID()
+			}
+			if _, ok := apps[app]; ok {
+				continue
+			}
+			apps[app] = struct{}{}
+			AddProviderToCache(name, app)
+		}
+	}
+}
+
+func loadSnapshot(s *Snapshot) {
+	snapshot.mu.Lock()
+	defer snapshot.mu.Unlock()
+	for name, ss := range s.Services {
+		if ss == nil || len(ss.Instances) == 0 {
+			continue
+		}
+		if _, ok := MicroserviceInstanceIndex.Get(name, nil); ok {
+			continue
+		}
+		MicroserviceInstanceIndex.Set(name, ss.Instances)
+		snapshot.loaded[name] = ss
+		snapshot.revisions[name] = cacheRevision(name)
+		openlogging.GetLogger().Warnf("use [%d] instances of [%s] from snapshot updated at %s",
+			len(ss.Instances), name, ss.UpdatedAt.Format(time.RFC3339))
+	}
+	snapshot.loadedAt = time.Now()
+}
+
+//GetSnapshotStatus return snapshot status and services which still use instances from snapshot
+func GetSnapshotStatus() SnapshotStatus {
+	status := SnapshotStatus{
+		Enabled: SnapshotEnabled(),
+		Stale:   make(map[string]*StaleService),
+	}
+	if !status.Enabled {
+		return status
+	}
+	status.Path = SnapshotPath()
+	snapshot.mu.RLock()
+	defer snapshot.mu.RUnlock()
+	status.LoadedAt = snapshot.loadedAt
+	status.SavedAt = snapshot.savedAt
+	for name, loaded := range snapshot.loaded {
+		instances, _ := MicroserviceInstanceIndex.Get(name, nil)
+		if snapshot.isStale(name, instances) {
+			status.Stale[name] = &StaleService{
+				UpdatedAt: loaded.UpdatedAt,
+				Age:       time.Since(loaded.UpdatedAt).Round(time.Second).String(),
+			}
+		}
+	}
+	return status
+}
+
+//prepareSnapshot reads snapshot before connecting to registry and adds its services as providers,
+//it return nil if snapshot is disabled or can not be read
+func prepareSnapshot() *Snapshot {
+	if !SnapshotEnabled() {
+		return nil
+	}
+	s, err := readSnapshot(SnapshotPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			openlogging.GetLogger().Errorf("load registry snapshot failed: %s", err)
+		}
+		return nil
+	}
+	addSnapshotProviders(s)
+	return s
+}
+
+//enableSnapshot is called after the first sync with registry,
+//services which registry could not return are loaded from snapshot, because registry may be unavailable,
+//instances from registry overwrites them once registry is available.
+//then instance cache is saved periodically
+func enableSnapshot(s *Snapshot) {
+	if !SnapshotEnabled() {
+		return
+	}
+	path := SnapshotPath()
+	if s != nil {
+		loadSnapshot(s)
+	}
+	interval, err := time.ParseDuration(archaius.GetString(SnapshotIntervalKey, DefaultSnapshotInterval.String()))
+	if err != nil || interval <= 0 {
+		openlogging.GetLogger().Warnf("invalid snapshot interval, use default %s", DefaultSnapshotInterval)
+		interval = DefaultSnapshotInterval
+	}
+	stop := make(chan struct{})
+	snapshot.mu.Lock()
+	snapshot.stop = stop
+	snapshot.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := SaveSnapshot(path); err != nil {
+					openlogging.GetLogger().Errorf("save registry snapshot failed: %s", err)
+				}
+			}
+		}
+	}()
+	openlogging.GetLogger().Infof("registry snapshot is saved to %s every %s", path, interval)
+}
+
+//StopSnapshot stops saving snapshot periodically, and saves it for the last time
+func StopSnapshot() {
+	snapshot.mu.Lock()
+	stop := snapshot.stop
+	snapshot.stop = nil
+	snapshot.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	if err := SaveSnapshot(SnapshotPath()); err != nil {
+		openlogging.GetLogger().Errorf("save registry snapshot failed: %s", err)
+	}
+}
@@ -0,0 +1,78 @@
+package registry_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry-snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "snapshot.json")
+	archaius.Set(registry.SnapshotEnabledKey, true)
+	archaius.Set(registry.SnapshotPathKey, p)
+	defer archaius.Delete(registry.SnapshotEnabledKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	closed := l.Addr().String()
+	l.Close()
+	registry.EnableRegistryCache()
+	registry.MicroserviceInstanceIndex.Set("Server", []*registry.MicroServiceInstance{
+		{
+			InstanceID:   "1",
+			EndpointsMap: map[string]*registry.Endpoint{common.ProtocolRest: {Address: closed}},
+			Metadata:     map[string]string{common.BuildinTagVersion: "1.0"},
+		},
+	})
+	assert.NoError(t, registry.SaveSnapshot(p))
+	saved := registry.GetSnapshotStatus().SavedAt
+	assert.False(t, saved.IsZero())
+
+	t.Run("load when registry is unavailable", func(t *testing.T) {
+		registry.EnableRegistryCache()
+		registry.MicroserviceInstanceIndex.Set("Other", []*registry.MicroServiceInstance{{InstanceID: "2"}})
+		assert.NoError(t, registry.LoadSnapshot(p))
+		ins, ok := registry.MicroserviceInstanceIndex.Get("Server", map[string]string{common.BuildinTagVersion: "1.0"})
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(ins))
+		assert.Equal(t, closed, ins[0].EndpointsMap[common.ProtocolRest].Address)
+
+		status := registry.GetSnapshotStatus()
+		assert.Equal(t, p, status.Path)
+		assert.Equal(t, 1, len(status.Stale))
+		assert.Equal(t, saved.Unix(), status.Stale["Server"].UpdatedAt.Unix())
+	})
+	t.Run("stale instances keep update time", func(t *testing.T) {
+		assert.NoError(t, registry.SaveSnapshot(p))
+		assert.NoError(t, registry.LoadSnapshot(p))
+		assert.Equal(t, saved.Unix(), registry.GetSnapshotStatus().Stale["Server"].UpdatedAt.Unix())
+	})
+	t.Run("still stale after status is marked by health check", func(t *testing.T) {
+		registry.NewActiveHealthChecker().Check("Server", registry.HealthCheckConfig{
+			Enabled: true, Timeout: time.Second, HealthyThreshold: 1, UnhealthyThreshold: 1, Type: registry.ProbeTCP,
+		})
+		ins, _ := registry.MicroserviceInstanceIndex.Get("Server", nil)
+		assert.Equal(t, runtime.StatusDown, ins[0].Status)
+		assert.Equal(t, 1, len(registry.GetSnapshotStatus().Stale))
+	})
+	t.Run("not stale after registry refreshes", func(t *testing.T) {
+		registry.MicroserviceInstanceIndex.Set("Server", []*registry.MicroServiceInstance{{InstanceID: "3"}})
+		assert.Equal(t, 0, len(registry.GetSnapshotStatus().Stale))
+	})
+	t.Run("invalid file", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(p, []byte("{"), 0600))
+		assert.Error(t, registry.LoadSnapshot(p))
+	})
+}
@@ -0,0 +1,196 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/registry"
+)
+
+//Explanation tells how a request is routed
+type Explanation struct {
+	Service string `json:"service"`
+	//Matched is false if no rule matches, then request is not routed by tags
+	Matched bool `json:"matched"`
+	//Index is index of matched rule in rules of service, it is -1 if no rule matches
+	Index int               `json:"index"`
+	Rule  *config.RouteRule `json:"rule,omitempty"`
+	//Routes are tags which may be chosen by weight, the rest of weight goes to latest version
+	Routes []*config.RouteTag `json:"routes,omitempty"`
+	//Tag is the chosen tag if it does not depend on weight
+	Tag *config.RouteTag `json:"tag,omitempty"`
+	//Rules are evaluated rules in order of precedence
+	Rules []RuleResult `json:"rules"`
+}
+
+//RuleResult is the result of evaluating a rule
+type RuleResult struct {
+	Index      int    `json:"index"`
+	Precedence int    `json:"precedence"`
+	Matched    bool   `json:"matched"`
+	Reason     string `json:"reason"`
+}
+
+//Explain evaluates route rules of service like Route does, and tells which rule and tag are chosen and why.
+//it is a dry run, weight pool and invocation are not changed
+func Explain(service string, headers map[string]string, source *registry.SourceInfo, inv *invocation.Invocation) *Explanation {
+	e := &Explanation{Service: service, Index: -1, Rules: []RuleResult{}}
+	if DefaultRouter == nil {
+		return e
+	}
+	if source == nil {
+		source = &registry.SourceInfo{}
+	}
+	if inv == nil {
+		inv = &invocation.Invocation{MicroServiceName: service}
+	}
+	origin := DefaultRouter.FetchRouteRuleByServiceName(service)
+	rules := make([]*config.RouteRule, len(origin))
+	copy(rules, origin)
+	rules = QuickSort(0, len(rules)-1, rules)
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		index := indexOf(origin, rule)
+		matched, reason := explainMatch(rule.Match, headers, source, inv)
+		e.Rules = append(e.Rules, RuleResult{Index: index, Precedence: rule.Precedence, Matched: matched, Reason: reason})
+		if !matched {
+			continue
+		}
+		e.Matched = true
+		e.Index = index
+		e.Rule = rule
+		e.Routes = effectiveRoutes(rule.Routes)
+		if len(e.Routes) == 1 {
+			e.Tag = e.Routes[0]
+		}
+		break
+	}
+	return e
+}
+
+func indexOf(rules []*config.RouteRule, rule *config.RouteRule) int {
+	for i, r := range rules {
+		if r == rule {
+			return i
+		}
+	}
+	return -1
+}
+
+//effectiveRoutes return tags with their weight, it is the same with weight pool
+func effectiveRoutes(tags []*config.RouteTag) []*config.RouteTag {
+	if len(tags) == 0 {
+		return nil
+	}
+	if tags[0].Weight == 100 {
+		return tags[:1]
+	}
+	routes := make([]*config.RouteTag, 0, len(tags)+1)
+	total := 0
+	for _, t := range tags {
+		routes = append(routes, t)
+		if t.Weight > 0 {
+			total += t.Weight
+		}
+	}
+	if total < 100 {
+		routes = append(routes, &config.RouteTag{
+			Weight: 100 - total,
+			Tags:   map[string]string{common.BuildinTagVersion: common.LatestVersion},
+			Label:  common.BuildinLabelVersion,
+		})
+	}
+	return routes
+}
+
+type condition struct {
+	name   string
+	expect interface{}
+	match  config.Match
+}
+
+//explainMatch checks conditions one by one, and return the reason of result
+func explainMatch(match config.Match, headers map[string]string, source *registry.SourceInfo, inv *invocation.Invocation) (bool, string) {
+	if refer := match.Refer; refer != "" {
+		t, ok := Templates[refer]
+		if !ok || t == nil {
+			return false, fmt.Sprintf("template %s does not exist", refer)
+		}
+		match = *t
+		match.Refer = ""
+	}
+	if matchAll(&match) {
+		return true, "rule has no match condition"
+	}
+	for _, c := range conditions(match) {
+		if !MatchInvocation(c.match, headers, source, inv) {
+			return false, fmt.Sprintf("%s does not match %v", c.name, c.expect)
+		}
+	}
+	return true, "all of conditions match"
+}
+
+//conditions splits match into conditions which have only one field
+func conditions(match config.Match) []condition {
+	var cs []condition
+	if match.Source != "" {
+		cs = append(cs, condition{name: "source", expect: match.Source, match: config.Match{Source: match.Source}})
+	}
+	//source tags are checked only if source is set
+	for _, k := range sortedKeys(match.SourceTags) {
+		if match.Source == "" {
+			break
+		}
+		cs = append(cs, condition{name: "sourceTags." + k, expect: match.SourceTags[k],
+			match: config.Match{Source: match.Source, SourceTags: map[string]string{k: match.SourceTags[k]}}})
+	}
+	for _, k := range sortedOperatorKeys(match.Headers) {
+		cs = append(cs, condition{name: "headers." + k, expect: match.Headers[k],
+			match: config.Match{Headers: map[string]map[string]string{k: match.Headers[k]}}})
+	}
+	for _, k := range sortedOperatorKeys(match.HTTPHeaders) {
+		cs = append(cs, condition{name: "httpHeaders." + k, expect: match.HTTPHeaders[k],
+			match: config.Match{HTTPHeaders: map[string]map[string]string{k: match.HTTPHeaders[k]}}})
+	}
+	if match.SchemaID != "" {
+		cs = append(cs, condition{name: "schemaID", expect: match.SchemaID, match: config.Match{SchemaID: match.SchemaID}})
+	}
+	if match.OperationID != "" {
+		cs = append(cs, condition{name: "operationID", expect: match.OperationID,
+			match: config.Match{OperationID: match.OperationID}})
+	}
+	if match.Method != "" {
+		cs = append(cs, condition{name: "method", expect: match.Method, match: config.Match{Method: match.Method}})
+	}
+	if len(match.Path) != 0 {
+		cs = append(cs, condition{name: "path", expect: match.Path, match: config.Match{Path: match.Path}})
+	}
+	for _, k := range sortedOperatorKeys(match.Query) {
+		cs = append(cs, condition{name: "query." + k, expect: match.Query[k],
+			match: config.Match{Query: map[string]map[string]string{k: match.Query[k]}}})
+	}
+	return cs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedOperatorKeys(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,273 @@
+//Package file is a router plugin which reads route rules from a local directory,
+//each yaml file in the directory holds rules of one service, and file name without extension is the service name.
+//the directory is watched, rules are reloaded and swapped when files change,
+//so that route rules can be managed in git without config center
+package file
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/router"
+	wp "github.com/go-chassis/go-chassis/core/router/weightpool"
+	"github.com/go-chassis/go-chassis/pkg/util/fileutil"
+	"github.com/go-mesh/openlogging"
+)
+
+const (
+	//Name is the name of file router plugin
+	Name = "file"
+	//DirKey is the config key of route rule directory
+	DirKey = "servicecomb.service.router.file.dir"
+	//DefaultDir is the route rule directory in conf dir
+	DefaultDir = "routeRule"
+)
+
+//debounce is how long to wait for more events before reload,
+//a file may be truncated and written in several events
+const debounce = 200 * time.Millisecond
+
+//errors of route rule file
+var (
+	ErrInvalidRule = errors.New("invalid route rule")
+	ErrEmptyRule   = errors.New("route rule file is empty")
+)
+
+//Dir return the route rule directory
+func Dir() string {
+	d := archaius.GetString(DirKey, "")
+	if d == "" {
+		d = filepath.Join(fileutil.GetConfDir(), DefaultDir)
+	}
+	abs, err := filepath.Abs(d)
+	if err != nil {
+		return d
+	}
+	return abs
+}
+
+//Router reads route rules from yaml files in a directory
+type Router struct {
+	dir       string
+	routeRule map[string][]*config.RouteRule
+	lock      sync.RWMutex
+	watcher   *fsnotify.Watcher
+	mu        sync.Mutex
+	reloading sync.Mutex
+}
+
+//New create file router which reads rules from dir
+func New(dir string) *Router {
+	return &Router{
+		dir:       dir,
+		routeRule: make(map[string][]*config.RouteRule),
+	}
+}
+
+func newRouter() (router.Router, error) {
+	return New(Dir()), nil
+}
+
+//Init loads route rules and watches the directory
+func (r *Router) Init(o router.Options) error {
+	if err := r.Reload(); err != nil {
+		openlogging.Error("load route rule files failed: " + err.Error())
+	}
+	if err := r.watch(); err != nil {
+		openlogging.Error("can not watch route rule dir: " + err.Error())
+	}
+	return nil
+}
+
+//SetRouteRule set rules, they are overwritten when files change
+func (r *Router) SetRouteRule(rr map[string][]*config.RouteRule) {
+	r.swap(rr)
+}
+
+//FetchRouteRuleByServiceName get rules for service
+func (r *Router) FetchRouteRuleByServiceName(service string) []*config.RouteRule {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.routeRule[service]
+}
+
+//ListRouteRule get rules for all service
+func (r *Router) ListRouteRule() map[string][]*config.RouteRule {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	rr := make(map[string][]*config.RouteRule, len(r.routeRule))
+	for k, v := range r.routeRule {
+		rr[k] = v
+	}
+	return rr
+}
+
+//Reload reads all of files in directory, and swaps rules at once.
+//if a file can not be read or its rules are invalid, old rules of that service are kept
+func (r *Router) Reload() error {
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+	files, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+	old := r.ListRouteRule()
+	rules := make(map[string][]*config.RouteRule, len(files))
+	for _, f := range files {
+		service, ok := serviceOf(f.Name())
+		if f.IsDir() || !ok {
+			continue
+		}
+		rr, err := readRules(filepath.Join(r.dir, f.Name()))
+		if err != nil {
+			openlogging.Warn("read route rule file failed, keep using old rules: "+err.Error(),
+				openlogging.WithTags(openlogging.Tags{"service": service}))
+			if o, ok := old[service]; ok {
+				rules[service] = o
+			}
+			continue
+		}
+		rules[service] = rr
+	}
+	r.swap(rules)
+	openlogging.GetLogger().Infof("loaded route rules of [%d] services from %s", len(rules), r.dir)
+	return nil
+}
+
+//Close stop watching directory
+func (r *Router) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watcher == nil {
+		return nil
+	}
+	err := r.watcher.Close()
+	r.watcher = nil
+	return err
+}
+
+//swap replaces all of rules, weight pools of changed services are reset
+func (r *Router) swap(rules map[string][]*config.RouteRule) {
+	r.lock.Lock()
+	old := r.routeRule
+	r.routeRule = rules
+	r.lock.Unlock()
+	for service, rr := range rules {
+		if o, ok := old[service]; !ok || !reflect.DeepEqual(o, rr) {
+			wp.GetPool().Reset(service)
+			openlogging.Info("update route rule success", openlogging.WithTags(
+				openlogging.Tags{
+					"service": service,
+					"rule":    rr,
+				}))
+		}
+	}
+	for service := range old {
+		if _, ok := rules[service]; !ok {
+			wp.GetPool().Reset(service)
+			openlogging.Info("route rule is removed", openlogging.WithTags(
+				openlogging.Tags{
+					"service": service,
+				}))
+		}
+	}
+}
+
+//watch the directory, editors and git may replace files by renaming,
+//events are coalesced, directory is reloaded after no event comes in debounce time
+func (r *Router) watch() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := w.Add(r.dir); err != nil {
+		w.Close()
+		return err
+	}
+	r.mu.Lock()
+	r.watcher = w
+	r.mu.Unlock()
+	reload := func() {
+		if err := r.Reload(); err != nil {
+			openlogging.Warn("reload route rule files failed, keep using old rules: " + err.Error())
+		}
+	}
+	go func() {
+		var timer *time.Timer
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					if timer != nil {
+						timer.Stop()
+					}
+					return
+				}
+				if _, ok := serviceOf(filepath.Base(e.Name)); !ok || e.Op == fsnotify.Chmod {
+					continue
+				}
+				openlogging.Debug("route rule file changed: " + e.String())
+				if timer == nil {
+					timer = time.AfterFunc(debounce, reload)
+				} else {
+					timer.Reset(debounce)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				openlogging.Error("watch route rule dir error: " + err.Error())
+			}
+		}
+	}()
+	return nil
+}
+
+//readRules parse and validate rules in file,
+//empty file is an error, because it may be read while it is being written
+func readRules(path string) ([]*config.RouteRule, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(string(b)) == "" {
+		return nil, ErrEmptyRule
+	}
+	rule, err := config.NewServiceRule(string(b))
+	if err != nil {
+		return nil, err
+	}
+	rr := rule.Value()
+	if len(rr) == 0 {
+		return nil, ErrEmptyRule
+	}
+	service, _ := serviceOf(filepath.Base(path))
+	if !router.ValidateRule(map[string][]*config.RouteRule{service: rr}) {
+		return nil, ErrInvalidRule
+	}
+	return rr, nil
+}
+
+//serviceOf return service name of a yaml file, hidden files are ignored
+func serviceOf(name string) (string, bool) {
+	if strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	ext := filepath.Ext(name)
+	if ext != ".yaml" && ext != ".yml" {
+		return "", false
+	}
+	return strings.TrimSuffix(name, ext), true
+}
+
+func init() {
+	router.InstallRouterPlugin(Name, newRouter)
+}
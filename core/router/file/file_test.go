@@ -0,0 +1,139 @@
+package file_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/router"
+	"github.com/go-chassis/go-chassis/core/router/file"
+	wp "github.com/go-chassis/go-chassis/core/router/weightpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rule = `
+- precedence: 2
+  match:
+    headers:
+      user:
+        exact: jason
+  route:
+    - weight: 100
+      tags:
+        version: 2.0.0
+- precedence: 1
+  route:
+    - weight: 100
+      tags:
+        version: 1.0.0
+`
+
+const changed = `
+- precedence: 1
+  route:
+    - weight: 50
+      tags:
+        version: 1.0.0
+    - weight: 50
+      tags:
+        version: 3.0.0
+`
+
+const invalid = `
+- route:
+    - weight: 200
+      tags:
+        version: 1.0.0
+`
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+	archaius.Init(archaius.WithMemorySource())
+}
+
+//writeFile writes a hidden temp file and renames it, so that watcher never reads a partial file
+func writeFile(t *testing.T, path, content string) {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	require.NoError(t, ioutil.WriteFile(tmp, []byte(content), 0600))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+//eventually waits until f returns true
+func eventually(f func() bool) bool {
+	for i := 0; i < 50; i++ {
+		if f() {
+			return true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return false
+}
+
+func TestRouter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-router")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	server := filepath.Join(dir, "Server.yaml")
+	writeFile(t, server, rule)
+	writeFile(t, filepath.Join(dir, "Other.yml"), rule)
+	writeFile(t, filepath.Join(dir, "README.md"), "not a rule")
+	archaius.Set(file.DirKey, dir)
+	assert.Equal(t, dir, file.Dir())
+
+	assert.NoError(t, router.BuildRouter(file.Name))
+	r := router.DefaultRouter
+	assert.NoError(t, r.Init(router.Options{}))
+	defer r.(*file.Router).Close()
+
+	t.Run("load rules", func(t *testing.T) {
+		assert.Equal(t, 2, len(r.ListRouteRule()))
+		rr := r.FetchRouteRuleByServiceName("Server")
+		require.Equal(t, 2, len(rr))
+		assert.Equal(t, "2.0.0", rr[0].Routes[0].Tags["version"])
+		assert.Equal(t, "version:2.0.0", rr[0].Routes[0].Label)
+	})
+	t.Run("reload and reset weight pool after file changes", func(t *testing.T) {
+		wp.GetPool().Set("Server", wp.NewPool())
+		wp.GetPool().Set("Other", wp.NewPool())
+		writeFile(t, server, changed)
+		assert.True(t, eventually(func() bool {
+			return len(r.FetchRouteRuleByServiceName("Server")) == 1
+		}))
+		_, ok := wp.GetPool().Get("Server")
+		assert.False(t, ok)
+		_, ok = wp.GetPool().Get("Other")
+		assert.True(t, ok, "pool of unchanged service is kept")
+	})
+	for name, content := range map[string]string{"invalid": invalid, "empty": "", "blank": "\n  \n", "empty list": "[]"} {
+		t.Run("keep old rules if file is "+name, func(t *testing.T) {
+			writeFile(t, server, content)
+			assert.NoError(t, r.(*file.Router).Reload())
+			rr := r.FetchRouteRuleByServiceName("Server")
+			require.Equal(t, 1, len(rr))
+			assert.Equal(t, 50, rr[0].Routes[0].Weight)
+		})
+	}
+	t.Run("remove rules after file is removed", func(t *testing.T) {
+		assert.NoError(t, os.Remove(filepath.Join(dir, "Other.yml")))
+		assert.True(t, eventually(func() bool {
+			return r.FetchRouteRuleByServiceName("Other") == nil
+		}))
+		_, ok := wp.GetPool().Get("Other")
+		assert.False(t, ok)
+	})
+}
+
+func TestRouter_NoDir(t *testing.T) {
+	r := file.New(filepath.Join(os.TempDir(), "file-router-not-exist"))
+	assert.Error(t, r.Reload())
+	assert.NoError(t, r.Init(router.Options{}))
+	assert.Empty(t, r.ListRouteRule())
+	assert.NoError(t, r.Close())
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"time"
+
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
+)
+
+//routeAction return actions of route rule, it is nil if rule has no action
+func routeAction(rule *config.RouteRule) *invocation.RouteAction {
+	if rule.Headers == nil && rule.Rewrite == nil && rule.Timeout == "" && rule.Retries == nil {
+		return nil
+	}
+	a := &invocation.RouteAction{}
+	if rule.Headers != nil {
+		a.RequestHeaders = headerOperations(rule.Headers.Request)
+		a.ResponseHeaders = headerOperations(rule.Headers.Response)
+	}
+	if rule.Rewrite != nil {
+		a.Rewrite = &invocation.Rewrite{
+			Path:        rule.Rewrite.Path,
+			StripPrefix: rule.Rewrite.StripPrefix,
+			Prefix:      rule.Rewrite.Prefix,
+		}
+	}
+	//invalid timeout is rejected by ValidateRule
+	a.Timeout, _ = parseTimeout(rule.Timeout)
+	if r := rule.Retries; r != nil {
+		a.Retry = &invocation.RouteRetry{
+			RetryOnSame: r.RetryOnSame,
+			RetryOnNext: r.RetryOnNext,
+			StatusCodes: r.StatusCodes,
+			Errors:      r.Errors,
+		}
+	}
+	return a
+}
+
+func headerOperations(o *config.HeaderOperations) *invocation.HeaderOperations {
+	if o == nil {
+		return nil
+	}
+	return &invocation.HeaderOperations{Set: o.Set, Add: o.Add, Remove: o.Remove}
+}
+
+func parseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+//DefaultMirrorTimeout is timeout of shadow request if mirror has no timeout
+const DefaultMirrorTimeout = 3 * time.Second
+
+//MirrorTimeout returns timeout of shadow request
+func MirrorTimeout(m *config.Mirror) time.Duration {
+	if d, err := parseTimeout(m.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultMirrorTimeout
+}
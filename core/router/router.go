@@ -5,15 +5,21 @@ import (
 	"errors"
 	"github.com/go-chassis/go-chassis/core/config"
 	mr "github.com/go-chassis/go-chassis/core/match"
+	"math/rand"
+	"net/http"
 	"strings"
 
 	"github.com/go-chassis/go-chassis/core/common"
 	"github.com/go-chassis/go-chassis/core/invocation"
 	"github.com/go-chassis/go-chassis/core/registry"
 	wp "github.com/go-chassis/go-chassis/core/router/weightpool"
+	"github.com/go-chassis/go-chassis/pkg/util/tags"
 	"github.com/go-mesh/openlogging"
 )
 
+//metaMirror is the metadata key of mirror which Route decides
+const metaMirror = "router.mirror"
+
 //Templates is for source match template settings
 var Templates = make(map[string]*config.Match)
 
@@ -54,19 +60,40 @@ func BuildRouter(name string) error {
 
 //Route decide the target service metadata
 //it decide based on configuration of route rule
-//it will set RouteTag to invocation
+//it will set RouteTag to invocation,
+//if matched rule has mirror and request is sampled, the mirror can be got by TakeMirror
 func Route(header map[string]string, si *registry.SourceInfo, inv *invocation.Invocation) error {
 	rules := SortRules(inv.MicroServiceName)
 	for _, rule := range rules {
-		if Match(rule.Match, header, si) {
+		if MatchInvocation(rule.Match, header, si, inv) {
 			tag := FitRate(rule.Routes, inv.MicroServiceName)
 			inv.RouteTags = routeTagToTags(tag)
+			inv.RouteAction = routeAction(rule)
+			if rule.Mirror != nil && rand.Float64()*100 < rule.Mirror.Percent {
+				inv.SetMetadata(metaMirror, rule.Mirror)
+			}
 			break
 		}
 	}
 	return nil
 }
 
+//TakeMirror returns the mirror decided by Route and removes it from invocation,
+//it returns nil if request should not be mirrored
+func TakeMirror(inv *invocation.Invocation) *config.Mirror {
+	m, ok := inv.Metadata[metaMirror].(*config.Mirror)
+	if !ok {
+		return nil
+	}
+	delete(inv.Metadata, metaMirror)
+	return m
+}
+
+//MirrorTags returns route tags of shadow destination
+func MirrorTags(m *config.Mirror) utiltags.Tags {
+	return routeTagToTags(&config.RouteTag{Tags: m.Tags, Label: utiltags.LabelOfTags(m.Tags)})
+}
+
 // FitRate fit rate
 func FitRate(tags []*config.RouteTag, dest string) *config.RouteTag {
 	if tags[0].Weight == 100 {
@@ -82,18 +109,73 @@ func FitRate(tags []*config.RouteTag, dest string) *config.RouteTag {
 	return pool.PickOne()
 }
 
-// Match check the route rule
+// Match check the route rule, rule which has request conditions does not match,
+// use MatchInvocation to check path, method, query and operation
 func Match(match config.Match, headers map[string]string, source *registry.SourceInfo) bool {
+	return MatchInvocation(match, headers, source, nil)
+}
+
+// MatchInvocation check the route rule with source, headers and request of invocation
+func MatchInvocation(match config.Match, headers map[string]string, source *registry.SourceInfo, inv *invocation.Invocation) bool {
 	//validate template first
 	if refer := match.Refer; refer != "" {
-		return SourceMatch(Templates[refer], headers, source)
+		return SourceMatch(Templates[refer], headers, source) && RequestMatch(Templates[refer], inv)
 	}
 	//match rule is not set
-	if match.Source == "" && match.HTTPHeaders == nil && match.Headers == nil {
+	if matchAll(&match) {
 		return true
 	}
 
-	return SourceMatch(&match, headers, source)
+	return SourceMatch(&match, headers, source) && RequestMatch(&match, inv)
+}
+
+//matchAll return whether match has no condition
+func matchAll(match *config.Match) bool {
+	return match.Refer == "" && match.Source == "" && match.HTTPHeaders == nil && match.Headers == nil &&
+		!hasRequestMatch(match)
+}
+
+func hasRequestMatch(match *config.Match) bool {
+	return len(match.Path) != 0 || match.Method != "" || len(match.Query) != 0 ||
+		match.SchemaID != "" || match.OperationID != ""
+}
+
+// RequestMatch check path, method and query of http request, and schema and operation of invocation
+func RequestMatch(match *config.Match, inv *invocation.Invocation) bool {
+	if !hasRequestMatch(match) {
+		return true
+	}
+	if inv == nil {
+		return false
+	}
+	if match.SchemaID != "" && match.SchemaID != inv.SchemaID {
+		return false
+	}
+	if match.OperationID != "" && match.OperationID != inv.OperationID {
+		return false
+	}
+	if len(match.Path) == 0 && match.Method == "" && len(match.Query) == 0 {
+		return true
+	}
+	req, ok := inv.Args.(*http.Request)
+	if !ok || req == nil || req.URL == nil {
+		return false
+	}
+	if match.Method != "" && !strings.EqualFold(match.Method, req.Method) {
+		return false
+	}
+	if len(match.Path) != 0 && !isMatch(map[string]string{"path": req.URL.Path}, "path", match.Path) {
+		return false
+	}
+	if len(match.Query) != 0 {
+		query := req.URL.Query()
+		for k, v := range match.Query {
+			if !isMatch(map[string]string{k: query.Get(k)}, k, v) {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // SourceMatch check the source route
@@ -36,27 +36,35 @@ func Init() error {
 	return nil
 }
 
-// ValidateRule validate the route rules of each service
+// ValidateRule validate the route rules of each service, problems are logged,
+// it returns false if there is any error, use Validate to get problems
 func ValidateRule(rules map[string][]*config.RouteRule) bool {
-	for name, rule := range rules {
+	for _, rule := range rules {
 		for _, route := range rule {
-			allWeight := 0
-			for _, routeTag := range route.Routes {
-				routeTag.Label = utiltags.LabelOfTags(routeTag.Tags)
-				allWeight += routeTag.Weight
+			if route == nil {
+				continue
 			}
-
-			if allWeight > 100 {
-				openlogging.Warn("route rule is invalid: total weight is over 100%", openlogging.WithTags(
-					openlogging.Tags{
-						"service": name,
-					}))
-				return false
+			for _, routeTag := range route.Routes {
+				if routeTag != nil {
+					routeTag.Label = utiltags.LabelOfTags(routeTag.Tags)
+				}
 			}
 		}
-
 	}
-	return true
+	errs := Validate(rules)
+	for _, e := range errs {
+		tags := openlogging.WithTags(openlogging.Tags{
+			"service": e.Service,
+			"index":   e.Index,
+			"field":   e.Field,
+		})
+		if e.Severity == SeverityError {
+			openlogging.Warn("route rule is invalid: "+e.Message, tags)
+		} else {
+			openlogging.Info("route rule may be wrong: "+e.Message, tags)
+		}
+	}
+	return !HasError(errs)
 }
 
 // Options defines how to init router and its fetcher
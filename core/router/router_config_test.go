@@ -34,5 +34,96 @@ func TestInit(t *testing.T) {
 		})
 		assert.False(t, ok)
 	})
+	t.Run("validate rule, invalid timeout, should fail", func(t *testing.T) {
+		ok := router.ValidateRule(map[string][]*config.RouteRule{
+			"service3": {
+				{Routes: []*config.RouteTag{{Weight: 100}}, Timeout: "1x"},
+			},
+		})
+		assert.False(t, ok)
+	})
+	t.Run("validate rule, invalid mirror percent, should fail", func(t *testing.T) {
+		ok := router.ValidateRule(map[string][]*config.RouteRule{
+			"service4": {
+				{Routes: []*config.RouteTag{{Weight: 100}}, Mirror: &config.Mirror{Percent: 120}},
+			},
+		})
+		assert.False(t, ok)
+	})
+
+}
 
+func TestValidate(t *testing.T) {
+	router.Templates["vmall"] = &config.Match{Headers: map[string]map[string]string{"user": {"regex": "("}}}
+	defer delete(router.Templates, "vmall")
+	errs := router.Validate(map[string][]*config.RouteRule{
+		"Carts": {
+			{
+				Precedence: 1,
+				Routes:     []*config.RouteTag{{Weight: 100}},
+			},
+			{
+				Precedence: 2,
+				Routes:     []*config.RouteTag{{Weight: 60}, {Weight: 50}},
+				Match:      config.Match{Refer: "unknown"},
+			},
+			{
+				Precedence: 1,
+				Routes:     []*config.RouteTag{{Weight: 80}},
+				Match: config.Match{
+					Headers: map[string]map[string]string{"Os": {"unknownOp": "ios"}},
+				},
+			},
+			{
+				Precedence: 3,
+				Routes:     []*config.RouteTag{{Weight: 100}},
+				Match:      config.Match{Refer: "vmall"},
+			},
+			{
+				Precedence: 3,
+				Match:      config.Match{Path: map[string]string{"regex": "[a-"}},
+			},
+		},
+	})
+	assert.True(t, router.HasError(errs))
+	type problem struct {
+		index    int
+		field    string
+		severity string
+	}
+	var problems []problem
+	for _, e := range errs {
+		assert.Equal(t, "Carts", e.Service)
+		assert.NotEmpty(t, e.Message)
+		assert.NotEmpty(t, e.Error())
+		problems = append(problems, problem{e.Index, e.Field, e.Severity})
+	}
+	assert.Equal(t, []problem{
+		{1, "match.refer", router.SeverityError},
+		{1, "route", router.SeverityError},
+		{2, "match.headers.Os", router.SeverityError},
+		{2, "precedence", router.SeverityWarning},
+		{2, "route", router.SeverityWarning},
+		{3, "match.headers.user", router.SeverityError},
+		{4, "match.path", router.SeverityError},
+		{4, "route", router.SeverityError},
+	}, problems)
+
+	errs = router.Validate(map[string][]*config.RouteRule{
+		"Carts": {{Routes: []*config.RouteTag{{Weight: 80}}}},
+	})
+	assert.False(t, router.HasError(errs))
+	assert.Equal(t, 1, len(errs))
+
+	errs = router.Validate(map[string][]*config.RouteRule{
+		"Carts": {{
+			Routes: []*config.RouteTag{{Weight: 100}},
+			Match:  config.Match{SourceTags: map[string]string{"version": "1.0"}},
+		}},
+	})
+	assert.False(t, router.HasError(errs))
+	if assert.Equal(t, 1, len(errs)) {
+		assert.Equal(t, "match.sourceTags", errs[0].Field)
+		assert.Equal(t, router.SeverityWarning, errs[0].Severity)
+	}
 }
@@ -1,6 +1,7 @@
 package router_test
 
 import (
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"github.com/go-chassis/go-chassis/core/registry"
 	"github.com/go-chassis/go-chassis/core/router"
 	_ "github.com/go-chassis/go-chassis/core/router/servicecomb"
+	wp "github.com/go-chassis/go-chassis/core/router/weightpool"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -193,6 +195,37 @@ func TestRoute2(t *testing.T) {
 	assert.Equal(t, "0.0.1", inv.RouteTags.Version())
 }
 
+func TestRouteMirror(t *testing.T) {
+	mirror := &config.Mirror{Service: "carts-shadow", Tags: map[string]string{"version": "0.0.2"}, Percent: 100}
+	d := map[string][]*config.RouteRule{
+		"carts": {
+			{
+				Routes: []*config.RouteTag{
+					{Weight: 100, Tags: map[string]string{"version": "0.0.1"}},
+				},
+				Mirror: mirror,
+			},
+		},
+	}
+	router.BuildRouter("cse")
+	router.DefaultRouter.SetRouteRule(d)
+
+	inv := &invocation.Invocation{MicroServiceName: "carts"}
+	err := router.Route(map[string]string{}, nil, inv)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.1", inv.RouteTags.Version())
+	m := router.TakeMirror(inv)
+	assert.True(t, m == mirror)
+	assert.Equal(t, "0.0.2", router.MirrorTags(m).Version())
+	assert.Nil(t, router.TakeMirror(inv))
+
+	mirror.Percent = 0
+	inv = &invocation.Invocation{MicroServiceName: "carts"}
+	err = router.Route(map[string]string{}, nil, inv)
+	assert.NoError(t, err)
+	assert.Nil(t, router.TakeMirror(inv))
+}
+
 func TestMatch(t *testing.T) {
 	si := &registry.SourceInfo{
 		Tags: map[string]string{},
@@ -227,6 +260,115 @@ func TestMatch(t *testing.T) {
 	assert.Equal(t, false, router.Match(match, headers, si))
 }
 
+func TestMatchInvocation(t *testing.T) {
+	si := &registry.SourceInfo{Name: "service", Tags: map[string]string{}}
+	req, err := http.NewRequest(http.MethodGet, "cse://carts/v1/carts/1?user=jason&region=EU", nil)
+	assert.NoError(t, err)
+	inv := &invocation.Invocation{MicroServiceName: "carts", Args: req}
+
+	t.Run("path", func(t *testing.T) {
+		assert.True(t, router.MatchInvocation(config.Match{Path: map[string]string{"exact": "/v1/carts/1"}}, nil, si, inv))
+		assert.True(t, router.MatchInvocation(config.Match{Path: map[string]string{"prefix": "/v1/"}}, nil, si, inv))
+		assert.True(t, router.MatchInvocation(config.Match{Path: map[string]string{"regex": "^/v1/carts/[0-9]+$"}}, nil, si, inv))
+		assert.False(t, router.MatchInvocation(config.Match{Path: map[string]string{"prefix": "/v2/"}}, nil, si, inv))
+		assert.True(t, router.MatchInvocation(config.Match{Path: map[string]string{"prefix": "/V1/", "caseInsensitive": "true"}}, nil, si, inv))
+	})
+	t.Run("method", func(t *testing.T) {
+		assert.True(t, router.MatchInvocation(config.Match{Method: "get"}, nil, si, inv))
+		assert.False(t, router.MatchInvocation(config.Match{Method: "POST"}, nil, si, inv))
+	})
+	t.Run("query", func(t *testing.T) {
+		m := config.Match{Query: map[string]map[string]string{
+			"user":   {"exact": "jason"},
+			"region": {"exact": "eu", "caseInsensitive": "true"},
+		}}
+		assert.True(t, router.MatchInvocation(m, nil, si, inv))
+		m.Query["vip"] = map[string]string{"exact": "true"}
+		assert.False(t, router.MatchInvocation(m, nil, si, inv))
+	})
+	t.Run("operation", func(t *testing.T) {
+		rpc := &invocation.Invocation{MicroServiceName: "carts", SchemaID: "CartService", OperationID: "Add"}
+		assert.True(t, router.MatchInvocation(config.Match{SchemaID: "CartService", OperationID: "Add"}, nil, si, rpc))
+		assert.False(t, router.MatchInvocation(config.Match{SchemaID: "CartService", OperationID: "Delete"}, nil, si, rpc))
+		assert.False(t, router.MatchInvocation(config.Match{Method: "GET"}, nil, si, rpc))
+	})
+	t.Run("with source", func(t *testing.T) {
+		m := config.Match{Source: "service", Path: map[string]string{"prefix": "/v1/"}}
+		assert.True(t, router.MatchInvocation(m, nil, si, inv))
+		assert.False(t, router.Match(m, nil, si))
+		m.Source = "other"
+		assert.False(t, router.MatchInvocation(m, nil, si, inv))
+	})
+	t.Run("source tags without source", func(t *testing.T) {
+		m := config.Match{SourceTags: map[string]string{"version": "1.0"}}
+		assert.True(t, router.MatchInvocation(m, nil, si, inv))
+		tagged := &registry.SourceInfo{Name: "service", Tags: map[string]string{"version": "1.0"}}
+		assert.True(t, router.MatchInvocation(m, nil, tagged, inv))
+	})
+}
+
+func TestExplain(t *testing.T) {
+	d := map[string][]*config.RouteRule{
+		"carts": {
+			{
+				Precedence: 1,
+				Routes: []*config.RouteTag{
+					{Weight: 60, Tags: map[string]string{"version": "1.0"}},
+					{Weight: 20, Tags: map[string]string{"version": "2.0"}},
+				},
+			},
+			{
+				Precedence: 2,
+				Routes: []*config.RouteTag{
+					{Weight: 100, Tags: map[string]string{"version": "3.0"}},
+				},
+				Match: config.Match{
+					Source:  "vmall",
+					Headers: map[string]map[string]string{"user": {"exact": "jason"}},
+					Method:  "GET",
+				},
+			},
+		},
+	}
+	router.BuildRouter("cse")
+	router.DefaultRouter.SetRouteRule(d)
+	req, err := http.NewRequest(http.MethodGet, "cse://carts/v1/carts", nil)
+	assert.NoError(t, err)
+
+	t.Run("matched by conditions", func(t *testing.T) {
+		e := router.Explain("carts", map[string]string{"user": "jason"}, &registry.SourceInfo{Name: "vmall"},
+			&invocation.Invocation{Args: req})
+		assert.True(t, e.Matched)
+		assert.Equal(t, 1, e.Index)
+		assert.Equal(t, "3.0", e.Tag.Tags["version"])
+		assert.Equal(t, 1, len(e.Rules))
+		assert.Equal(t, "all of conditions match", e.Rules[0].Reason)
+	})
+	t.Run("fall back to rule without condition", func(t *testing.T) {
+		e := router.Explain("carts", map[string]string{"user": "tom"}, &registry.SourceInfo{Name: "vmall"},
+			&invocation.Invocation{Args: req})
+		assert.True(t, e.Matched)
+		assert.Equal(t, 0, e.Index)
+		assert.Nil(t, e.Tag)
+		assert.Equal(t, 3, len(e.Routes))
+		assert.Equal(t, 20, e.Routes[2].Weight)
+		assert.Equal(t, common.LatestVersion, e.Routes[2].Tags[common.BuildinTagVersion])
+		assert.Equal(t, 2, len(e.Rules))
+		assert.False(t, e.Rules[0].Matched)
+		assert.Equal(t, "headers.user does not match map[exact:jason]", e.Rules[0].Reason)
+		assert.Equal(t, "rule has no match condition", e.Rules[1].Reason)
+	})
+	t.Run("no rule", func(t *testing.T) {
+		e := router.Explain("orders", nil, nil, nil)
+		assert.False(t, e.Matched)
+		assert.Equal(t, -1, e.Index)
+		assert.Empty(t, e.Rules)
+	})
+	//dry run does not create weight pool
+	_, ok := wp.GetPool().Get("carts")
+	assert.False(t, ok)
+}
+
 func TestFitRate(t *testing.T) {
 	tags := InitTags("0.1", "0.2")
 	tag := router.FitRate(tags, "service") //0,0
@@ -0,0 +1,201 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/go-chassis/go-chassis/core/config"
+	mr "github.com/go-chassis/go-chassis/core/match"
+)
+
+//severities of rule error, rules with error are not applied, warning is only reported
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+//RuleError is a problem of route rule
+type RuleError struct {
+	Service  string `json:"service"`
+	Index    int    `json:"index"` //index of rule in rules of service
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+//Error return error message
+func (e RuleError) Error() string {
+	return fmt.Sprintf("%s: route rule %d of service [%s], %s: %s", e.Severity, e.Index, e.Service, e.Field, e.Message)
+}
+
+//Validate checks route rules and returns all of problems
+func Validate(rules map[string][]*config.RouteRule) []RuleError {
+	var errs []RuleError
+	for service, rr := range rules {
+		for i, rule := range rr {
+			if rule == nil {
+				errs = append(errs, RuleError{Service: service, Index: i, Field: "rule", Severity: SeverityError,
+					Message: "rule is empty"})
+				continue
+			}
+			errs = append(errs, validateRule(service, i, rule)...)
+		}
+		errs = append(errs, unreachable(service, rr)...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Service != errs[j].Service {
+			return errs[i].Service < errs[j].Service
+		}
+		if errs[i].Index != errs[j].Index {
+			return errs[i].Index < errs[j].Index
+		}
+		return errs[i].Field < errs[j].Field
+	})
+	return errs
+}
+
+//HasError return whether there is any error, warnings are ignored
+func HasError(errs []RuleError) bool {
+	for _, e := range errs {
+		if e.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRule(service string, index int, rule *config.RouteRule) []RuleError {
+	var errs []RuleError
+	report := func(severity, field, format string, args ...interface{}) {
+		errs = append(errs, RuleError{Service: service, Index: index, Field: field, Severity: severity,
+			Message: fmt.Sprintf(format, args...)})
+	}
+	if len(rule.Routes) == 0 {
+		report(SeverityError, "route", "no route tag")
+	}
+	total := 0
+	for i, t := range rule.Routes {
+		if t == nil {
+			report(SeverityError, fmt.Sprintf("route[%d]", i), "route tag is empty")
+			continue
+		}
+		if t.Weight < 0 {
+			report(SeverityError, fmt.Sprintf("route[%d].weight", i), "weight %d is negative", t.Weight)
+		}
+		total += t.Weight
+	}
+	if total > 100 {
+		report(SeverityError, "route", "total weight %d is over 100", total)
+	} else if len(rule.Routes) != 0 && total < 100 {
+		report(SeverityWarning, "route", "total weight %d is less than 100, the rest goes to latest version", total)
+	}
+
+	match := &rule.Match
+	if refer := rule.Match.Refer; refer != "" {
+		t, ok := Templates[refer]
+		if !ok || t == nil {
+			report(SeverityError, "match.refer", "template %s does not exist", refer)
+			match = nil
+		} else {
+			others := rule.Match
+			others.Refer = ""
+			if !matchAll(&others) {
+				report(SeverityWarning, "match", "other conditions are ignored, template %s is used", refer)
+			}
+			match = t
+		}
+	}
+	if match != nil && match.Source == "" && len(match.SourceTags) != 0 {
+		report(SeverityWarning, "match.sourceTags", "source tags are ignored because source is not set")
+	}
+	if match != nil {
+		for field, v := range operators(match) {
+			for op, exp := range v {
+				if op == "caseInsensitive" {
+					continue
+				}
+				if !mr.Installed(op) {
+					report(SeverityError, field, "operator %s is not installed", op)
+					continue
+				}
+				if op == "regex" {
+					if _, err := regexp.CompilePOSIX(exp); err != nil {
+						report(SeverityError, field, "invalid regex %s: %s", exp, err)
+					}
+				}
+			}
+		}
+	}
+
+	if d, err := parseTimeout(rule.Timeout); err != nil || d < 0 {
+		report(SeverityError, "timeout", "invalid duration %s", rule.Timeout)
+	}
+	if m := rule.Mirror; m != nil && (m.Percent < 0 || m.Percent > 100) {
+		report(SeverityError, "mirror.percent", "percent %v is not in 0 to 100", m.Percent)
+	}
+	if m := rule.Mirror; m != nil {
+		if d, err := parseTimeout(m.Timeout); err != nil || d < 0 {
+			report(SeverityError, "mirror.timeout", "invalid duration %s", m.Timeout)
+		}
+	}
+	if r := rule.Retries; r != nil && (r.RetryOnSame < 0 || r.RetryOnNext < 0) {
+		report(SeverityError, "retries", "retry times can not be negative")
+	}
+	return errs
+}
+
+//operators return operators of match conditions, field name is key
+func operators(match *config.Match) map[string]map[string]string {
+	m := make(map[string]map[string]string)
+	for k, v := range match.Headers {
+		m["match.headers."+k] = v
+	}
+	for k, v := range match.HTTPHeaders {
+		m["match.httpHeaders."+k] = v
+	}
+	for k, v := range match.Query {
+		m["match.query."+k] = v
+	}
+	if len(match.Path) != 0 {
+		m["match.path"] = match.Path
+	}
+	return m
+}
+
+//unreachable reports rules which can never be matched, because a rule before them matches the same or all requests
+func unreachable(service string, rules []*config.RouteRule) []RuleError {
+	type indexed struct {
+		index int
+		rule  *config.RouteRule
+	}
+	sorted := make([]indexed, 0, len(rules))
+	for i, r := range rules {
+		if r != nil {
+			sorted = append(sorted, indexed{index: i, rule: r})
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].rule.Precedence > sorted[j].rule.Precedence
+	})
+	var errs []RuleError
+	for i, r := range sorted {
+		for _, before := range sorted[:i] {
+			if !matchAll(&before.rule.Match) && !reflect.DeepEqual(before.rule.Match, r.rule.Match) {
+				continue
+			}
+			msg := fmt.Sprintf("it is shadowed by rule %d which matches the same requests", before.index)
+			if matchAll(&before.rule.Match) {
+				msg = fmt.Sprintf("it is shadowed by rule %d which matches all requests", before.index)
+			}
+			if before.rule.Precedence == r.rule.Precedence {
+				msg += " with the same precedence, order of them is not decided"
+			}
+			errs = append(errs, RuleError{Service: service, Index: r.index, Field: "precedence",
+				Severity: SeverityWarning, Message: msg})
+			break
+		}
+	}
+	return errs
+}
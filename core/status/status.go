@@ -25,6 +25,7 @@ const (
 
 	InternalServerError = "InternalServerError"
 	ServiceUnavailable  = "ServiceUnavailable"
+	TooManyRequests     = "TooManyRequests"
 	//TODO more status key
 )
 
@@ -33,6 +34,7 @@ var defaultStatus = map[string]int{
 
 	InternalServerError: http.StatusInternalServerError,
 	ServiceUnavailable:  http.StatusServiceUnavailable,
+	TooManyRequests:     http.StatusTooManyRequests,
 	//TODO more default status
 }
 
@@ -0,0 +1,22 @@
+package eventlistener
+
+import (
+	"github.com/go-chassis/go-archaius/event"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-mesh/openlogging"
+)
+
+//BulkheadKey matches bulkhead events
+const BulkheadKey = "^cse\\.bulkhead\\."
+
+//BulkheadEventListener clears bulkhead config cache,
+//bulkheads are resized with new config when next call comes
+type BulkheadEventListener struct {
+	Key string
+}
+
+//Event is a method used to handle a bulkhead event
+func (e *BulkheadEventListener) Event(evt *event.Event) {
+	openlogging.GetLogger().Debugf("bulkhead event, key: %s, type: %s", evt.Key, evt.EventType)
+	servicecomb.BulkheadConfigCache.Flush()
+}
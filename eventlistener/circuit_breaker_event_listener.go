@@ -8,6 +8,7 @@ import (
 	"github.com/go-chassis/go-chassis/control/servicecomb"
 	"github.com/go-chassis/go-chassis/core/common"
 	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/resilience/breaker"
 	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
 	"github.com/go-mesh/openlogging"
 )
@@ -19,8 +20,8 @@ const (
 	ConsumerCircuitbreakerKey = "cse.circuitBreaker"
 	ConsumerFallbackKey       = "cse.fallback"
 	ConsumerFallbackPolicyKey = "cse.fallbackpolicy"
-	regex4normal              = "cse\\.(isolation|circuitBreaker|fallback|fallbackpolicy)\\.Consumer\\.(.*)\\.(timeout|timeoutInMilliseconds|maxConcurrentRequests|enabled|forceOpen|forceClosed|sleepWindowInMilliseconds|requestVolumeThreshold|errorThresholdPercentage|enabled|maxConcurrentRequests|policy)\\.(.+)"
-	regex4mesher              = "cse\\.(isolation|circuitBreaker|fallback|fallbackpolicy)\\.(.+)\\.Consumer\\.(.*)\\.(timeout|timeoutInMilliseconds|maxConcurrentRequests|enabled|forceOpen|forceClosed|sleepWindowInMilliseconds|requestVolumeThreshold|errorThresholdPercentage|enabled|maxConcurrentRequests|policy)\\.(.+)"
+	regex4normal              = "cse\\.(isolation|circuitBreaker|fallback|fallbackpolicy)\\.Consumer\\.(.*)\\.(timeout|timeoutInMilliseconds|maxConcurrentRequests|enabled|forceOpen|forceClosed|sleepWindowInMilliseconds|requestVolumeThreshold|errorThresholdPercentage|windowType|windowSize|slowCallDurationInMilliseconds|slowCallRateThreshold|halfOpenRequests|enabled|maxConcurrentRequests|policy)\\.(.+)"
+	regex4mesher              = "cse\\.(isolation|circuitBreaker|fallback|fallbackpolicy)\\.(.+)\\.Consumer\\.(.*)\\.(timeout|timeoutInMilliseconds|maxConcurrentRequests|enabled|forceOpen|forceClosed|sleepWindowInMilliseconds|requestVolumeThreshold|errorThresholdPercentage|windowType|windowSize|slowCallDurationInMilliseconds|slowCallRateThreshold|halfOpenRequests|enabled|maxConcurrentRequests|policy)\\.(.+)"
 )
 
 //CircuitBreakerEventListener is a struct with one string variable
@@ -37,6 +38,7 @@ func (el *CircuitBreakerEventListener) Event(e *event.Event) {
 		openlogging.Error("can not unmarshal new cb config: " + err.Error())
 	}
 	servicecomb.SaveToCBCache(config.GetHystrixConfig())
+	servicecomb.BreakerConfigCache.Flush()
 	switch e.EventType {
 	case common.Update:
 		FlushCircuitByKey(e.Key)
@@ -54,9 +56,11 @@ func FlushCircuitByKey(key string) {
 	if cmdName == common.Consumer {
 		openlogging.Info("Global Key changed For circuit: [" + cmdName + "], will flush all circuit")
 		hystrix.Flush()
+		breaker.Flush()
 	} else {
 		openlogging.Info("Specific Key changed For circuit: [" + cmdName + "], will only flush this circuit")
 		hystrix.FlushByName(cmdName)
+		breaker.FlushByName(cmdName)
 	}
 
 }
@@ -23,6 +23,8 @@ func Init() {
 	RegisterKeys(qpsEventListener, QPSLimitKey)
 	RegisterKeys(circuitBreakerEventListener, ConsumerFallbackKey, ConsumerFallbackPolicyKey, ConsumerIsolationKey, ConsumerCircuitbreakerKey)
 	RegisterKeys(lbEventListener, LoadBalanceKey)
+	RegisterKeys(&BulkheadEventListener{}, BulkheadKey)
+	RegisterKeys(&HedgingEventListener{}, HedgingKey)
 	RegisterKeys(&LagerEventListener{}, LagerLevelKey)
 
 }
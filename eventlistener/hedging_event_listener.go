@@ -0,0 +1,21 @@
+package eventlistener
+
+import (
+	"github.com/go-chassis/go-archaius/event"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-mesh/openlogging"
+)
+
+//HedgingKey matches hedging events
+const HedgingKey = "^cse\\.hedging\\."
+
+//HedgingEventListener clears hedging config cache
+type HedgingEventListener struct {
+	Key string
+}
+
+//Event is a method used to handle a hedging event
+func (e *HedgingEventListener) Event(evt *event.Event) {
+	openlogging.GetLogger().Debugf("hedging event, key: %s, type: %s", evt.Key, evt.EventType)
+	servicecomb.HedgingConfigCache.Flush()
+}
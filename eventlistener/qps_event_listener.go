@@ -26,7 +26,8 @@ type QPSEventListener struct {
 func (el *QPSEventListener) Event(e *event.Event) {
 	qpsLimiter := rate.GetRateLimiters()
 
-	if strings.Contains(e.Key, "enabled") {
+	//only limit changes, other keys like enabled, mode and distributed store are read when they are used
+	if strings.Contains(e.Key, "enabled") || !strings.Contains(e.Key, ".limit") {
 		return
 	}
 	qps, ok := e.Value.(int)
@@ -1,16 +1,21 @@
 module github.com/go-chassis/go-chassis
 
 require (
+	github.com/alicebob/miniredis/v2 v2.11.0
 	github.com/apache/servicecomb-service-center v0.0.0-20200723031815-784c3533a8f2
 	github.com/cenkalti/backoff v2.0.0+incompatible
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/emicklei/go-restful v2.12.0+incompatible
+	github.com/envoyproxy/go-control-plane v0.9.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-chassis/foundation v0.1.1-0.20191113114104-2b05871e9ec4
 	github.com/go-chassis/go-archaius v1.3.2
 	github.com/go-chassis/go-restful-swagger20 v1.0.3-0.20200310030431-17d80f34264f
 	github.com/go-chassis/paas-lager v1.1.1
 	github.com/go-mesh/openlogging v1.0.1
+	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/golang/protobuf v1.3.2
+	github.com/gomodule/redigo v2.0.0+incompatible // indirect
 	github.com/gorilla/websocket v1.4.0
 	github.com/hashicorp/go-version v1.0.0
 	github.com/opentracing/opentracing-go v1.1.0
@@ -19,7 +24,10 @@ require (
 	github.com/prometheus/common v0.2.0
 	github.com/smartystreets/goconvey v0.0.0-20190330032615-68dc04aab96a
 	github.com/stretchr/testify v1.4.0
+	github.com/yuin/gopher-lua v0.0.0-20190514113301-1cd887cd7036 // indirect
+	google.golang.org/grpc v1.23.0
 	gopkg.in/yaml.v2 v2.2.4
+	k8s.io/api v0.17.0
 	k8s.io/apimachinery v0.17.0
 	k8s.io/client-go v0.17.0
 	k8s.io/kube-openapi v0.0.0-20191107075043-30be4d16710a // indirect
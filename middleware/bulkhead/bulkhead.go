@@ -0,0 +1,132 @@
+//Package bulkhead supplies handlers which limit concurrent calls of each service or api
+package bulkhead
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/status"
+	"github.com/go-chassis/go-chassis/pkg/metrics"
+	"github.com/go-chassis/go-chassis/resilience/bulkhead"
+	"github.com/go-mesh/openlogging"
+)
+
+// names
+const (
+	Consumer = "bulkhead-consumer"
+	Provider = "bulkhead-provider"
+)
+
+//MetricRejected is the counter of rejected calls, reason is full or timeout
+const MetricRejected = "bulkhead_rejected_total"
+
+var (
+	metricsMu      sync.Mutex
+	metricsCreated bool
+)
+
+//Handler limits concurrent calls, calls are rejected with service unavailable if bulkhead is full
+type Handler struct {
+	serviceType string
+}
+
+//bulkheadConfig get config from panel, or from archaius if panel does not provide it
+func bulkheadConfig(i *invocation.Invocation, serviceType string) control.BulkheadConfig {
+	if p, ok := control.DefaultPanel.(control.BulkheadPanel); ok {
+		return p.GetBulkhead(*i, serviceType)
+	}
+	return new(servicecomb.Panel).GetBulkhead(*i, serviceType)
+}
+
+// Handle limits concurrent calls
+func (h *Handler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	//shadow requests of mirroring must not take slots of caller
+	if i.IsMirror() {
+		chain.Next(i, cb)
+		return
+	}
+	c := bulkheadConfig(i, h.serviceType)
+	if !c.Enabled {
+		chain.Next(i, cb)
+		return
+	}
+	b := bulkhead.Get(c.Name, bulkhead.Settings{
+		Mode:               c.Mode,
+		MaxConcurrentCalls: c.MaxConcurrentCalls,
+		MaxWaitingCalls:    c.MaxWaitingCalls,
+		MaxWaitDuration:    c.MaxWaitDuration,
+	})
+	ctx := i.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := b.Acquire(ctx); err != nil {
+		reportRejected(c.Name, err)
+		handler.WriteBackErr(err, status.Status(i.Protocol, status.ServiceUnavailable), cb)
+		return
+	}
+	defer b.Release()
+	chain.Next(i, cb)
+}
+
+// Name returns name
+func (h *Handler) Name() string {
+	if h.serviceType == common.Provider {
+		return Provider
+	}
+	return Consumer
+}
+
+//createMetrics creates metrics if they are not created, it does nothing before metrics are initialized
+func createMetrics() {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if metricsCreated {
+		return
+	}
+	if err := metrics.CreateCounter(metrics.CounterOpts{
+		Name:   MetricRejected,
+		Help:   "total calls rejected by bulkhead",
+		Labels: []string{"name", "reason"},
+	}); err != nil {
+		openlogging.GetLogger().Debugf("can not create bulkhead metrics: %s", err)
+		return
+	}
+	metricsCreated = true
+}
+
+func reportRejected(name string, err error) {
+	reason := "timeout"
+	if err == bulkhead.ErrFull {
+		reason = "full"
+	}
+	if err := metrics.CounterAdd(MetricRejected, 1, map[string]string{"name": name, "reason": reason}); err != nil {
+		openlogging.GetLogger().Debugf("report bulkhead metric failed: %s", err)
+	}
+}
+
+func newConsumerHandler() handler.Handler {
+	createMetrics()
+	return &Handler{serviceType: common.Consumer}
+}
+
+func newProviderHandler() handler.Handler {
+	createMetrics()
+	return &Handler{serviceType: common.Provider}
+}
+
+func init() {
+	err := handler.RegisterHandler(Consumer, newConsumerHandler)
+	if err != nil {
+		openlogging.Error(err.Error())
+	}
+	err = handler.RegisterHandler(Provider, newProviderHandler)
+	if err != nil {
+		openlogging.Error(err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+package bulkhead_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-archaius/event"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/eventlistener"
+	"github.com/go-chassis/go-chassis/middleware/bulkhead"
+	rb "github.com/go-chassis/go-chassis/resilience/bulkhead"
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/go-chassis/go-chassis/control/servicecomb"
+)
+
+//nestedHandler calls chain again inside the call, so that there are 2 concurrent calls
+type nestedHandler struct {
+	nested func() *invocation.Response
+	inner  *invocation.Response
+}
+
+func (h *nestedHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	if h.nested != nil {
+		nested := h.nested
+		h.nested = nil
+		h.inner = nested()
+	}
+	cb(&invocation.Response{Status: http.StatusOK})
+}
+
+func (h *nestedHandler) Name() string {
+	return "nested"
+}
+
+func TestHandler(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	assert.NoError(t, control.Init(control.Options{}))
+	archaius.Set("cse.bulkhead.Consumer.Server.enabled", true)
+	archaius.Set("cse.bulkhead.Consumer.Server.maxConcurrentCalls", 1)
+	defer rb.Flush()
+
+	bh, err := handler.CreateHandler(bulkhead.Consumer)
+	assert.NoError(t, err)
+	h := &nestedHandler{}
+	call := func() *invocation.Response {
+		c := &handler.Chain{}
+		c.AddHandler(bh)
+		c.AddHandler(h)
+		i := &invocation.Invocation{MicroServiceName: "Server", Protocol: common.ProtocolRest}
+		var resp *invocation.Response
+		c.Next(i, func(r *invocation.Response) {
+			resp = r
+		})
+		return resp
+	}
+	t.Run("concurrent call is rejected", func(t *testing.T) {
+		h.nested = call
+		assert.Equal(t, http.StatusOK, call().Status)
+		assert.Equal(t, rb.ErrFull, h.inner.Err)
+		assert.Equal(t, http.StatusServiceUnavailable, h.inner.Status)
+	})
+	t.Run("config changes", func(t *testing.T) {
+		archaius.Set("cse.bulkhead.Consumer.Server.maxConcurrentCalls", 2)
+		l := &eventlistener.BulkheadEventListener{}
+		l.Event(&event.Event{EventType: common.Update, Key: "cse.bulkhead.Consumer.Server.maxConcurrentCalls", Value: 2})
+		h.nested = call
+		assert.Equal(t, http.StatusOK, call().Status)
+		assert.NoError(t, h.inner.Err)
+	})
+	t.Run("other service is not limited", func(t *testing.T) {
+		h.nested = call
+		c := &handler.Chain{}
+		c.AddHandler(bh)
+		c.AddHandler(h)
+		c.Next(&invocation.Invocation{MicroServiceName: "Other"}, func(r *invocation.Response) {
+			assert.NoError(t, r.Err)
+		})
+	})
+	t.Run("config is read from archaius if panel does not provide it", func(t *testing.T) {
+		old := control.DefaultPanel
+		control.DefaultPanel = struct{ control.Panel }{old}
+		defer func() { control.DefaultPanel = old }()
+		archaius.Set("cse.bulkhead.Consumer.Server.maxConcurrentCalls", 1)
+		l := &eventlistener.BulkheadEventListener{}
+		l.Event(&event.Event{EventType: common.Update, Key: "cse.bulkhead.Consumer.Server.maxConcurrentCalls", Value: 1})
+		h.nested = call
+		assert.Equal(t, http.StatusOK, call().Status)
+		assert.Equal(t, rb.ErrFull, h.inner.Err)
+	})
+}
@@ -22,10 +22,13 @@ type BizKeeperConsumerHandler struct{}
 
 // Handle function is for to handle the chain
 func (bk *BizKeeperConsumerHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	//shadow requests of mirroring must not change circuit state of caller
+	if i.IsMirror() {
+		chain.Next(i, cb)
+		return
+	}
 	command, cmdConfig := control.DefaultPanel.GetCircuitBreaker(*i, common.Consumer)
-
 	cmdConfig.MetricsConsumerNum = archaius.GetInt("cse.metrics.circuitMetricsConsumerNum", hystrix.DefaultMetricsConsumerNum)
-	hystrix.ConfigureCommand(command, cmdConfig)
 
 	finish := make(chan *invocation.Response, 1)
 	f, err := GetFallbackFun(command, common.Consumer, i, finish, cmdConfig.ForceFallback)
@@ -33,13 +36,13 @@ func (bk *BizKeeperConsumerHandler) Handle(chain *handler.Chain, i *invocation.I
 		handler.WriteBackErr(err, status.Status(i.Protocol, status.InternalServerError), cb)
 		return
 	}
-	err = hystrix.Do(command, func() (err error) {
+	err = run(i, common.Consumer, command, cmdConfig, func() (err error) {
 		chain.Next(i, func(resp *invocation.Response) {
 			err = resp.Err
 			select {
 			case finish <- resp:
 			default:
-				// means hystrix error occurred
+				// means circuit error occurred
 			}
 		})
 		return
@@ -48,7 +51,7 @@ func (bk *BizKeeperConsumerHandler) Handle(chain *handler.Chain, i *invocation.I
 	// err is not nil in conditions:
 	// 1 fallback is nil
 	//   1.1 chain.Next() fail
-	//   1.2 hystrix mechanism, retur error as ErrMaxConcurrency / ErrCircuitOpen / ErrForceFallback
+	//   1.2 circuit mechanism, retur error as ErrMaxConcurrency / ErrCircuitOpen / ErrForceFallback
 	// 2 fallback is not nil
 	//   2.1 fallback failed no matter chain.Next() is executed or not
 	if err != nil {
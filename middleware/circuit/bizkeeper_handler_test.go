@@ -7,8 +7,10 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/go-chassis/go-archaius"
 	"github.com/go-chassis/go-chassis/control"
 	_ "github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/common"
 	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/config/model"
 	"github.com/go-chassis/go-chassis/core/handler"
@@ -18,6 +20,7 @@ import (
 	_ "github.com/go-chassis/go-chassis/initiator"
 	"github.com/go-chassis/go-chassis/middleware/circuit"
 	"github.com/go-chassis/go-chassis/pkg/util/fileutil"
+	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -136,6 +139,47 @@ func TestBizKeeperProviderHandler_Handle(t *testing.T) {
 	})
 }
 
+func TestBizKeeperConsumerHandler_Engine(t *testing.T) {
+	c := handler.Chain{}
+	c.AddHandler(&circuit.BizKeeperConsumerHandler{})
+	call := func(service string) string {
+		i := &invocation.Invocation{
+			MicroServiceName: service,
+			SchemaID:         "schema1",
+			OperationID:      "SayHello",
+			Args:             &helloworld.HelloRequest{Name: "peter"},
+		}
+		c.Next(i, func(r *invocation.Response) {
+			assert.NoError(t, r.Err)
+		})
+		command, _ := control.DefaultPanel.GetCircuitBreaker(*i, common.Consumer)
+		return command
+	}
+
+	t.Run("hystrix is default engine", func(t *testing.T) {
+		_, created, err := hystrix.GetCircuit(call("engine1"))
+		assert.NoError(t, err)
+		assert.False(t, created)
+	})
+	t.Run("native engine does not create hystrix circuit", func(t *testing.T) {
+		archaius.Set(config.CircuitBreakerEngineKey, config.CircuitBreakerEngineNative)
+		defer archaius.Delete(config.CircuitBreakerEngineKey)
+		_, created, err := hystrix.GetCircuit(call("engine2"))
+		assert.NoError(t, err)
+		assert.True(t, created)
+	})
+	t.Run("native engine works if panel does not provide breaker config", func(t *testing.T) {
+		archaius.Set(config.CircuitBreakerEngineKey, config.CircuitBreakerEngineNative)
+		defer archaius.Delete(config.CircuitBreakerEngineKey)
+		old := control.DefaultPanel
+		control.DefaultPanel = struct{ control.Panel }{old}
+		defer func() { control.DefaultPanel = old }()
+		_, created, err := hystrix.GetCircuit(call("engine3"))
+		assert.NoError(t, err)
+		assert.True(t, created)
+	})
+}
+
 func TestBizKeeperHandler_Names(t *testing.T) {
 	bizPro := &circuit.BizKeeperProviderHandler{}
 	proName := bizPro.Name()
@@ -9,7 +9,6 @@ import (
 	"github.com/go-chassis/go-chassis/core/status"
 
 	"github.com/go-chassis/go-chassis/control"
-	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
 )
 
 var errNextChainNoResponse = errors.New("hystrix next chain not respond")
@@ -20,10 +19,9 @@ type BizKeeperProviderHandler struct{}
 // Handle handler for bizkeeper provider
 func (bk *BizKeeperProviderHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
 	command, cmdConfig := control.DefaultPanel.GetCircuitBreaker(*i, common.Provider)
-	hystrix.ConfigureCommand(command, cmdConfig)
 
 	var r *invocation.Response
-	err := hystrix.Do(command, func() (err error) {
+	err := run(i, common.Provider, command, cmdConfig, func() (err error) {
 		chain.Next(i, func(resp *invocation.Response) {
 			r = resp
 			if resp == nil {
@@ -42,7 +40,7 @@ func (bk *BizKeeperProviderHandler) Handle(chain *handler.Chain, i *invocation.I
 
 	// when fallback is nil, err not nil only when:
 	// 1. chain.Next() is executed and resp.Err is not nil
-	// 2. error generated by hystrix mechanism, such as ErrMaxConcurrency / ErrCircuitOpen / ErrForceFallback
+	// 2. error generated by circuit mechanism, such as ErrMaxConcurrency / ErrCircuitOpen / ErrForceFallback
 	//    in this case chain.Next() is not executed (r == nil)
 	if r == nil {
 		handler.WriteBackErr(err, status.Status(i.Protocol, status.ServiceUnavailable), cb)
@@ -3,7 +3,11 @@ package circuit
 import (
 	"errors"
 	"fmt"
+	"github.com/go-chassis/go-chassis/control"
+	"github.com/go-chassis/go-chassis/control/servicecomb"
+	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/resilience/breaker"
 	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
 	"github.com/go-mesh/openlogging"
 	"io"
@@ -37,6 +41,32 @@ func Init() {
 	fallbackFuncMap[ReturnNil] = FallbackNil
 }
 
+//run executes a call with circuit of command, hystrix is used unless native engine is configured,
+//native circuit breaker does not report metrics yet
+func run(i *invocation.Invocation, serviceType, command string, c hystrix.CommandConfig,
+	f func() error, fallback func(error) error) error {
+	if config.GetCircuitBreakerEngine() != config.CircuitBreakerEngineNative {
+		hystrix.ConfigureCommand(command, c)
+		return hystrix.Do(command, f, fallback)
+	}
+	s := breaker.FromCommandConfig(c)
+	bc := breakerConfig(i, serviceType)
+	s.WindowType = bc.WindowType
+	s.WindowSize = bc.WindowSize
+	s.SlowCallDuration = bc.SlowCallDuration
+	s.SlowCallRateThreshold = bc.SlowCallRateThreshold
+	s.HalfOpenRequests = bc.HalfOpenRequests
+	return breaker.Do(command, s, f, fallback)
+}
+
+//breakerConfig get config from panel, or from archaius if panel does not provide it
+func breakerConfig(i *invocation.Invocation, serviceType string) control.BreakerConfig {
+	if p, ok := control.DefaultPanel.(control.BreakerPanel); ok {
+		return p.GetBreaker(*i, serviceType)
+	}
+	return new(servicecomb.Panel).GetBreaker(*i, serviceType)
+}
+
 //RegisterFallback register custom logic
 func RegisterFallback(name string, f Fallback) {
 	fallbackFuncMap[name] = f
@@ -0,0 +1,158 @@
+//Package concurrencylimiter supplies a provider handler which limits concurrent calls adaptively,
+//limit is adjusted by latency, so that it is not necessary to tune qps limit for each service
+package concurrencylimiter
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-archaius/event"
+	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/status"
+	"github.com/go-chassis/go-chassis/pkg/metrics"
+	"github.com/go-chassis/go-chassis/resilience/limit"
+	"github.com/go-mesh/openlogging"
+)
+
+// names
+const (
+	Provider = "concurrencylimiter-provider"
+	//ConfigKey matches config of concurrency limit
+	ConfigKey = "^cse\\.concurrencyLimit\\."
+)
+
+//metrics
+const (
+	MetricLimit    = "concurrency_limit"
+	MetricRejected = "concurrency_limit_rejected_total"
+)
+
+//ErrLimitExceeded means running calls reach the limit
+var ErrLimitExceeded = errors.New("concurrency limit exceeded")
+
+//all of provider chains share one limiter, config is reloaded when it changes
+var (
+	once      sync.Once
+	limiter   *limit.Limiter
+	enabled   int32
+	lastLimit int64
+
+	metricsMu      sync.Mutex
+	metricsCreated bool
+)
+
+//ProviderHandler limits concurrent calls of provider
+type ProviderHandler struct{}
+
+// Handle rejects call with too many requests if limit is reached
+func (h *ProviderHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	if atomic.LoadInt32(&enabled) == 0 {
+		chain.Next(i, cb)
+		return
+	}
+	t, ok := limiter.Acquire()
+	if !ok {
+		report(MetricRejected, i.MicroServiceName, 1)
+		handler.WriteBackErr(ErrLimitExceeded, status.Status(i.Protocol, status.TooManyRequests), cb)
+		return
+	}
+	dropped := false
+	chain.Next(i, func(r *invocation.Response) {
+		//the call is overloaded by upstream, status 0 means protocol has no such status
+		dropped = r != nil && r.Err != nil && r.Status != 0 &&
+			(r.Status == status.Status(i.Protocol, status.TooManyRequests) ||
+				r.Status == status.Status(i.Protocol, status.ServiceUnavailable))
+		cb(r)
+	})
+	limiter.Release(t, dropped)
+	if l := int64(limiter.Limit()); atomic.SwapInt64(&lastLimit, l) != l {
+		report(MetricLimit, i.MicroServiceName, float64(l))
+	}
+}
+
+// Name returns name
+func (h *ProviderHandler) Name() string {
+	return Provider
+}
+
+func report(name, service string, val float64) {
+	var err error
+	if name == MetricLimit {
+		err = metrics.GaugeSet(name, val, map[string]string{"service": service})
+	} else {
+		err = metrics.CounterAdd(name, val, map[string]string{"service": service})
+	}
+	if err != nil {
+		openlogging.GetLogger().Debugf("report concurrency limit metric failed: %s", err)
+	}
+}
+
+//configListener reloads config
+type configListener struct{}
+
+//Event reloads config, all of config is read again, so event is not used
+func (l *configListener) Event(e *event.Event) {
+	reload()
+}
+
+func reload() {
+	var n int32
+	if config.GetConcurrencyLimitEnabled() {
+		n = 1
+	}
+	s := config.GetConcurrencyLimitSettings()
+	limiter.Update(s)
+	atomic.StoreInt32(&enabled, n)
+	openlogging.GetLogger().Infof("concurrency limit enabled: %v, algorithm: %s", n == 1, s.Algorithm)
+}
+
+func initLimiter() {
+	limiter = limit.New(config.GetConcurrencyLimitSettings())
+	reload()
+	if err := archaius.RegisterListener(&configListener{}, ConfigKey); err != nil {
+		openlogging.Error(err.Error())
+	}
+}
+
+//createMetrics creates metrics if they are not created, it does nothing before metrics are initialized
+func createMetrics() {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if metricsCreated {
+		return
+	}
+	if err := metrics.CreateGauge(metrics.GaugeOpts{
+		Name:   MetricLimit,
+		Help:   "current adaptive concurrency limit",
+		Labels: []string{"service"},
+	}); err != nil {
+		openlogging.GetLogger().Debugf("can not create concurrency limit metrics: %s", err)
+		return
+	}
+	if err := metrics.CreateCounter(metrics.CounterOpts{
+		Name:   MetricRejected,
+		Help:   "total calls rejected by adaptive concurrency limit",
+		Labels: []string{"service"},
+	}); err != nil {
+		openlogging.GetLogger().Debugf("can not create concurrency limit metrics: %s", err)
+		return
+	}
+	metricsCreated = true
+}
+
+func newProviderHandler() handler.Handler {
+	once.Do(initLimiter)
+	createMetrics()
+	return &ProviderHandler{}
+}
+
+func init() {
+	err := handler.RegisterHandler(Provider, newProviderHandler)
+	if err != nil {
+		openlogging.Error(err.Error())
+	}
+}
@@ -0,0 +1,69 @@
+package concurrencylimiter_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-archaius"
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/middleware/concurrencylimiter"
+	"github.com/stretchr/testify/assert"
+)
+
+//nestedHandler calls chain again inside the call, so that there are 2 concurrent calls
+type nestedHandler struct {
+	nested func() *invocation.Response
+	inner  *invocation.Response
+}
+
+func (h *nestedHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	if h.nested != nil {
+		nested := h.nested
+		h.nested = nil
+		h.inner = nested()
+	}
+	cb(&invocation.Response{Status: http.StatusOK})
+}
+
+func (h *nestedHandler) Name() string {
+	return "nested"
+}
+
+func TestProviderHandler(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	archaius.Set("cse.concurrencyLimit.Provider.enabled", true)
+	archaius.Set("cse.concurrencyLimit.Provider.algorithm", "aimd")
+	archaius.Set("cse.concurrencyLimit.Provider.initialLimit", 1)
+	archaius.Set("cse.concurrencyLimit.Provider.maxLimit", 1)
+	ch, err := handler.CreateHandler(concurrencylimiter.Provider)
+	assert.NoError(t, err)
+
+	h := &nestedHandler{}
+	call := func() *invocation.Response {
+		c := &handler.Chain{}
+		c.AddHandler(ch)
+		c.AddHandler(h)
+		var resp *invocation.Response
+		c.Next(&invocation.Invocation{MicroServiceName: "Server", Protocol: common.ProtocolRest}, func(r *invocation.Response) {
+			resp = r
+		})
+		return resp
+	}
+	t.Run("call exceeding limit is rejected", func(t *testing.T) {
+		h.nested = call
+		assert.Equal(t, http.StatusOK, call().Status)
+		assert.Equal(t, concurrencylimiter.ErrLimitExceeded, h.inner.Err)
+		assert.Equal(t, http.StatusTooManyRequests, h.inner.Status)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		archaius.Set("cse.concurrencyLimit.Provider.enabled", false)
+		//event is dispatched asynchronously
+		time.Sleep(100 * time.Millisecond)
+		h.nested = call
+		assert.Equal(t, http.StatusOK, call().Status)
+		assert.NoError(t, h.inner.Err)
+	})
+}
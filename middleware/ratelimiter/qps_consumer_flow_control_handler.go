@@ -23,6 +23,11 @@ type ConsumerRateLimiterHandler struct{}
 
 // Handle is handles the consumer rate limiter APIs
 func (rl *ConsumerRateLimiterHandler) Handle(chain *handler.Chain, i *invocation.Invocation, cb invocation.ResponseCallBack) {
+	//shadow requests of mirroring must not take tokens of caller
+	if i.IsMirror() {
+		chain.Next(i, cb)
+		return
+	}
 	rlc := control.DefaultPanel.GetRateLimiting(*i, common.Consumer)
 	if !rlc.Enabled {
 		chain.Next(i, cb)
@@ -36,6 +41,7 @@ func (rl *ConsumerRateLimiterHandler) Handle(chain *handler.Chain, i *invocation
 		return
 	}
 	//get operation meta info ms.schema, ms.schema.operation, ms
+	rate.GetRateLimiters().SetMode(rlc.Key, rlc.Mode)
 	if rate.GetRateLimiters().TryAccept(rlc.Key, rlc.Rate, rlc.Rate/5) {
 		chain.Next(i, cb)
 	} else {
@@ -25,6 +25,7 @@ func (rl *ProviderRateLimiterHandler) Handle(chain *handler.Chain, i *invocation
 		cb(r)
 		return
 	}
+	rate.GetRateLimiters().SetMode(rlc.Key, rlc.Mode)
 	if rate.GetRateLimiters().TryAccept(rlc.Key, rlc.Rate, rlc.Rate/5) {
 		chain.Next(i, cb)
 	} else {
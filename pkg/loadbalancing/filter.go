@@ -1,16 +1,20 @@
 package loadbalancing
 
 import (
+	"math/rand"
+
 	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/loadbalancer"
 	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
 )
 
 func init() {
 	loadbalancer.InstallFilter(loadbalancer.ZoneAware, FilterAvailableZoneAffinity)
 }
 
-//FilterAvailableZoneAffinity is a region and zone based Select Filter which will Do the selection of instance in the same region and zone, if not Do the selection of instance in any zone in same region , if not Do the selection of instance in any zone of any region
+//FilterAvailableZoneAffinity is a region and zone based Select Filter which will Do the selection of instance in the same region and zone, if not Do the selection of instance in any zone in same region , if not Do the selection of instance in any zone of any region.
+//if spillover threshold is set and healthy ratio of local zone is under it, part of requests spill over to other zones
 func FilterAvailableZoneAffinity(old []*registry.MicroServiceInstance, c []*loadbalancer.Criteria) []*registry.MicroServiceInstance {
 	var instances []*registry.MicroServiceInstance
 	if config.GetDataCenter() == nil {
@@ -23,6 +27,11 @@ func FilterAvailableZoneAffinity(old []*registry.MicroServiceInstance, c []*load
 	availableZone := config.GetDataCenter().AvailableZone
 	regionName := config.GetDataCenter().Name
 	instances = getInstancesZoneWise(old, regionName, availableZone)
+	if len(instances) != 0 && spillover(serviceOf(c), instances) {
+		if others := getInstancesOutOfZone(old, regionName, availableZone); len(others) != 0 {
+			return others
+		}
+	}
 	if len(instances) == 0 {
 		instances = getAvailableInstancesInSameRegion(old, regionName)
 		if len(instances) == 0 {
@@ -51,6 +60,70 @@ func getInstancesZoneWise(providerInstances []*registry.MicroServiceInstance, re
 	return instances
 }
 
+//spillover decides whether a request should go to other zones, the less healthy instances local zone has,
+//the more requests spill over. there is no spillover if healthy ratio reaches threshold
+func spillover(service string, local []*registry.MicroServiceInstance) bool {
+	threshold := config.ZoneAwareSpilloverThreshold(service)
+	if threshold <= 0 {
+		return false
+	}
+	healthy := 0
+	for _, ins := range local {
+		if isHealthy(ins) {
+			healthy++
+		}
+	}
+	ratio := healthy * 100 / len(local)
+	if ratio >= threshold {
+		return false
+	}
+	return rand.Intn(threshold) >= ratio
+}
+
+//serviceOf return target service in criteria
+func serviceOf(c []*loadbalancer.Criteria) string {
+	for _, cr := range c {
+		if cr.Key == loadbalancer.CriteriaService {
+			return cr.Value
+		}
+	}
+	return ""
+}
+
+//isHealthy checks status in registry and outlier detection
+func isHealthy(ins *registry.MicroServiceInstance) bool {
+	if ins.Status == runtime.StatusDown {
+		return false
+	}
+	for _, ep := range ins.EndpointsMap {
+		if loadbalancer.IsEjected(ep.Address) {
+			return false
+		}
+	}
+	return true
+}
+
+//getInstancesOutOfZone return healthy instances in other zones, instances in same region are preferred
+func getInstancesOutOfZone(providerInstances []*registry.MicroServiceInstance, region, availableZone string) []*registry.MicroServiceInstance {
+	sameRegion := make([]*registry.MicroServiceInstance, 0)
+	others := make([]*registry.MicroServiceInstance, 0)
+	for _, ins := range providerInstances {
+		if !isHealthy(ins) {
+			continue
+		}
+		switch {
+		case ins.DataCenterInfo == nil || ins.DataCenterInfo.Region != region:
+			others = append(others, ins)
+		case ins.DataCenterInfo.AvailableZone != availableZone:
+			sameRegion = append(sameRegion, ins)
+		}
+	}
+	if len(sameRegion) != 0 {
+		return sameRegion
+	}
+	return others
+}
+
 // getAvailableInstancesInSameRegion check for available instances in same region
 func getAvailableInstancesInSameRegion(providerInstances []*registry.MicroServiceInstance, region string) []*registry.MicroServiceInstance {
 	instances := make([]*registry.MicroServiceInstance, 0)
@@ -7,6 +7,7 @@ import (
 	"github.com/go-chassis/go-chassis/core/loadbalancer"
 	"github.com/go-chassis/go-chassis/core/registry"
 	"github.com/go-chassis/go-chassis/pkg/loadbalancing"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -84,3 +85,61 @@ func TestFilterAvailableZoneAffinity(t *testing.T) {
 	assert.NotEqual(t, 0, len(instances))
 
 }
+
+func TestFilterAvailableZoneAffinity_Spillover(t *testing.T) {
+	config.GlobalDefinition.DataCenter.AvailableZone = "az-1"
+	config.GlobalDefinition.DataCenter.Name = "region-1"
+	archaius.Set("cse.loadbalance.zoneAware.spilloverThreshold", 50)
+	defer archaius.Delete("cse.loadbalance.zoneAware.spilloverThreshold")
+
+	newInstance := func(id, region, az string) *registry.MicroServiceInstance {
+		return &registry.MicroServiceInstance{
+			InstanceID:     id,
+			EndpointsMap:   map[string]*registry.Endpoint{"rest": {Address: id}},
+			DataCenterInfo: &registry.DataCenterInfo{Region: region, AvailableZone: az},
+		}
+	}
+	testData := []*registry.MicroServiceInstance{
+		newInstance("local-1", "region-1", "az-1"),
+		newInstance("local-2", "region-1", "az-1"),
+		newInstance("local-3", "region-1", "az-1"),
+		newInstance("local-4", "region-1", "az-1"),
+		newInstance("region-1", "region-1", "az-2"),
+		newInstance("other-1", "region-2", "az-3"),
+	}
+
+	//healthy ratio 50% reaches threshold
+	testData[0].Status = runtime.StatusDown
+	testData[1].Status = runtime.StatusDown
+	for i := 0; i < 20; i++ {
+		instances := loadbalancing.FilterAvailableZoneAffinity(testData, nil)
+		assert.Equal(t, 4, len(instances))
+	}
+
+	//healthy ratio 25%, half of requests spill over to same region
+	testData[2].Status = runtime.StatusDown
+	spilled := 0
+	for i := 0; i < 1000; i++ {
+		instances := loadbalancing.FilterAvailableZoneAffinity(testData, nil)
+		if instances[0].InstanceID == "region-1" {
+			assert.Equal(t, 1, len(instances))
+			spilled++
+		}
+	}
+	assert.InDelta(t, 500, spilled, 100)
+
+	//no healthy instance in local zone
+	testData[3].Status = runtime.StatusDown
+	instances := loadbalancing.FilterAvailableZoneAffinity(testData, nil)
+	assert.Equal(t, "region-1", instances[0].InstanceID)
+
+	//threshold of service overrides global one
+	archaius.Set("cse.loadbalance.Server.zoneAware.spilloverThreshold", 0)
+	defer archaius.Delete("cse.loadbalance.Server.zoneAware.spilloverThreshold")
+	testData[3].Status = runtime.StatusRunning
+	c := []*loadbalancer.Criteria{{Key: loadbalancer.CriteriaService, Operator: loadbalancer.OperatorEqual, Value: "Server"}}
+	for i := 0; i < 20; i++ {
+		instances := loadbalancing.FilterAvailableZoneAffinity(testData, c)
+		assert.Equal(t, 4, len(instances))
+	}
+}
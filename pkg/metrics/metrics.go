@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"github.com/go-chassis/go-archaius"
 	"github.com/prometheus/client_golang/prometheus"
@@ -29,43 +30,70 @@ type Registry interface {
 
 var defaultRegistry Registry
 
+//ErrNotInitialized means Init is not called
+var ErrNotInitialized = errors.New("metrics registry is not initialized")
+
 //CreateGauge init a new gauge type
 func CreateGauge(opts GaugeOpts) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.CreateGauge(opts)
 }
 
 //CreateCounter init a new counter type
 func CreateCounter(opts CounterOpts) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.CreateCounter(opts)
 }
 
 //CreateSummary init a new summary type
 func CreateSummary(opts SummaryOpts) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.CreateSummary(opts)
 }
 
 //CreateHistogram init a new summary type
 func CreateHistogram(opts HistogramOpts) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.CreateHistogram(opts)
 }
 
 //GaugeSet set a new value to a collector
 func GaugeSet(name string, val float64, labels map[string]string) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.GaugeSet(name, val, labels)
 }
 
 //CounterAdd increase value of a collector
 func CounterAdd(name string, val float64, labels map[string]string) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.CounterAdd(name, val, labels)
 }
 
 //SummaryObserve gives a value to summary collector
 func SummaryObserve(name string, val float64, labels map[string]string) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.SummaryObserve(name, val, labels)
 }
 
 //HistogramObserve gives a value to histogram collector
 func HistogramObserve(name string, val float64, labels map[string]string) error {
+	if defaultRegistry == nil {
+		return ErrNotInitialized
+	}
 	return defaultRegistry.HistogramObserve(name, val, labels)
 }
 
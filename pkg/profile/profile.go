@@ -1,10 +1,15 @@
 package profile
 
 import (
+	"net/http"
+
 	"github.com/emicklei/go-restful"
+	"github.com/go-chassis/go-chassis/core/common"
 	"github.com/go-chassis/go-chassis/core/config"
+	"github.com/go-chassis/go-chassis/core/invocation"
 	"github.com/go-chassis/go-chassis/core/registry"
 	"github.com/go-chassis/go-chassis/core/router"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
 	"github.com/go-mesh/openlogging"
 )
 
@@ -17,6 +22,7 @@ const (
 type Profile struct {
 	RouteRule map[string][]*config.RouteRule              `json:"routeRule"`
 	Discovery map[string][]*registry.MicroServiceInstance `json:"discovery"`
+	Snapshot  registry.SnapshotStatus                     `json:"snapshot"`
 }
 
 // HTTPHandleRouteRuleFunc is a go-restful handler which can expose profile of route rule in http server
@@ -26,6 +32,87 @@ func HTTPHandleRouteRuleFunc(req *restful.Request, rep *restful.Response) {
 	}
 }
 
+// ExplainRequest describes a request whose route is explained
+type ExplainRequest struct {
+	Service     string            `json:"service"`
+	Source      string            `json:"source"`
+	SourceTags  map[string]string `json:"sourceTags"`
+	Headers     map[string]string `json:"headers"`
+	Method      string            `json:"method"`
+	Path        string            `json:"path"` //path can contain query
+	SchemaID    string            `json:"schemaID"`
+	OperationID string            `json:"operationID"`
+}
+
+// HTTPHandleRouteRuleExplainFunc is a go-restful handler which explains which route rule and tag a request goes to
+func HTTPHandleRouteRuleExplainFunc(req *restful.Request, rep *restful.Response) {
+	r := &ExplainRequest{}
+	if err := req.ReadEntity(r); err != nil {
+		writeError(rep, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if r.Service == "" {
+		writeError(rep, http.StatusBadRequest, "service is empty")
+		return
+	}
+	e, err := explain(r)
+	if err != nil {
+		writeError(rep, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := rep.WriteAsJson(e); err != nil {
+		openlogging.Error(msgWriteError + err.Error())
+	}
+}
+
+// HTTPHandleRouteRuleValidateFunc is a go-restful handler which reports problems of route rules in use
+func HTTPHandleRouteRuleValidateFunc(req *restful.Request, rep *restful.Response) {
+	errs := router.Validate(listRouteRule())
+	if errs == nil {
+		errs = []router.RuleError{}
+	}
+	if err := rep.WriteAsJson(errs); err != nil {
+		openlogging.Error(msgWriteError + err.Error())
+	}
+}
+
+func writeError(rep *restful.Response, status int, msg string) {
+	if err := rep.WriteErrorString(status, msg); err != nil {
+		openlogging.Error(msgWriteError + err.Error())
+	}
+}
+
+//explain builds invocation like router handler does, and explains route of it
+func explain(r *ExplainRequest) (*router.Explanation, error) {
+	inv := &invocation.Invocation{
+		MicroServiceName:   r.Service,
+		SourceMicroService: r.Source,
+		SchemaID:           r.SchemaID,
+		OperationID:        r.OperationID,
+	}
+	if r.Method != "" || r.Path != "" {
+		method := r.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		req, err := http.NewRequest(method, "cse://"+r.Service+r.Path, nil)
+		if err != nil {
+			return nil, err
+		}
+		inv.Args = req
+	}
+	tags := make(map[string]string, len(r.SourceTags)+1)
+	tags[common.BuildinTagApp] = runtime.App
+	for k, v := range r.SourceTags {
+		tags[k] = v
+	}
+	headers := r.Headers
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	return router.Explain(r.Service, headers, &registry.SourceInfo{Name: r.Source, Tags: tags}, inv), nil
+}
+
 // HTTPHandleDiscoveryFunc is a go-restful handler which can expose profile of discovery in http server
 func HTTPHandleDiscoveryFunc(req *restful.Request, rep *restful.Response) {
 	if err := rep.WriteAsJson(listMicroServiceInstance()); err != nil {
@@ -33,6 +120,13 @@ func HTTPHandleDiscoveryFunc(req *restful.Request, rep *restful.Response) {
 	}
 }
 
+// HTTPHandleSnapshotFunc is a go-restful handler which can expose whether discovery uses instances from snapshot
+func HTTPHandleSnapshotFunc(req *restful.Request, rep *restful.Response) {
+	if err := rep.WriteAsJson(registry.GetSnapshotStatus()); err != nil {
+		openlogging.Error(msgWriteError + err.Error())
+	}
+}
+
 // HTTPHandleProfileFunc is a go-restful handler which can expose all profiles in http server
 func HTTPHandleProfileFunc(req *restful.Request, rep *restful.Response) {
 	if err := rep.WriteAsJson(newProfile()); err != nil {
@@ -44,6 +138,7 @@ func newProfile() Profile {
 	return Profile{
 		RouteRule: listRouteRule(),
 		Discovery: listMicroServiceInstance(),
+		Snapshot:  registry.GetSnapshotStatus(),
 	}
 }
 
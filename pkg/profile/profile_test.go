@@ -1,6 +1,13 @@
 package profile
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"github.com/go-chassis/go-archaius"
 	"github.com/go-chassis/go-chassis/core/config"
 	"github.com/go-chassis/go-chassis/core/registry"
 	"github.com/go-chassis/go-chassis/core/router"
@@ -10,6 +17,7 @@ import (
 )
 
 func TestProfile(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
 	err := router.BuildRouter("cse")
 	assert.NoError(t, err)
 	rr := map[string][]*config.RouteRule{"test": {{Precedence: 10}}}
@@ -22,4 +30,49 @@ func TestProfile(t *testing.T) {
 
 	assert.Equal(t, 10, p.RouteRule["test"][0].Precedence)
 	assert.Equal(t, "id", p.Discovery["test"][0].InstanceID)
+	assert.False(t, p.Snapshot.Enabled)
+}
+
+func TestHTTPHandleRouteRuleExplainFunc(t *testing.T) {
+	archaius.Init(archaius.WithMemorySource())
+	err := router.BuildRouter("cse")
+	assert.NoError(t, err)
+	router.DefaultRouter.SetRouteRule(map[string][]*config.RouteRule{"test": {{
+		Routes: []*config.RouteTag{{Weight: 100, Tags: map[string]string{"version": "2.0"}}},
+		Match:  config.Match{Path: map[string]string{"prefix": "/v2"}, Query: map[string]map[string]string{"q": {"exact": "1"}}},
+	}}})
+
+	ws := new(restful.WebService)
+	ws.Route(ws.POST("/explain").To(HTTPHandleRouteRuleExplainFunc))
+	ws.Route(ws.GET("/validate").To(HTTPHandleRouteRuleValidateFunc))
+	c := restful.NewContainer()
+	c.Add(ws)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", restful.MIME_JSON)
+		w := httptest.NewRecorder()
+		c.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do(http.MethodPost, "/explain", `{"service":"test","path":"/v2/hello?q=1"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	e := &router.Explanation{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), e))
+	assert.True(t, e.Matched)
+	assert.Equal(t, "2.0", e.Tag.Tags["version"])
+
+	w = do(http.MethodPost, "/explain", `{"service":"test","path":"/v1/hello"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	e = &router.Explanation{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), e))
+	assert.False(t, e.Matched)
+	assert.Equal(t, "path does not match map[prefix:/v2]", e.Rules[0].Reason)
+
+	w = do(http.MethodPost, "/explain", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodGet, "/validate", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", strings.TrimSpace(w.Body.String()))
 }
@@ -0,0 +1,127 @@
+package httputil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+//ErrBodyNotReplayable means request body is too large to be saved, request can be sent only once
+var ErrBodyNotReplayable = errors.New("request body is not replayable")
+
+//ReplayableBody saves request body so that request can be sent again,
+//the head of body is kept in memory, and the rest is spilled to a temp file.
+//body is not read if request has GetBody, GetBody is used for each attempt
+type ReplayableBody struct {
+	req  *http.Request
+	mem  []byte
+	file *os.File
+	size int64
+}
+
+//NewReplayableBody reads body of request, memLimit is the max bytes kept in memory,
+//maxSize is the max bytes of body, if body is larger than maxSize, ErrBodyNotReplayable is returned,
+//and request body is restored so that the request can still be sent once
+func NewReplayableBody(req *http.Request, memLimit, maxSize int64) (*ReplayableBody, error) {
+	b := &ReplayableBody{req: req}
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return b, nil
+	}
+	if maxSize < memLimit {
+		maxSize = memLimit
+	}
+	mem, err := ioutil.ReadAll(io.LimitReader(req.Body, memLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(mem)) <= memLimit {
+		b.mem = mem
+		b.size = int64(len(mem))
+		req.Body.Close()
+		return b, nil
+	}
+	if maxSize == memLimit {
+		req.Body = &readCloser{Reader: io.MultiReader(bytes.NewReader(mem), req.Body), Closer: req.Body}
+		return nil, ErrBodyNotReplayable
+	}
+	f, err := ioutil.TempFile("", "chassis-body-")
+	if err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(f, io.LimitReader(req.Body, maxSize-int64(len(mem))))
+	if err != nil {
+		removeFile(f)
+		return nil, err
+	}
+	b.mem = mem
+	b.file = f
+	b.size = int64(len(mem)) + n
+	//body may be larger than max size, it is sent as a stream
+	var rest [1]byte
+	if m, _ := io.ReadFull(req.Body, rest[:]); m > 0 {
+		req.Body = &readCloser{
+			Reader: io.MultiReader(b.newReader(), bytes.NewReader(rest[:m]), req.Body),
+			Closer: &bodyCloser{body: req.Body, replay: b},
+		}
+		return nil, ErrBodyNotReplayable
+	}
+	req.Body.Close()
+	return b, nil
+}
+
+func (b *ReplayableBody) newReader() io.Reader {
+	if b.file == nil {
+		return bytes.NewReader(b.mem)
+	}
+	return io.MultiReader(bytes.NewReader(b.mem), io.NewSectionReader(b.file, 0, b.size-int64(len(b.mem))))
+}
+
+//Reset sets a new body of request for next attempt
+func (b *ReplayableBody) Reset() error {
+	if b.req.GetBody != nil {
+		body, err := b.req.GetBody()
+		if err != nil {
+			return err
+		}
+		b.req.Body = body
+		return nil
+	}
+	if b.req.Body == nil || b.req.Body == http.NoBody {
+		return nil
+	}
+	b.req.Body = ioutil.NopCloser(b.newReader())
+	b.req.ContentLength = b.size
+	return nil
+}
+
+//Close removes temp file
+func (b *ReplayableBody) Close() {
+	if b.file != nil {
+		removeFile(b.file)
+		b.file = nil
+	}
+}
+
+func removeFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+//bodyCloser closes original body and removes temp file
+type bodyCloser struct {
+	body   io.Closer
+	replay *ReplayableBody
+}
+
+func (c *bodyCloser) Close() error {
+	c.replay.Close()
+	return c.body.Close()
+}
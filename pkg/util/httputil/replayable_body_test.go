@@ -0,0 +1,59 @@
+package httputil_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chassis/go-chassis/pkg/util/httputil"
+	"github.com/stretchr/testify/assert"
+)
+
+func readBody(t *testing.T, b *httputil.ReplayableBody, req *http.Request) string {
+	assert.NoError(t, b.Reset())
+	body, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestNewReplayableBody(t *testing.T) {
+	t.Run("body in memory", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "http://Server/a", ioutil.NopCloser(strings.NewReader("hello")))
+		b, err := httputil.NewReplayableBody(req, 10, 10)
+		assert.NoError(t, err)
+		defer b.Close()
+		assert.Equal(t, "hello", readBody(t, b, req))
+		assert.Equal(t, "hello", readBody(t, b, req))
+		assert.Equal(t, int64(5), req.ContentLength)
+	})
+	t.Run("body spilled to file", func(t *testing.T) {
+		content := strings.Repeat("0123456789", 10)
+		req, _ := http.NewRequest(http.MethodPost, "http://Server/a", ioutil.NopCloser(strings.NewReader(content)))
+		b, err := httputil.NewReplayableBody(req, 8, 100)
+		assert.NoError(t, err)
+		defer b.Close()
+		assert.Equal(t, content, readBody(t, b, req))
+		assert.Equal(t, content, readBody(t, b, req))
+	})
+	t.Run("body is too large", func(t *testing.T) {
+		content := strings.Repeat("0123456789", 10)
+		for _, max := range []int64{8, 50} {
+			req, _ := http.NewRequest(http.MethodPost, "http://Server/a", ioutil.NopCloser(strings.NewReader(content)))
+			_, err := httputil.NewReplayableBody(req, 8, max)
+			assert.Equal(t, httputil.ErrBodyNotReplayable, err)
+			body, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(body))
+			assert.NoError(t, req.Body.Close())
+		}
+	})
+	t.Run("GetBody is used", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "http://Server/a", bytes.NewReader([]byte("hello")))
+		b, err := httputil.NewReplayableBody(req, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", readBody(t, b, req))
+		assert.Equal(t, "hello", readBody(t, b, req))
+	})
+}
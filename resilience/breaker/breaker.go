@@ -0,0 +1,351 @@
+//Package breaker is a circuit breaker which runs calls in caller goroutine,
+//it has closed, open and half open states, and trips by error rate or slow call rate in a sliding window
+package breaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chassis/go-chassis/third_party/forked/afex/hystrix-go/hystrix"
+	"github.com/go-mesh/openlogging"
+)
+
+//errors are the same with hystrix, so that fallbacks work with both of them
+var (
+	ErrCircuitOpen    = hystrix.ErrCircuitOpen
+	ErrMaxConcurrency = hystrix.ErrMaxConcurrency
+	ErrForceFallback  = hystrix.ErrForceFallback
+)
+
+//State is state of circuit breaker
+type State int32
+
+//states
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+//default settings
+const (
+	DefaultWindowType       = WindowTime
+	DefaultTimeWindowSize   = 10
+	DefaultCountWindowSize  = 100
+	DefaultHalfOpenRequests = 1
+)
+
+//Settings of circuit breaker
+type Settings struct {
+	Enabled       bool
+	ForceOpen     bool
+	ForceClose    bool
+	ForceFallback bool
+	//MaxConcurrentRequests rejects calls if there are too many running calls, 0 means no limit
+	MaxConcurrentRequests int
+	//RequestVolumeThreshold is min calls in window before circuit can be tripped
+	RequestVolumeThreshold int
+	ErrorPercentThreshold  int
+	SleepWindow            time.Duration
+	//WindowType is count or time, size of count window is calls, size of time window is seconds
+	WindowType string
+	WindowSize int
+	//calls longer than SlowCallDuration are slow, circuit is tripped if slow calls reach the percentage
+	SlowCallDuration      time.Duration
+	SlowCallRateThreshold int
+	//HalfOpenRequests is the calls allowed in half open state, circuit is closed if all of them succeed
+	HalfOpenRequests int
+}
+
+//FromCommandConfig converts hystrix config to settings, default values are same with hystrix.
+//window, slow call and half open settings are not in hystrix config, set them after conversion
+func FromCommandConfig(c hystrix.CommandConfig) Settings {
+	s := Settings{
+		Enabled:                c.CircuitBreakerEnabled,
+		ForceOpen:              c.ForceOpen,
+		ForceClose:             c.ForceClose,
+		ForceFallback:          c.ForceFallback,
+		MaxConcurrentRequests:  c.MaxConcurrentRequests,
+		RequestVolumeThreshold: c.RequestVolumeThreshold,
+		ErrorPercentThreshold:  c.ErrorPercentThreshold,
+		SleepWindow:            time.Duration(c.SleepWindow) * time.Millisecond,
+	}
+	if s.MaxConcurrentRequests == 0 {
+		s.MaxConcurrentRequests = hystrix.DefaultMaxConcurrent
+	}
+	if s.RequestVolumeThreshold == 0 {
+		s.RequestVolumeThreshold = hystrix.DefaultVolumeThreshold
+	}
+	if s.ErrorPercentThreshold == 0 {
+		s.ErrorPercentThreshold = hystrix.DefaultErrorPercentThreshold
+	}
+	if s.SleepWindow == 0 {
+		s.SleepWindow = time.Duration(hystrix.DefaultSleepWindow) * time.Millisecond
+	}
+	return s
+}
+
+func (s *Settings) normalize() {
+	if s.WindowType != WindowCount {
+		s.WindowType = DefaultWindowType
+	}
+	if s.WindowSize <= 0 {
+		s.WindowSize = DefaultTimeWindowSize
+		if s.WindowType == WindowCount {
+			s.WindowSize = DefaultCountWindowSize
+		}
+	}
+	if s.HalfOpenRequests <= 0 {
+		s.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+}
+
+//Breaker protects a resource, calls are rejected when it is open.
+//call Acquire before a call and Release after it, or use Do
+type Breaker struct {
+	name    string
+	running int32
+
+	mu       sync.Mutex
+	settings Settings
+	state    State
+	//changedAt is the time state changes
+	changedAt time.Time
+	//generation changes when state changes, results of calls in old generation are ignored
+	generation uint64
+	probes     int
+	successes  int
+	window     window
+}
+
+//New create a breaker
+func New(name string, s Settings) *Breaker {
+	s.normalize()
+	return &Breaker{name: name, settings: s, window: newWindow(s.WindowType, s.WindowSize)}
+}
+
+//Name return name
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+//State return current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+//update changes settings, window is rebuilt if window settings change
+func (b *Breaker) update(s Settings) {
+	s.normalize()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.settings == s {
+		return
+	}
+	if b.settings.WindowType != s.WindowType || b.settings.WindowSize != s.WindowSize {
+		b.window = newWindow(s.WindowType, s.WindowSize)
+	}
+	b.settings = s
+}
+
+//currentState moves open circuit to half open after sleep window,
+//and allows new calls in half open state after sleep window, in case calls never return
+func (b *Breaker) currentState(now time.Time) State {
+	if b.state != StateClosed && now.Sub(b.changedAt) >= b.settings.SleepWindow {
+		if b.state == StateOpen {
+			b.setState(StateHalfOpen, now)
+		} else if b.probes >= b.settings.HalfOpenRequests {
+			b.generation++
+			b.probes = 0
+			b.successes = 0
+			b.changedAt = now
+		}
+	}
+	return b.state
+}
+
+func (b *Breaker) setState(s State, now time.Time) {
+	if b.state == s {
+		return
+	}
+	openlogging.GetLogger().Infof("circuit [%s] is %s, it was %s", b.name, s, b.state)
+	b.state = s
+	b.changedAt = now
+	b.generation++
+	b.probes = 0
+	b.successes = 0
+	if s == StateClosed {
+		b.window.reset()
+	}
+}
+
+//Acquire return a generation if call is allowed, it must be passed to Release
+func (b *Breaker) Acquire() (uint64, error) {
+	b.mu.Lock()
+	s := &b.settings
+	if s.ForceFallback {
+		b.mu.Unlock()
+		return 0, ErrForceFallback
+	}
+	if s.ForceOpen {
+		b.mu.Unlock()
+		return 0, ErrCircuitOpen
+	}
+	generation := b.generation
+	probe := false
+	if s.Enabled && !s.ForceClose {
+		switch b.currentState(time.Now()) {
+		case StateOpen:
+			b.mu.Unlock()
+			return 0, ErrCircuitOpen
+		case StateHalfOpen:
+			if b.probes >= s.HalfOpenRequests {
+				b.mu.Unlock()
+				return 0, ErrCircuitOpen
+			}
+			b.probes++
+			generation = b.generation
+			probe = true
+		}
+	}
+	max := int32(s.MaxConcurrentRequests)
+	b.mu.Unlock()
+	if n := atomic.AddInt32(&b.running, 1); max > 0 && n > max {
+		atomic.AddInt32(&b.running, -1)
+		if probe {
+			b.mu.Lock()
+			if b.generation == generation {
+				b.probes--
+			}
+			b.mu.Unlock()
+		}
+		return 0, ErrMaxConcurrency
+	}
+	return generation, nil
+}
+
+//Release records result of call
+func (b *Breaker) Release(generation uint64, failed bool, d time.Duration) {
+	atomic.AddInt32(&b.running, -1)
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := &b.settings
+	if !s.Enabled || s.ForceClose || s.ForceOpen || generation != b.generation {
+		return
+	}
+	o := outcomeSuccess
+	if failed {
+		o |= outcomeFailure
+	}
+	if s.SlowCallDuration > 0 && d >= s.SlowCallDuration {
+		o |= outcomeSlow
+	}
+	switch b.state {
+	case StateHalfOpen:
+		if o != outcomeSuccess {
+			b.setState(StateOpen, now)
+			return
+		}
+		b.successes++
+		if b.successes >= s.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+	case StateClosed:
+		b.window.record(o, now)
+		c := b.window.counts(now)
+		if c.total < s.RequestVolumeThreshold || c.total == 0 {
+			return
+		}
+		if c.failures*100 >= s.ErrorPercentThreshold*c.total ||
+			(s.SlowCallRateThreshold > 0 && c.slow*100 >= s.SlowCallRateThreshold*c.total) {
+			b.setState(StateOpen, now)
+		}
+	}
+}
+
+//Do runs function in caller goroutine, if call is rejected or fails, fallback handles the error,
+//the error of fallback is returned, or error of call is returned if fallback is nil
+func (b *Breaker) Do(run func() error, fallback func(error) error) error {
+	generation, err := b.Acquire()
+	if err != nil {
+		return tryFallback(err, fallback)
+	}
+	start := time.Now()
+	err = run()
+	b.Release(generation, err != nil, time.Since(start))
+	if err != nil {
+		return tryFallback(err, fallback)
+	}
+	return nil
+}
+
+func tryFallback(err error, fallback func(error) error) error {
+	if fallback == nil {
+		return err
+	}
+	return fallback(err)
+}
+
+var (
+	breakers   = make(map[string]*Breaker)
+	breakersMu sync.RWMutex
+)
+
+//Get return breaker of name, it is created if it does not exist, settings are updated if they change
+func Get(name string, s Settings) *Breaker {
+	breakersMu.RLock()
+	b, ok := breakers[name]
+	breakersMu.RUnlock()
+	if ok {
+		b.update(s)
+		return b
+	}
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	if b, ok = breakers[name]; ok {
+		b.update(s)
+		return b
+	}
+	openlogging.GetLogger().Infof("new circuit [%s] is protecting your service", name)
+	b = New(name, s)
+	breakers[name] = b
+	return b
+}
+
+//Do gets breaker of name and runs function with it
+func Do(name string, s Settings, run func() error, fallback func(error) error) error {
+	return Get(name, s).Do(run, fallback)
+}
+
+//FlushByName removes breaker of name and breakers under it, like Consumer.Server.rest./hello under Consumer.Server
+func FlushByName(name string) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	for n := range breakers {
+		if n == name || (len(n) > len(name) && n[:len(name)+1] == name+".") {
+			delete(breakers, n)
+		}
+	}
+}
+
+//Flush removes all breakers
+func Flush() {
+	breakersMu.Lock()
+	breakers = make(map[string]*Breaker)
+	breakersMu.Unlock()
+}
@@ -0,0 +1,141 @@
+package breaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis/resilience/breaker"
+	"github.com/stretchr/testify/assert"
+)
+
+var errFake = errors.New("fake")
+
+func call(b *breaker.Breaker, err error) error {
+	return b.Do(func() error { return err }, nil)
+}
+
+func TestBreaker_ErrorRate(t *testing.T) {
+	b := breaker.New("error", breaker.Settings{
+		Enabled:                true,
+		RequestVolumeThreshold: 4,
+		ErrorPercentThreshold:  50,
+		SleepWindow:            50 * time.Millisecond,
+	})
+	assert.NoError(t, call(b, nil))
+	assert.NoError(t, call(b, nil))
+	assert.Equal(t, errFake, call(b, errFake))
+	assert.Equal(t, breaker.StateClosed, b.State())
+	assert.Equal(t, errFake, call(b, errFake))
+	assert.Equal(t, breaker.StateOpen, b.State())
+	assert.Equal(t, breaker.ErrCircuitOpen, call(b, nil))
+
+	t.Run("half open probe fails", func(t *testing.T) {
+		time.Sleep(60 * time.Millisecond)
+		assert.Equal(t, breaker.StateHalfOpen, b.State())
+		assert.Equal(t, errFake, call(b, errFake))
+		assert.Equal(t, breaker.StateOpen, b.State())
+	})
+	t.Run("half open probe succeeds", func(t *testing.T) {
+		time.Sleep(60 * time.Millisecond)
+		assert.NoError(t, call(b, nil))
+		assert.Equal(t, breaker.StateClosed, b.State())
+		//window is reset after circuit is closed
+		assert.Equal(t, errFake, call(b, errFake))
+		assert.Equal(t, breaker.StateClosed, b.State())
+	})
+}
+
+func TestBreaker_HalfOpenRequests(t *testing.T) {
+	b := breaker.New("halfOpen", breaker.Settings{
+		Enabled:                true,
+		RequestVolumeThreshold: 1,
+		ErrorPercentThreshold:  50,
+		SleepWindow:            10 * time.Millisecond,
+		HalfOpenRequests:       2,
+	})
+	assert.Equal(t, errFake, call(b, errFake))
+	time.Sleep(20 * time.Millisecond)
+	g1, err := b.Acquire()
+	assert.NoError(t, err)
+	g2, err := b.Acquire()
+	assert.NoError(t, err)
+	_, err = b.Acquire()
+	assert.Equal(t, breaker.ErrCircuitOpen, err)
+	b.Release(g1, false, 0)
+	assert.Equal(t, breaker.StateHalfOpen, b.State())
+	b.Release(g2, false, 0)
+	assert.Equal(t, breaker.StateClosed, b.State())
+}
+
+func TestBreaker_SlowCall(t *testing.T) {
+	b := breaker.New("slow", breaker.Settings{
+		Enabled:                true,
+		RequestVolumeThreshold: 2,
+		ErrorPercentThreshold:  100,
+		SleepWindow:            time.Hour,
+		SlowCallDuration:       10 * time.Millisecond,
+		SlowCallRateThreshold:  50,
+	})
+	g, err := b.Acquire()
+	assert.NoError(t, err)
+	b.Release(g, false, time.Millisecond)
+	g, err = b.Acquire()
+	assert.NoError(t, err)
+	b.Release(g, false, 20*time.Millisecond)
+	assert.Equal(t, breaker.StateOpen, b.State())
+}
+
+func TestBreaker_CountWindow(t *testing.T) {
+	b := breaker.New("count", breaker.Settings{
+		Enabled:                true,
+		RequestVolumeThreshold: 4,
+		ErrorPercentThreshold:  75,
+		SleepWindow:            time.Hour,
+		WindowType:             breaker.WindowCount,
+		WindowSize:             4,
+	})
+	call(b, nil)
+	call(b, errFake)
+	call(b, nil)
+	call(b, errFake)
+	assert.Equal(t, breaker.StateClosed, b.State())
+	//the oldest success is out of window, 3 of last 4 calls fail
+	call(b, errFake)
+	assert.Equal(t, breaker.StateOpen, b.State())
+}
+
+func TestBreaker_Force(t *testing.T) {
+	b := breaker.New("force", breaker.Settings{ForceOpen: true})
+	assert.Equal(t, breaker.ErrCircuitOpen, call(b, nil))
+	b = breaker.New("force", breaker.Settings{ForceFallback: true})
+	err := b.Do(func() error { return nil }, func(err error) error {
+		assert.Equal(t, breaker.ErrForceFallback, err)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestBreaker_MaxConcurrency(t *testing.T) {
+	b := breaker.New("max", breaker.Settings{MaxConcurrentRequests: 1})
+	g, err := b.Acquire()
+	assert.NoError(t, err)
+	_, err = b.Acquire()
+	assert.Equal(t, breaker.ErrMaxConcurrency, err)
+	b.Release(g, false, 0)
+	_, err = b.Acquire()
+	assert.NoError(t, err)
+}
+
+func TestFlushByName(t *testing.T) {
+	s := breaker.Settings{Enabled: true}
+	b1 := breaker.Get("Consumer.Server", s)
+	b2 := breaker.Get("Consumer.Server.rest./hello", s)
+	b3 := breaker.Get("Consumer.Server2", s)
+	assert.True(t, b1 == breaker.Get("Consumer.Server", s))
+	breaker.FlushByName("Consumer.Server")
+	assert.False(t, b1 == breaker.Get("Consumer.Server", s))
+	assert.False(t, b2 == breaker.Get("Consumer.Server.rest./hello", s))
+	assert.True(t, b3 == breaker.Get("Consumer.Server2", s))
+	breaker.Flush()
+}
@@ -0,0 +1,127 @@
+package breaker
+
+import "time"
+
+//window types
+const (
+	//WindowCount counts outcomes of last N calls
+	WindowCount = "count"
+	//WindowTime counts outcomes of calls in last N seconds
+	WindowTime = "time"
+)
+
+//outcome of a call, it is kept in count window
+const (
+	outcomeSuccess uint8 = 0
+	outcomeFailure uint8 = 1
+	outcomeSlow    uint8 = 2
+)
+
+//counts is the statistics of a window
+type counts struct {
+	total    int
+	failures int
+	slow     int
+}
+
+func (c *counts) add(o uint8, delta int) {
+	c.total += delta
+	if o&outcomeFailure != 0 {
+		c.failures += delta
+	}
+	if o&outcomeSlow != 0 {
+		c.slow += delta
+	}
+}
+
+//window records outcomes of calls, it is not thread safe, breaker protects it
+type window interface {
+	record(o uint8, now time.Time)
+	counts(now time.Time) counts
+	reset()
+}
+
+//countWindow is a ring of outcomes, it never allocates after creation
+type countWindow struct {
+	outcomes []uint8
+	pos      int
+	full     bool
+	c        counts
+}
+
+func newCountWindow(size int) *countWindow {
+	return &countWindow{outcomes: make([]uint8, size)}
+}
+
+func (w *countWindow) record(o uint8, now time.Time) {
+	if w.full {
+		w.c.add(w.outcomes[w.pos], -1)
+	}
+	w.outcomes[w.pos] = o
+	w.c.add(o, 1)
+	w.pos++
+	if w.pos == len(w.outcomes) {
+		w.pos = 0
+		w.full = true
+	}
+}
+
+func (w *countWindow) counts(now time.Time) counts {
+	return w.c
+}
+
+func (w *countWindow) reset() {
+	w.pos = 0
+	w.full = false
+	w.c = counts{}
+}
+
+type bucket struct {
+	second int64
+	counts
+}
+
+//timeWindow has a bucket for each second
+type timeWindow struct {
+	buckets []bucket
+}
+
+func newTimeWindow(seconds int) *timeWindow {
+	return &timeWindow{buckets: make([]bucket, seconds)}
+}
+
+func (w *timeWindow) record(o uint8, now time.Time) {
+	sec := now.Unix()
+	b := &w.buckets[sec%int64(len(w.buckets))]
+	if b.second != sec {
+		b.second = sec
+		b.counts = counts{}
+	}
+	b.add(o, 1)
+}
+
+func (w *timeWindow) counts(now time.Time) counts {
+	var c counts
+	oldest := now.Unix() - int64(len(w.buckets))
+	for _, b := range w.buckets {
+		if b.second > oldest {
+			c.total += b.total
+			c.failures += b.failures
+			c.slow += b.slow
+		}
+	}
+	return c
+}
+
+func (w *timeWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
+
+func newWindow(kind string, size int) window {
+	if kind == WindowCount {
+		return newCountWindow(size)
+	}
+	return newTimeWindow(size)
+}
@@ -0,0 +1,168 @@
+//Package bulkhead limits concurrent calls, so that a slow resource can not use up all of goroutines and connections
+package bulkhead
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-mesh/openlogging"
+)
+
+//modes
+const (
+	//ModeSemaphore rejects calls immediately if max concurrent calls is reached
+	ModeSemaphore = "semaphore"
+	//ModeQueue puts calls into a bounded queue, and rejects them if they wait too long
+	ModeQueue = "queue"
+)
+
+//errors
+var (
+	//ErrFull means max concurrent calls is reached and queue is full
+	ErrFull = errors.New("bulkhead is full")
+	//ErrTimeout means call waits too long in queue
+	ErrTimeout = errors.New("bulkhead wait timeout")
+)
+
+//Settings of bulkhead
+type Settings struct {
+	Mode               string
+	MaxConcurrentCalls int
+	//MaxWaitingCalls and MaxWaitDuration only work in queue mode
+	MaxWaitingCalls int
+	MaxWaitDuration time.Duration
+}
+
+//Bulkhead is a semaphore with a bounded FIFO queue, call Acquire before a call and Release after it
+type Bulkhead struct {
+	name     string
+	mu       sync.Mutex
+	settings Settings
+	active   int
+	//waiters is a list of chan struct{}, the chan is closed when the call gets a permit
+	waiters *list.List
+}
+
+//New create a bulkhead
+func New(name string, s Settings) *Bulkhead {
+	return &Bulkhead{name: name, settings: s, waiters: list.New()}
+}
+
+//Name return name
+func (b *Bulkhead) Name() string {
+	return b.name
+}
+
+//Active return number of running calls
+func (b *Bulkhead) Active() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active
+}
+
+//Waiting return number of calls in queue
+func (b *Bulkhead) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.waiters.Len()
+}
+
+//Acquire gets a permit, in queue mode it waits until a permit is released, wait duration passed or ctx is done
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	b.mu.Lock()
+	if b.active < b.settings.MaxConcurrentCalls && b.waiters.Len() == 0 {
+		b.active++
+		b.mu.Unlock()
+		return nil
+	}
+	if b.settings.Mode != ModeQueue || b.waiters.Len() >= b.settings.MaxWaitingCalls {
+		b.mu.Unlock()
+		return ErrFull
+	}
+	ready := make(chan struct{})
+	e := b.waiters.PushBack(ready)
+	wait := b.settings.MaxWaitDuration
+	b.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	err := ErrTimeout
+	select {
+	case <-ready:
+		return nil
+	case <-timer.C:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-ready:
+		//permit is granted at the same time
+		return nil
+	default:
+	}
+	b.waiters.Remove(e)
+	return err
+}
+
+//Release returns a permit, the first call in queue gets it
+func (b *Bulkhead) Release() {
+	b.mu.Lock()
+	b.active--
+	b.grant()
+	b.mu.Unlock()
+}
+
+//grant gives permits to calls in queue if there are free permits
+func (b *Bulkhead) grant() {
+	for b.active < b.settings.MaxConcurrentCalls && b.waiters.Len() > 0 {
+		b.active++
+		close(b.waiters.Remove(b.waiters.Front()).(chan struct{}))
+	}
+}
+
+//update changes settings, running calls are not affected,
+//if max concurrent calls is increased, calls in queue get permits
+func (b *Bulkhead) update(s Settings) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.settings == s {
+		return
+	}
+	openlogging.GetLogger().Infof("bulkhead [%s] changes to %+v", b.name, s)
+	b.settings = s
+	b.grant()
+}
+
+var (
+	bulkheads   = make(map[string]*Bulkhead)
+	bulkheadsMu sync.RWMutex
+)
+
+//Get return bulkhead of name, it is created if it does not exist, settings are updated if they change
+func Get(name string, s Settings) *Bulkhead {
+	bulkheadsMu.RLock()
+	b, ok := bulkheads[name]
+	bulkheadsMu.RUnlock()
+	if !ok {
+		bulkheadsMu.Lock()
+		if b, ok = bulkheads[name]; !ok {
+			b = New(name, s)
+			bulkheads[name] = b
+		}
+		bulkheadsMu.Unlock()
+	}
+	b.update(s)
+	return b
+}
+
+//Flush removes all bulkheads, running calls release permits to old bulkheads
+func Flush() {
+	bulkheadsMu.Lock()
+	bulkheads = make(map[string]*Bulkhead)
+	bulkheadsMu.Unlock()
+}
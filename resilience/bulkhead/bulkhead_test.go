@@ -0,0 +1,81 @@
+package bulkhead_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis/resilience/bulkhead"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkhead_Semaphore(t *testing.T) {
+	b := bulkhead.New("semaphore", bulkhead.Settings{Mode: bulkhead.ModeSemaphore, MaxConcurrentCalls: 2})
+	ctx := context.Background()
+	assert.NoError(t, b.Acquire(ctx))
+	assert.NoError(t, b.Acquire(ctx))
+	assert.Equal(t, bulkhead.ErrFull, b.Acquire(ctx))
+	b.Release()
+	assert.NoError(t, b.Acquire(ctx))
+	assert.Equal(t, 2, b.Active())
+}
+
+func TestBulkhead_Queue(t *testing.T) {
+	b := bulkhead.New("queue", bulkhead.Settings{
+		Mode:               bulkhead.ModeQueue,
+		MaxConcurrentCalls: 1,
+		MaxWaitingCalls:    1,
+		MaxWaitDuration:    time.Second,
+	})
+	ctx := context.Background()
+	assert.NoError(t, b.Acquire(ctx))
+	done := make(chan error)
+	go func() {
+		done <- b.Acquire(ctx)
+	}()
+	for b.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	t.Run("queue is full", func(t *testing.T) {
+		assert.Equal(t, bulkhead.ErrFull, b.Acquire(ctx))
+	})
+	t.Run("waiting call gets released permit", func(t *testing.T) {
+		b.Release()
+		assert.NoError(t, <-done)
+		assert.Equal(t, 1, b.Active())
+		assert.Equal(t, 0, b.Waiting())
+	})
+	t.Run("wait timeout", func(t *testing.T) {
+		b := bulkhead.New("timeout", bulkhead.Settings{
+			Mode:               bulkhead.ModeQueue,
+			MaxConcurrentCalls: 1,
+			MaxWaitingCalls:    1,
+			MaxWaitDuration:    10 * time.Millisecond,
+		})
+		assert.NoError(t, b.Acquire(ctx))
+		assert.Equal(t, bulkhead.ErrTimeout, b.Acquire(ctx))
+		assert.Equal(t, 0, b.Waiting())
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Equal(t, context.Canceled, b.Acquire(cancelled))
+	})
+}
+
+func TestGet(t *testing.T) {
+	defer bulkhead.Flush()
+	s := bulkhead.Settings{Mode: bulkhead.ModeQueue, MaxConcurrentCalls: 1, MaxWaitingCalls: 1, MaxWaitDuration: time.Second}
+	b := bulkhead.Get("Consumer.Server", s)
+	assert.NoError(t, b.Acquire(context.Background()))
+	done := make(chan error)
+	go func() {
+		done <- b.Acquire(context.Background())
+	}()
+	for b.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	//more permits are granted to waiting calls
+	s.MaxConcurrentCalls = 2
+	assert.True(t, b == bulkhead.Get("Consumer.Server", s))
+	assert.NoError(t, <-done)
+	assert.Equal(t, 2, b.Active())
+}
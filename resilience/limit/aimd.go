@@ -0,0 +1,27 @@
+package limit
+
+import "time"
+
+//AIMD increases limit by 1 after a call succeeds, and multiplies limit by backoff ratio after a call is dropped.
+//calls longer than timeout are dropped
+type AIMD struct {
+	backoffRatio float64
+	timeout      time.Duration
+}
+
+//NewAIMD create aimd algorithm
+func NewAIMD(backoffRatio float64, timeout time.Duration) *AIMD {
+	return &AIMD{backoffRatio: backoffRatio, timeout: timeout}
+}
+
+//Update returns new limit
+func (a *AIMD) Update(limit int, rtt time.Duration, inflight int, dropped bool) int {
+	if dropped || rtt > a.timeout {
+		return int(float64(limit) * a.backoffRatio)
+	}
+	//limit is not used up, do not increase it
+	if inflight*2 >= limit {
+		return limit + 1
+	}
+	return limit
+}
@@ -0,0 +1,84 @@
+package limit
+
+import (
+	"math"
+	"time"
+)
+
+//gradient window settings
+const (
+	//GradientWindow is the samples in a window, limit is adjusted once for each window
+	GradientWindow = 10
+	//GradientProbeWindows is the windows after which min rtt is measured again,
+	//min rtt is replaced by the lowest window average in these windows
+	GradientProbeWindows = 100
+)
+
+//Gradient adjusts limit by gradient of min rtt to average rtt in a window,
+//limit grows by square root of it when latency is near min rtt, and shrinks when latency rises.
+//it is not thread safe, limiter protects it
+type Gradient struct {
+	tolerance float64
+	smoothing float64
+	minRTT    time.Duration
+	periodMin time.Duration
+	windows   int
+	estimated float64
+
+	sum         time.Duration
+	count       int
+	maxInflight int
+	dropped     bool
+}
+
+//NewGradient create gradient algorithm
+func NewGradient(tolerance, smoothing float64) *Gradient {
+	return &Gradient{tolerance: tolerance, smoothing: smoothing}
+}
+
+//Update records a sample, and adjusts limit when a window is full
+func (g *Gradient) Update(limit int, rtt time.Duration, inflight int, dropped bool) int {
+	g.sum += rtt
+	g.count++
+	if inflight > g.maxInflight {
+		g.maxInflight = inflight
+	}
+	g.dropped = g.dropped || dropped
+	if g.count < GradientWindow {
+		return limit
+	}
+	avg := g.sum / time.Duration(g.count)
+	maxInflight, dropped := g.maxInflight, g.dropped
+	g.sum, g.count, g.maxInflight, g.dropped = 0, 0, 0, false
+
+	//min rtt may be changed by the service itself, measure it again sometimes.
+	//it is the lowest average of a probe period, not the average of a loaded window, so it does not ratchet up
+	if g.periodMin == 0 || avg < g.periodMin {
+		g.periodMin = avg
+	}
+	if g.minRTT == 0 || avg < g.minRTT {
+		g.minRTT = avg
+	}
+	g.windows++
+	if g.windows >= GradientProbeWindows {
+		g.minRTT = g.periodMin
+		g.periodMin = 0
+		g.windows = 0
+	}
+	if int(g.estimated) != limit {
+		g.estimated = float64(limit)
+	}
+	var newLimit float64
+	switch {
+	case dropped:
+		newLimit = float64(limit) / 2
+	case maxInflight*2 < limit:
+		//limit is not used up, latency tells nothing about it
+		return limit
+	default:
+		gradient := math.Max(0.5, math.Min(1, g.tolerance*float64(g.minRTT)/float64(avg)))
+		newLimit = float64(limit)*gradient + math.Sqrt(float64(limit))
+	}
+	g.estimated = g.estimated*(1-g.smoothing) + newLimit*g.smoothing
+	return int(g.estimated)
+}
@@ -0,0 +1,167 @@
+//Package limit is an adaptive concurrency limiter,
+//it measures latency of calls and finds the concurrency which keeps latency near the minimum
+package limit
+
+import (
+	"sync"
+	"time"
+)
+
+//algorithms
+const (
+	AlgorithmGradient = "gradient"
+	AlgorithmAIMD     = "aimd"
+)
+
+//default settings
+const (
+	DefaultInitialLimit = 20
+	DefaultMinLimit     = 1
+	DefaultMaxLimit     = 1000
+	DefaultTolerance    = 1.5
+	DefaultSmoothing    = 0.2
+	DefaultBackoffRatio = 0.9
+	DefaultTimeout      = time.Second
+)
+
+//Algorithm computes new limit when a call finishes
+type Algorithm interface {
+	//Update returns new limit, rtt is latency of call, inflight is running calls when the call started,
+	//dropped means the call is rejected or failed because of overload
+	Update(limit int, rtt time.Duration, inflight int, dropped bool) int
+}
+
+//Settings of limiter
+type Settings struct {
+	Algorithm    string
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	//Tolerance is the rtt ratio to min rtt which is acceptable, limit decreases if rtt is higher, only for gradient
+	Tolerance float64
+	//Smoothing is the weight of new limit, only for gradient
+	Smoothing float64
+	//BackoffRatio is multiplied to limit if a call is dropped, only for aimd
+	BackoffRatio float64
+	//calls longer than Timeout are dropped, only for aimd
+	Timeout time.Duration
+}
+
+func (s *Settings) normalize() {
+	if s.Algorithm != AlgorithmAIMD {
+		s.Algorithm = AlgorithmGradient
+	}
+	if s.MinLimit <= 0 {
+		s.MinLimit = DefaultMinLimit
+	}
+	if s.MaxLimit < s.MinLimit {
+		s.MaxLimit = DefaultMaxLimit
+		if s.MaxLimit < s.MinLimit {
+			s.MaxLimit = s.MinLimit
+		}
+	}
+	if s.InitialLimit <= 0 {
+		s.InitialLimit = DefaultInitialLimit
+	}
+	s.InitialLimit = clamp(s.InitialLimit, s.MinLimit, s.MaxLimit)
+	if s.Tolerance < 1 {
+		s.Tolerance = DefaultTolerance
+	}
+	if s.Smoothing <= 0 || s.Smoothing > 1 {
+		s.Smoothing = DefaultSmoothing
+	}
+	if s.BackoffRatio <= 0 || s.BackoffRatio >= 1 {
+		s.BackoffRatio = DefaultBackoffRatio
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = DefaultTimeout
+	}
+}
+
+func newAlgorithm(s Settings) Algorithm {
+	if s.Algorithm == AlgorithmAIMD {
+		return NewAIMD(s.BackoffRatio, s.Timeout)
+	}
+	return NewGradient(s.Tolerance, s.Smoothing)
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+//Token is returned by Acquire, it must be passed to Release
+type Token struct {
+	start    time.Time
+	inflight int
+}
+
+//Limiter rejects calls if running calls reach limit, limit is adjusted by algorithm after each call
+type Limiter struct {
+	mu       sync.Mutex
+	settings Settings
+	alg      Algorithm
+	limit    int
+	inflight int
+}
+
+//New create a limiter
+func New(s Settings) *Limiter {
+	s.normalize()
+	return &Limiter{settings: s, alg: newAlgorithm(s), limit: s.InitialLimit}
+}
+
+//Acquire returns false if call is rejected
+func (l *Limiter) Acquire() (Token, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inflight >= l.limit {
+		return Token{}, false
+	}
+	l.inflight++
+	return Token{start: time.Now(), inflight: l.inflight}, true
+}
+
+//Release records latency of call and adjusts limit
+func (l *Limiter) Release(t Token, dropped bool) {
+	rtt := time.Since(t.start)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	l.limit = clamp(l.alg.Update(l.limit, rtt, t.inflight, dropped), l.settings.MinLimit, l.settings.MaxLimit)
+}
+
+//Limit return current limit
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+//Inflight return running calls
+func (l *Limiter) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+//Update changes settings, algorithm state is reset if algorithm changes
+func (l *Limiter) Update(s Settings) {
+	s.normalize()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.settings == s {
+		return
+	}
+	if l.settings.Algorithm != s.Algorithm {
+		l.limit = s.InitialLimit
+	}
+	l.alg = newAlgorithm(s)
+	l.settings = s
+	l.limit = clamp(l.limit, s.MinLimit, s.MaxLimit)
+}
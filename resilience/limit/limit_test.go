@@ -0,0 +1,87 @@
+package limit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis/resilience/limit"
+	"github.com/stretchr/testify/assert"
+)
+
+//window runs a gradient window with the same rtt and inflight
+func window(g *limit.Gradient, l int, rtt time.Duration, inflight int) int {
+	for n := 0; n < limit.GradientWindow; n++ {
+		l = g.Update(l, rtt, inflight, false)
+	}
+	return l
+}
+
+func TestGradient(t *testing.T) {
+	g := limit.NewGradient(limit.DefaultTolerance, limit.DefaultSmoothing)
+	l := 20
+	t.Run("limit grows when latency is near min rtt", func(t *testing.T) {
+		for n := 0; n < 20; n++ {
+			l = window(g, l, 10*time.Millisecond, l)
+		}
+		assert.True(t, l > 30, l)
+	})
+	t.Run("limit does not grow if it is not used up", func(t *testing.T) {
+		assert.Equal(t, l, window(g, l, 10*time.Millisecond, 1))
+	})
+	t.Run("limit shrinks when latency rises", func(t *testing.T) {
+		before := l
+		for n := 0; n < 20; n++ {
+			l = window(g, l, 100*time.Millisecond, l)
+		}
+		assert.True(t, l < before/2, l)
+	})
+	t.Run("min rtt is not reset to average of loaded windows", func(t *testing.T) {
+		g := limit.NewGradient(limit.DefaultTolerance, limit.DefaultSmoothing)
+		l := window(g, 20, 10*time.Millisecond, 20)
+		for n := 0; n < limit.GradientProbeWindows+limit.GradientProbeWindows/2; n++ {
+			l = window(g, l, 100*time.Millisecond, l)
+		}
+		assert.True(t, l < 10, l)
+
+		//latency stays high for a whole probe period, it is the new min rtt
+		before := l
+		for n := 0; n < limit.GradientProbeWindows; n++ {
+			l = window(g, l, 100*time.Millisecond, l)
+		}
+		assert.True(t, l > before, l)
+	})
+}
+
+func TestAIMD(t *testing.T) {
+	a := limit.NewAIMD(0.5, 100*time.Millisecond)
+	assert.Equal(t, 11, a.Update(10, time.Millisecond, 5, false))
+	assert.Equal(t, 10, a.Update(10, time.Millisecond, 1, false))
+	assert.Equal(t, 5, a.Update(10, time.Millisecond, 5, true))
+	assert.Equal(t, 5, a.Update(10, time.Second, 5, false))
+}
+
+func TestLimiter(t *testing.T) {
+	l := limit.New(limit.Settings{Algorithm: limit.AlgorithmAIMD, InitialLimit: 2, MaxLimit: 3})
+	t1, ok := l.Acquire()
+	assert.True(t, ok)
+	t2, ok := l.Acquire()
+	assert.True(t, ok)
+	_, ok = l.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, 2, l.Inflight())
+	l.Release(t1, false)
+	l.Release(t2, false)
+	assert.Equal(t, 3, l.Limit())
+	t.Run("limit is not less than min limit", func(t *testing.T) {
+		for n := 0; n < 10; n++ {
+			tk, ok := l.Acquire()
+			assert.True(t, ok)
+			l.Release(tk, true)
+		}
+		assert.Equal(t, limit.DefaultMinLimit, l.Limit())
+	})
+	t.Run("settings change", func(t *testing.T) {
+		l.Update(limit.Settings{Algorithm: limit.AlgorithmGradient, InitialLimit: 5})
+		assert.Equal(t, 5, l.Limit())
+	})
+}
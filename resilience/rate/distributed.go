@@ -0,0 +1,68 @@
+package rate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-mesh/openlogging"
+)
+
+//modes of limiter
+const (
+	//ModeLocal limits rate of this process
+	ModeLocal = "local"
+	//ModeDistributed limits rate of all processes by a shared store
+	ModeDistributed = "distributed"
+)
+
+//algorithms of distributed limiter
+const (
+	AlgorithmSlidingWindow = "sliding-window"
+	AlgorithmGCRA          = "gcra"
+)
+
+//DistributedRetryInterval is the time limiters work in local mode after shared store fails
+const DistributedRetryInterval = time.Second
+
+//Distributed limits rate of all processes by a shared store, like redis
+type Distributed interface {
+	//Allow returns whether a request is allowed, error means store is unavailable
+	Allow(name string, qps, burst int) (bool, error)
+}
+
+//DistributedOptions is options of distributed limiter
+type DistributedOptions struct {
+	Plugin    string
+	Address   string
+	Password  string
+	DB        int
+	Algorithm string
+	//Prefix is added to keys in store, so that keys of different systems do not conflict
+	Prefix  string
+	Timeout time.Duration
+}
+
+var distributedPlugins = make(map[string]func(opts DistributedOptions) (Distributed, error))
+
+//InstallDistributed install distributed limiter plugin
+func InstallDistributed(name string, f func(opts DistributedOptions) (Distributed, error)) {
+	distributedPlugins[name] = f
+}
+
+//InitDistributed creates distributed limiter, it does nothing if plugin is empty
+func InitDistributed(opts DistributedOptions) error {
+	if opts.Plugin == "" {
+		return nil
+	}
+	f, ok := distributedPlugins[opts.Plugin]
+	if !ok {
+		return fmt.Errorf("not supported distributed rate limiter [%s]", opts.Plugin)
+	}
+	d, err := f(opts)
+	if err != nil {
+		return err
+	}
+	GetRateLimiters().SetDistributed(d)
+	openlogging.Info(fmt.Sprintf("distributed rate limiter [%s@%s] enabled", opts.Plugin, opts.Address))
+	return nil
+}
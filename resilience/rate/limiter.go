@@ -4,7 +4,10 @@ package rate
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/go-mesh/openlogging"
 	"k8s.io/client-go/util/flowcontrol"
 )
 
@@ -19,6 +22,11 @@ const (
 type Limiters struct {
 	sync.RWMutex
 	m map[string]flowcontrol.RateLimiter
+	//modes is local or distributed mode of each limiter, default is local
+	modes       map[string]string
+	distributed Distributed
+	//fallbackUntil is the unix nano time until which distributed limiters work in local mode
+	fallbackUntil int64
 }
 
 // variables of qps limiter and mutex variable
@@ -30,15 +38,24 @@ var (
 // GetRateLimiters get qps rate limiters
 func GetRateLimiters() *Limiters {
 	once.Do(func() {
-		qpsLimiter = &Limiters{m: make(map[string]flowcontrol.RateLimiter)}
+		qpsLimiter = &Limiters{m: make(map[string]flowcontrol.RateLimiter), modes: make(map[string]string)}
 	})
 	return qpsLimiter
 }
 
 //TryAccept try to accept a request. if limiter can not accept a request, it returns false
 //name is the limiter name
+//if limiter is in distributed mode but shared store is unavailable, it works in local mode
 func (qpsL *Limiters) TryAccept(name string, qps, burst int) bool {
 	qpsL.RLock()
+	if qpsL.modes[name] == ModeDistributed && qpsL.distributed != nil {
+		d := qpsL.distributed
+		qpsL.RUnlock()
+		if allowed, ok := qpsL.tryDistributed(d, name, qps, burst); ok {
+			return allowed
+		}
+		qpsL.RLock()
+	}
 	limiter, ok := qpsL.m[name]
 	if !ok {
 		qpsL.RUnlock()
@@ -68,6 +85,44 @@ func (qpsL *Limiters) addLimiter(name string, qps, burst int) bool {
 	return r.TryAccept()
 }
 
+//tryDistributed returns false as the second value if shared store is unavailable
+func (qpsL *Limiters) tryDistributed(d Distributed, name string, qps, burst int) (bool, bool) {
+	now := time.Now().UnixNano()
+	if now < atomic.LoadInt64(&qpsL.fallbackUntil) {
+		return false, false
+	}
+	allowed, err := d.Allow(name, qps, burst)
+	if err != nil {
+		until := now + int64(DistributedRetryInterval)
+		if old := atomic.SwapInt64(&qpsL.fallbackUntil, until); old < now {
+			openlogging.GetLogger().Warnf("distributed rate limiter is unavailable, fall back to local mode: %s", err)
+		}
+		return false, false
+	}
+	return allowed, true
+}
+
+//SetMode sets local or distributed mode of limiter
+func (qpsL *Limiters) SetMode(name, mode string) {
+	qpsL.RLock()
+	old := qpsL.modes[name]
+	qpsL.RUnlock()
+	if old == mode {
+		return
+	}
+	qpsL.Lock()
+	qpsL.modes[name] = mode
+	qpsL.Unlock()
+}
+
+//SetDistributed sets the distributed limiter which limiters in distributed mode use
+func (qpsL *Limiters) SetDistributed(d Distributed) {
+	qpsL.Lock()
+	qpsL.distributed = d
+	qpsL.Unlock()
+	atomic.StoreInt64(&qpsL.fallbackUntil, 0)
+}
+
 // UpdateRateLimit will update the old limiters
 func (qpsL *Limiters) UpdateRateLimit(name string, qps, burst int) {
 	qpsL.addLimiter(name, qps, burst)
@@ -0,0 +1,120 @@
+//Package redis is a distributed rate limiter plugin based on redis,
+//import it to install the plugin, it supports sliding window and GCRA algorithms
+package redis
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-chassis/go-chassis/resilience/rate"
+	"github.com/go-redis/redis"
+)
+
+//Name is the plugin name
+const Name = "redis"
+
+//defaults
+const (
+	DefaultPrefix  = "chassis:ratelimit:"
+	DefaultTimeout = 100 * time.Millisecond
+)
+
+//slidingWindowScript counts requests in current second, and estimates requests in last second
+//by weight of previous second. KEYS are current and previous window,
+//ARGV are limit, weight of previous window and ttl in milliseconds
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+if previous * tonumber(ARGV[2]) + current + 1 > tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+//gcraScript saves theoretical arrival time of next request, request is allowed
+//if it does not arrive earlier than burst intervals. ARGV are now, interval and burst in microseconds
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local tolerance = tonumber(ARGV[3])
+local tat = tonumber(redis.call('GET', KEYS[1]) or ARGV[1])
+if tat < now then
+	tat = now
+end
+local next = tat + interval
+if next - now > tolerance then
+	return 0
+end
+redis.call('SET', KEYS[1], string.format('%d', next), 'PX', string.format('%d', math.ceil((next - now) / 1000)))
+return 1
+`)
+
+//Limiter limits rate by redis
+type Limiter struct {
+	client    *redis.Client
+	algorithm string
+	prefix    string
+}
+
+//New create redis limiter
+func New(opts rate.DistributedOptions) (rate.Distributed, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultPrefix
+	}
+	if opts.Algorithm != rate.AlgorithmGCRA {
+		opts.Algorithm = rate.AlgorithmSlidingWindow
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:         opts.Address,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		DialTimeout:  opts.Timeout,
+		ReadTimeout:  opts.Timeout,
+		WriteTimeout: opts.Timeout,
+	})
+	return &Limiter{client: client, algorithm: opts.Algorithm, prefix: opts.Prefix}, nil
+}
+
+//Allow returns whether a request is allowed, burst only works in GCRA
+func (l *Limiter) Allow(name string, qps, burst int) (bool, error) {
+	if qps <= 0 {
+		return false, nil
+	}
+	now := time.Now()
+	//hash tag keeps keys of a limiter in one slot of redis cluster
+	key := l.prefix + "{" + name + "}"
+	var r int64
+	var err error
+	if l.algorithm == rate.AlgorithmGCRA {
+		if burst < 1 {
+			burst = 1
+		}
+		interval := int64(time.Second/time.Microsecond) / int64(qps)
+		r, err = gcraScript.Run(l.client, []string{key},
+			now.UnixNano()/int64(time.Microsecond), interval, interval*int64(burst)).Int64()
+	} else {
+		sec := now.Unix()
+		weight := 1 - float64(now.UnixNano()-sec*int64(time.Second))/float64(time.Second)
+		r, err = slidingWindowScript.Run(l.client,
+			[]string{key + ":" + strconv.FormatInt(sec, 10), key + ":" + strconv.FormatInt(sec-1, 10)},
+			qps, strconv.FormatFloat(weight, 'f', 3, 64), 2000).Int64()
+	}
+	if err != nil {
+		return false, err
+	}
+	return r == 1, nil
+}
+
+//Close closes redis client
+func (l *Limiter) Close() error {
+	return l.client.Close()
+}
+
+func init() {
+	rate.InstallDistributed(Name, New)
+}
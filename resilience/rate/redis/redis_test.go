@@ -0,0 +1,56 @@
+package redis_test
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chassis/go-chassis/resilience/rate"
+	"github.com/go-chassis/go-chassis/resilience/rate/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func allowed(d rate.Distributed, name string, qps, burst, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		ok, err := d.Allow(name, qps, burst)
+		if err == nil && ok {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	defer s.Close()
+	t.Run("sliding window", func(t *testing.T) {
+		d, err := redis.New(rate.DistributedOptions{Address: s.Addr()})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, allowed(d, "sliding", 5, 0, 10))
+		//another process shares the same quota
+		d2, _ := redis.New(rate.DistributedOptions{Address: s.Addr()})
+		assert.Equal(t, 0, allowed(d2, "sliding", 5, 0, 10))
+	})
+	t.Run("gcra", func(t *testing.T) {
+		d, err := redis.New(rate.DistributedOptions{Address: s.Addr(), Algorithm: rate.AlgorithmGCRA})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, allowed(d, "gcra", 10, 3, 10))
+	})
+}
+
+func TestLimiters_Fallback(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.NoError(t, err)
+	assert.NoError(t, rate.InitDistributed(rate.DistributedOptions{Plugin: redis.Name, Address: s.Addr()}))
+	l := rate.GetRateLimiters()
+	defer l.SetDistributed(nil)
+	l.SetMode("fallback", rate.ModeDistributed)
+	assert.True(t, l.TryAccept("fallback", 1, 1))
+	assert.False(t, l.TryAccept("fallback", 1, 1))
+
+	//store is unavailable, local limiter works
+	s.Close()
+	assert.True(t, l.TryAccept("fallback", 1, 1))
+	assert.False(t, l.TryAccept("fallback", 1, 1))
+}
@@ -35,3 +35,27 @@ func GetBackOff(kind string, min, max int) backoff.BackOff {
 	}
 
 }
+
+//RetryAfterBackOff waits at least the time asked by remote, for example the Retry-After header
+type RetryAfterBackOff struct {
+	backoff.BackOff
+	wait time.Duration
+}
+
+//SetRetryAfter sets the min wait time of next back off
+func (b *RetryAfterBackOff) SetRetryAfter(d time.Duration) {
+	b.wait = d
+}
+
+//NextBackOff return the longer one of back off policy and retry after
+func (b *RetryAfterBackOff) NextBackOff() time.Duration {
+	d := b.BackOff.NextBackOff()
+	if d == backoff.Stop {
+		return d
+	}
+	if b.wait > d {
+		d = b.wait
+	}
+	b.wait = 0
+	return d
+}
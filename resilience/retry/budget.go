@@ -0,0 +1,54 @@
+package retry
+
+import (
+	"sync"
+)
+
+//DefaultMinTokens is the tokens a budget has at the beginning, so that a few extra requests are allowed at low traffic
+const DefaultMinTokens = 3
+
+//Budget limits extra requests, such as retries and hedges, to a percentage of requests.
+//each request deposits percent/100 token, each extra request withdraws 1 token
+type Budget struct {
+	mu     sync.Mutex
+	tokens float64
+	ratio  float64
+	max    float64
+}
+
+//NewBudget create a budget, percent is the percentage of extra requests,
+//tokens never exceed max so that extra requests can not burst after a long quiet time
+func NewBudget(percent int, max float64) *Budget {
+	if max < DefaultMinTokens {
+		max = DefaultMinTokens
+	}
+	return &Budget{tokens: DefaultMinTokens, ratio: float64(percent) / 100, max: max}
+}
+
+//Deposit is called for each request
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+	b.mu.Unlock()
+}
+
+//Withdraw is called before an extra request, it returns false if budget is used up
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+//SetPercent changes the percentage of extra requests
+func (b *Budget) SetPercent(percent int) {
+	b.mu.Lock()
+	b.ratio = float64(percent) / 100
+	b.mu.Unlock()
+}
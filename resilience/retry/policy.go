@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//error classes of retry policy
+const (
+	//ErrorAny matches any error
+	ErrorAny = "error"
+	//ErrorConnectFailure matches errors of connecting to remote
+	ErrorConnectFailure = "connect-failure"
+	//ErrorReset matches connections closed by remote before response arrives
+	ErrorReset = "reset"
+	//ErrorTimeout matches timeout errors
+	ErrorTimeout = "timeout"
+)
+
+//MaxRetryAfter is the max wait time of Retry-After header, call is not retried if remote asks for waiting longer
+const MaxRetryAfter = 10 * time.Second
+
+//Policy decides which failed calls are retried.
+//a call is retried if its status matches StatusCodes or its error matches Errors,
+//if both of them are empty, a call is retried when it has any error
+type Policy struct {
+	//StatusCodes are status like 503, or status classes like 5xx
+	StatusCodes []string
+	//Errors are error classes
+	Errors []string
+	//Methods are http methods which can be retried, empty means all of methods
+	Methods []string
+}
+
+//NewPolicy create a policy from comma separated strings
+func NewPolicy(statusCodes, errs, methods string) Policy {
+	return Policy{
+		StatusCodes: split(statusCodes),
+		Errors:      split(errs),
+		Methods:     split(strings.ToUpper(methods)),
+	}
+}
+
+func split(s string) []string {
+	result := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+//MatchMethod return whether requests of method can be retried, method is empty if protocol is not http
+func (p Policy) MatchMethod(method string) bool {
+	if len(p.Methods) == 0 || method == "" {
+		return true
+	}
+	for _, m := range p.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+//Retriable return whether a call with status and error should be retried, status is 0 if it is unknown
+func (p Policy) Retriable(status int, err error) bool {
+	if err == context.Canceled {
+		return false
+	}
+	if len(p.StatusCodes) == 0 && len(p.Errors) == 0 {
+		return err != nil
+	}
+	return p.matchStatus(status) || (err != nil && p.matchError(err))
+}
+
+func (p Policy) matchStatus(status int) bool {
+	if status == 0 {
+		return false
+	}
+	code := strconv.Itoa(status)
+	for _, s := range p.StatusCodes {
+		if s == code || (len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] == code[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) matchError(err error) bool {
+	class := ErrorClass(err)
+	for _, e := range p.Errors {
+		if e == ErrorAny || e == class {
+			return true
+		}
+	}
+	return false
+}
+
+//ErrorClass return class of error, it is empty if error is not classified
+func ErrorClass(err error) string {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ErrorConnectFailure
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorTimeout
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorReset
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "connection refused"):
+		return ErrorConnectFailure
+	case strings.Contains(msg, "connection reset"), strings.Contains(msg, "broken pipe"):
+		return ErrorReset
+	case strings.Contains(msg, "Timeout"), strings.Contains(msg, "timeout"):
+		return ErrorTimeout
+	}
+	return ""
+}
+
+//RetryAfter return wait time in Retry-After header, which is seconds or http date
+func RetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
@@ -0,0 +1,75 @@
+package retry_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chassis/go-chassis/resilience/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Retriable(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	t.Run("empty policy retries errors", func(t *testing.T) {
+		p := retry.NewPolicy("", "", "")
+		assert.True(t, p.Retriable(0, errors.New("fake")))
+		assert.False(t, p.Retriable(http.StatusServiceUnavailable, nil))
+		assert.True(t, p.MatchMethod(http.MethodPost))
+	})
+	t.Run("status and errors", func(t *testing.T) {
+		p := retry.NewPolicy("429, 5xx", "connect-failure", "get,head")
+		assert.True(t, p.Retriable(http.StatusTooManyRequests, nil))
+		assert.True(t, p.Retriable(http.StatusBadGateway, nil))
+		assert.False(t, p.Retriable(http.StatusNotFound, nil))
+		assert.True(t, p.Retriable(0, dialErr))
+		assert.False(t, p.Retriable(0, io.EOF))
+		assert.True(t, p.MatchMethod(http.MethodGet))
+		assert.False(t, p.MatchMethod(http.MethodPost))
+		assert.True(t, p.MatchMethod(""))
+	})
+}
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, retry.ErrorConnectFailure, retry.ErrorClass(&net.OpError{Op: "dial", Err: errors.New("fake")}))
+	assert.Equal(t, retry.ErrorReset, retry.ErrorClass(io.ErrUnexpectedEOF))
+	assert.Equal(t, retry.ErrorReset, retry.ErrorClass(errors.New("read: connection reset by peer")))
+	assert.Equal(t, retry.ErrorTimeout, retry.ErrorClass(errors.New("Client.Timeout exceeded while awaiting headers")))
+	assert.Equal(t, "", retry.ErrorClass(errors.New("fake")))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+	h := http.Header{}
+	_, ok := retry.RetryAfter(h, now)
+	assert.False(t, ok)
+
+	h.Set("Retry-After", "3")
+	d, ok := retry.RetryAfter(h, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	h.Set("Retry-After", now.Add(time.Minute).UTC().Format(http.TimeFormat))
+	d, ok = retry.RetryAfter(h, now)
+	assert.True(t, ok)
+	assert.True(t, d > 58*time.Second && d <= time.Minute)
+
+	h.Set("Retry-After", "soon")
+	_, ok = retry.RetryAfter(h, now)
+	assert.False(t, ok)
+}
+
+func TestBudget(t *testing.T) {
+	b := retry.NewBudget(50, 10)
+	for n := 0; n < retry.DefaultMinTokens; n++ {
+		assert.True(t, b.Withdraw())
+	}
+	assert.False(t, b.Withdraw())
+	b.Deposit()
+	b.Deposit()
+	assert.True(t, b.Withdraw())
+	assert.False(t, b.Withdraw())
+}
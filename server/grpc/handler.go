@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"google.golang.org/grpc"
+)
+
+//serviceHandler is the last handler of provider chain, it calls the real grpc service
+type serviceHandler struct {
+	f func(*invocation.Invocation) *invocation.Response
+}
+
+//Handle call grpc service and then call back
+func (h *serviceHandler) Handle(chain *handler.Chain, inv *invocation.Invocation, cb invocation.ResponseCallBack) {
+	cb(h.f(inv))
+}
+
+//Name returns the name string
+func (h *serviceHandler) Name() string {
+	return "grpc"
+}
+
+//serverStream overrides context of grpc server stream, so that service can get the context modified by handler chain
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+//Context return invocation context
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
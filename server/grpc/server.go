@@ -0,0 +1,222 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/core/server"
+	chassisStatus "github.com/go-chassis/go-chassis/core/status"
+	"github.com/go-chassis/go-chassis/pkg/runtime"
+	"github.com/go-chassis/go-chassis/pkg/util/iputil"
+	"github.com/go-mesh/openlogging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//Name is the protocol name of grpc server
+const Name = common.ProtocolGRPC
+
+const openTLS = "?sslEnabled=true"
+
+//ErrGRPCSvcDescMissing happens if you do not give *grpc.ServiceDesc with server.WithRPCServiceDesc
+var ErrGRPCSvcDescMissing = errors.New("must use server.WithRPCServiceDesc to set *grpc.ServiceDesc")
+
+func init() {
+	server.InstallPlugin(Name, New)
+	chassisStatus.Register(Name, map[string]int{
+		chassisStatus.Unauthorized:        int(codes.Unauthenticated),
+		chassisStatus.InternalServerError: int(codes.Internal),
+		chassisStatus.ServiceUnavailable:  int(codes.Unavailable),
+		chassisStatus.TooManyRequests:     int(codes.ResourceExhausted),
+	})
+}
+
+//Server is grpc server holder
+type Server struct {
+	s    *grpc.Server
+	opts server.Options
+	mux  sync.RWMutex
+}
+
+//New create grpc server
+func New(opts server.Options) server.ProtocolServer {
+	s := &Server{
+		opts: opts,
+	}
+	gopts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+	}
+	if opts.TLSConfig != nil {
+		gopts = append(gopts, grpc.Creds(credentials.NewTLS(opts.TLSConfig)))
+	}
+	if opts.BodyLimit > 0 {
+		gopts = append(gopts, grpc.MaxRecvMsgSize(int(opts.BodyLimit)))
+	}
+	if opts.HeaderLimit > 0 {
+		gopts = append(gopts, grpc.MaxHeaderListSize(uint32(opts.HeaderLimit)))
+	}
+	if opts.Timeout > 0 {
+		gopts = append(gopts, grpc.ConnectionTimeout(opts.Timeout))
+	}
+	s.s = grpc.NewServer(gopts...)
+	return s
+}
+
+//Register register grpc services, schema is the service implementation,
+//you must give *grpc.ServiceDesc by server.WithRPCServiceDesc
+func (s *Server) Register(schema interface{}, options ...server.RegisterOption) (string, error) {
+	opts := server.RegisterOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	desc, ok := opts.RPCSvcDesc.(*grpc.ServiceDesc)
+	if !ok {
+		return "", ErrGRPCSvcDescMissing
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.s.RegisterService(desc, schema)
+	openlogging.GetLogger().Infof("grpc service registered [%s]", desc.ServiceName)
+	return desc.ServiceName, nil
+}
+
+//Start launch the grpc server
+func (s *Server) Start() error {
+	opts := s.opts
+	sslFlag := ""
+	if opts.TLSConfig != nil {
+		sslFlag = openTLS
+	}
+	//tls is handled by grpc credentials, so listen on plain tcp
+	l, lIP, lPort, err := iputil.StartListener(opts.Address, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start listener: %s", err.Error())
+	}
+	registry.InstanceEndpoints[opts.ProtocolServerName] = net.JoinHostPort(lIP, lPort) + sslFlag
+	go func() {
+		if err := s.s.Serve(l); err != nil {
+			openlogging.Error("grpc server err: " + err.Error())
+			server.ErrRuntime <- err
+		}
+	}()
+	openlogging.GetLogger().Infof("grpc server is listening at %s", registry.InstanceEndpoints[opts.ProtocolServerName])
+	return nil
+}
+
+//Stop gracefully stops the grpc server
+func (s *Server) Stop() error {
+	s.s.GracefulStop()
+	return nil
+}
+
+//String return protocol name
+func (s *Server) String() string {
+	return Name
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	h grpc.UnaryHandler) (interface{}, error) {
+	inv := Request2Invocation(ctx, req, info.FullMethod)
+	var resp interface{}
+	err := s.handle(inv, func(inv *invocation.Invocation) *invocation.Response {
+		r, err := h(inv.Ctx, inv.Args)
+		return &invocation.Response{Result: r, Err: err}
+	}, func(ir *invocation.Response) {
+		resp = ir.Result
+	})
+	return resp, err
+}
+
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	h grpc.StreamHandler) error {
+	inv := Request2Invocation(ss.Context(), ss, info.FullMethod)
+	return s.handle(inv, func(inv *invocation.Invocation) *invocation.Response {
+		return &invocation.Response{Err: h(srv, &serverStream{ServerStream: ss, ctx: inv.Ctx})}
+	}, func(ir *invocation.Response) {})
+}
+
+//handle run provider handler chain, and call real service in the end of the chain
+func (s *Server) handle(inv *invocation.Invocation, f func(*invocation.Invocation) *invocation.Response,
+	cb invocation.ResponseCallBack) error {
+	originChain := &handler.Chain{}
+	if s.opts.ChainName != "" {
+		var err error
+		originChain, err = handler.GetChain(common.Provider, s.opts.ChainName)
+		if err != nil {
+			openlogging.Error("handler chain init err.", openlogging.WithTags(openlogging.Tags{
+				"err": err.Error(),
+			}))
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	c := originChain.Clone()
+	c.AddHandler(&serviceHandler{f: f})
+	var respErr error
+	c.Next(inv, func(ir *invocation.Response) {
+		if ir.Err != nil {
+			respErr = toStatusError(ir)
+			return
+		}
+		cb(ir)
+	})
+	return respErr
+}
+
+//toStatusError keeps grpc status error as it is,
+//other errors is converted by invocation response status
+func toStatusError(ir *invocation.Response) error {
+	if _, ok := status.FromError(ir.Err); ok {
+		return ir.Err
+	}
+	code := codes.Code(ir.Status)
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+	return status.Error(code, ir.Err.Error())
+}
+
+//Request2Invocation convert grpc request to invocation,
+//full method is in format of /{service name}/{method name}
+func Request2Invocation(ctx context.Context, req interface{}, fullMethod string) *invocation.Invocation {
+	schemaID, operationID := SplitFullMethod(fullMethod)
+	m := make(map[string]string)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, v := range md {
+			if len(v) > 0 {
+				m[k] = v[0]
+			}
+		}
+	}
+	return &invocation.Invocation{
+		MicroServiceName:   runtime.ServiceName,
+		SourceMicroService: m[common.HeaderSourceName],
+		Args:               req,
+		Protocol:           common.ProtocolGRPC,
+		SchemaID:           schemaID,
+		OperationID:        operationID,
+		Ctx:                context.WithValue(ctx, common.ContextHeaderKey{}, m),
+		Metadata:           make(map[string]interface{}),
+	}
+}
+
+//SplitFullMethod split /{service name}/{method name} into service name and method name
+func SplitFullMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	i := strings.LastIndex(fullMethod, "/")
+	if i < 0 {
+		return "", fullMethod
+	}
+	return fullMethod[:i], fullMethod[i+1:]
+}
@@ -0,0 +1,146 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-chassis/go-chassis/core/common"
+	"github.com/go-chassis/go-chassis/core/handler"
+	"github.com/go-chassis/go-chassis/core/invocation"
+	"github.com/go-chassis/go-chassis/core/lager"
+	"github.com/go-chassis/go-chassis/core/registry"
+	"github.com/go-chassis/go-chassis/core/server"
+	chassisStatus "github.com/go-chassis/go-chassis/core/status"
+	"github.com/go-chassis/go-chassis/examples/schemas/helloworld"
+	chassisgrpc "github.com/go-chassis/go-chassis/server/grpc"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	lager.Init(&lager.Options{
+		LoggerLevel: "INFO",
+	})
+}
+
+type greeter interface {
+	SayHello(context.Context, *helloworld.HelloRequest) (*helloworld.HelloReply, error)
+}
+
+type greeterServer struct{}
+
+func (s *greeterServer) SayHello(ctx context.Context, in *helloworld.HelloRequest) (*helloworld.HelloReply, error) {
+	return &helloworld.HelloReply{Message: "hello " + in.Name + common.FromContext(ctx)["user"]}, nil
+}
+
+func sayHelloHandler(srv interface{}, ctx context.Context, dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(helloworld.HelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/helloworld.Greeter/SayHello"}
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(greeter).SayHello(ctx, req.(*helloworld.HelloRequest))
+	}
+	return interceptor(ctx, in, info, h)
+}
+
+var greeterDesc = &grpc.ServiceDesc{
+	ServiceName: "helloworld.Greeter",
+	HandlerType: (*greeter)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "SayHello", Handler: sayHelloHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "helloworld.proto",
+}
+
+type denyHandler struct{}
+
+func (h *denyHandler) Handle(chain *handler.Chain, inv *invocation.Invocation, cb invocation.ResponseCallBack) {
+	if inv.Headers()["deny"] != "" {
+		handler.WriteBackErr(errors.New("denied"), int(codes.PermissionDenied), cb)
+		return
+	}
+	inv.SetHeader("user", "!")
+	chain.Next(inv, cb)
+}
+
+func (h *denyHandler) Name() string {
+	return "grpc-deny"
+}
+
+func TestSplitFullMethod(t *testing.T) {
+	s, m := chassisgrpc.SplitFullMethod("/helloworld.Greeter/SayHello")
+	assert.Equal(t, "helloworld.Greeter", s)
+	assert.Equal(t, "SayHello", m)
+	s, m = chassisgrpc.SplitFullMethod("SayHello")
+	assert.Equal(t, "", s)
+	assert.Equal(t, "SayHello", m)
+}
+
+func TestStatus(t *testing.T) {
+	assert.Equal(t, int(codes.ResourceExhausted), chassisStatus.Status(chassisgrpc.Name, chassisStatus.TooManyRequests))
+	assert.Equal(t, int(codes.Unavailable), chassisStatus.Status(chassisgrpc.Name, chassisStatus.ServiceUnavailable))
+}
+
+func TestRequest2Invocation(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(common.HeaderSourceName, "consumer", "user", "peter"))
+	inv := chassisgrpc.Request2Invocation(ctx, "req", "/helloworld.Greeter/SayHello")
+	assert.Equal(t, "consumer", inv.SourceMicroService)
+	assert.Equal(t, "helloworld.Greeter", inv.SchemaID)
+	assert.Equal(t, "SayHello", inv.OperationID)
+	assert.Equal(t, common.ProtocolGRPC, inv.Protocol)
+	assert.Equal(t, "peter", inv.Headers()["user"])
+}
+
+func TestServer_Register(t *testing.T) {
+	f, err := server.GetServerFunc(chassisgrpc.Name)
+	assert.NoError(t, err)
+	s := f(server.Options{Address: "127.0.0.1:0"})
+	assert.Equal(t, chassisgrpc.Name, s.String())
+
+	_, err = s.Register(&greeterServer{})
+	assert.Equal(t, chassisgrpc.ErrGRPCSvcDescMissing, err)
+
+	id, err := s.Register(&greeterServer{}, server.WithRPCServiceDesc(greeterDesc))
+	assert.NoError(t, err)
+	assert.Equal(t, "helloworld.Greeter", id)
+}
+
+func TestServer_Start(t *testing.T) {
+	assert.NoError(t, handler.RegisterHandler("grpc-deny", func() handler.Handler { return &denyHandler{} }))
+	assert.NoError(t, handler.CreateChains(common.Provider, map[string]string{"grpc": "grpc-deny"}))
+
+	f, err := server.GetServerFunc(chassisgrpc.Name)
+	assert.NoError(t, err)
+	s := f(server.Options{Address: "127.0.0.1:0", ProtocolServerName: "grpc", ChainName: "grpc"})
+	_, err = s.Register(&greeterServer{}, server.WithRPCServiceDesc(greeterDesc))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Start())
+	defer s.Stop()
+
+	conn, err := grpc.Dial(registry.InstanceEndpoints["grpc"], grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("call service through provider chain", func(t *testing.T) {
+		reply := &helloworld.HelloReply{}
+		err := conn.Invoke(context.Background(), "/helloworld.Greeter/SayHello",
+			&helloworld.HelloRequest{Name: "peter"}, reply)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello peter!", reply.Message)
+	})
+	t.Run("provider chain rejects request", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "deny", "true")
+		err := conn.Invoke(ctx, "/helloworld.Greeter/SayHello",
+			&helloworld.HelloRequest{Name: "peter"}, &helloworld.HelloReply{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
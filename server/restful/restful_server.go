@@ -36,8 +36,12 @@ const (
 	DefaultProfilePath      = "profile"
 	ProfileRouteRuleSubPath = "route-rule"
 	ProfileDiscoverySubPath = "discovery"
-	MimeFile                = "application/octet-stream"
-	MimeMult                = "multipart/form-data"
+	ProfileSnapshotSubPath  = "snapshot"
+	//sub paths of route rule profile
+	ProfileExplainSubPath  = "explain"
+	ProfileValidateSubPath = "validate"
+	MimeFile               = "application/octet-stream"
+	MimeMult               = "multipart/form-data"
 )
 
 const openTLS = "?sslEnabled=true"
@@ -89,9 +93,21 @@ func addProfileRoutes(ws *restful.WebService) {
 	openlogging.Info("Enabled profile route-rule API on " + profileRouteRulePath)
 	ws.Route(ws.GET(profileRouteRulePath).To(profile.HTTPHandleRouteRuleFunc))
 
+	profileExplainPath := profileRouteRulePath + "/" + ProfileExplainSubPath
+	openlogging.Info("Enabled profile route-rule explain API on " + profileExplainPath)
+	ws.Route(ws.POST(profileExplainPath).To(profile.HTTPHandleRouteRuleExplainFunc))
+
+	profileValidatePath := profileRouteRulePath + "/" + ProfileValidateSubPath
+	openlogging.Info("Enabled profile route-rule validate API on " + profileValidatePath)
+	ws.Route(ws.GET(profileValidatePath).To(profile.HTTPHandleRouteRuleValidateFunc))
+
 	profileDiscoveryPath := profilePath + "/" + ProfileDiscoverySubPath
 	openlogging.Info("Enabled profile discovery API on " + profileDiscoveryPath)
 	ws.Route(ws.GET(profileDiscoveryPath).To(profile.HTTPHandleDiscoveryFunc))
+
+	profileSnapshotPath := profilePath + "/" + ProfileSnapshotSubPath
+	openlogging.Info("Enabled profile snapshot API on " + profileSnapshotPath)
+	ws.Route(ws.GET(profileSnapshotPath).To(profile.HTTPHandleSnapshotFunc))
 }
 
 // HTTPRequest2Invocation convert http request to uniform invocation data format